@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates"
+	"github.com/r2dtools/sslbot/internal/certificates/request"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var AdoptCertificateCmd = &cobra.Command{
+	Use:   "adopt-cert",
+	Short: "Show unmanaged certificates used by hosts or adopt one of them to the default storage",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conf, err := config.GetConfig()
+
+		if err != nil {
+			return err
+		}
+
+		log, err := logger.NewLogger(conf)
+
+		if err != nil {
+			return err
+		}
+
+		certManager, err := certificates.CreateCertificateManager(
+			conf,
+			webserver.CreateWebServer,
+			reverter.CreateReverter,
+			log,
+			&sync.Mutex{},
+		)
+
+		if err != nil {
+			return err
+		}
+
+		if adoptCertPath != "" {
+			adoptRequest := request.AdoptRequest{
+				CertPath:      adoptCertPath,
+				CertName:      adoptCertName,
+				Link:          adoptLink,
+				RewriteVhosts: adoptRewrite,
+			}
+			cert, err := certManager.Adopt(adoptRequest)
+
+			if err != nil {
+				return err
+			}
+
+			return writeOutput(cmd, fmt.Sprintf("Certificate %s adopted as %s\n", cert.CN, adoptCertName))
+		}
+
+		unmanagedCerts, err := certManager.GetUnmanagedCertificates()
+
+		if err != nil {
+			return err
+		}
+
+		if isJson {
+			output, err := json.Marshal(unmanagedCerts)
+
+			if err != nil {
+				return err
+			}
+
+			return writeOutput(cmd, string(output))
+		}
+
+		var outputParts []string
+
+		for _, unmanagedCert := range unmanagedCerts {
+			output, err := yaml.Marshal(unmanagedCert)
+
+			if err != nil {
+				return err
+			}
+
+			outputParts = append(outputParts, string(output))
+		}
+
+		return writeOutput(cmd, strings.Join(outputParts, "\n"))
+	},
+}
+
+var adoptCertPath string
+var adoptCertName string
+var adoptLink bool
+var adoptRewrite bool
+
+func init() {
+	AdoptCertificateCmd.PersistentFlags().StringVarP(&adoptCertPath, "path", "p", "", "path of the unmanaged certificate to adopt")
+	AdoptCertificateCmd.PersistentFlags().StringVarP(&adoptCertName, "name", "n", "", "certificate name in the default storage")
+	AdoptCertificateCmd.PersistentFlags().BoolVar(&adoptLink, "link", false, "link the certificate to the storage instead of copying")
+	AdoptCertificateCmd.PersistentFlags().BoolVar(&adoptRewrite, "rewrite", false, "switch hosts to the storage certificate path")
+}
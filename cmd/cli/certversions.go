@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var CertVersionsCmd = &cobra.Command{
+	Use:   "cert-versions",
+	Short: "Show previous versions of a storage certificate or restore one of them",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conf, err := config.GetConfig()
+
+		if err != nil {
+			return err
+		}
+
+		log, err := logger.NewLogger(conf)
+
+		if err != nil {
+			return err
+		}
+
+		if versionsCertName == "" {
+			return fmt.Errorf("certificate name is not specified")
+		}
+
+		certManager, err := certificates.CreateCertificateManager(
+			conf,
+			webserver.CreateWebServer,
+			reverter.CreateReverter,
+			log,
+			&sync.Mutex{},
+		)
+
+		if err != nil {
+			return err
+		}
+
+		if restoreVersionID != "" {
+			cert, err := certManager.RestoreStorageCertificateVersion(versionsCertName, versionsStorageType, restoreVersionID)
+
+			if err != nil {
+				return err
+			}
+
+			return writeOutput(cmd, fmt.Sprintf("Certificate %s restored to version %s, valid to %s\n", versionsCertName, restoreVersionID, cert.ValidTo))
+		}
+
+		versions, err := certManager.GetStorageCertificateVersions(versionsCertName, versionsStorageType)
+
+		if err != nil {
+			return err
+		}
+
+		if isJson {
+			output, err := json.Marshal(versions)
+
+			if err != nil {
+				return err
+			}
+
+			return writeOutput(cmd, string(output))
+		}
+
+		var outputParts []string
+
+		for _, version := range versions {
+			output, err := yaml.Marshal(version)
+
+			if err != nil {
+				return err
+			}
+
+			outputParts = append(outputParts, string(output))
+		}
+
+		return writeOutput(cmd, strings.Join(outputParts, "\n"))
+	},
+}
+
+var versionsCertName string
+var versionsStorageType string
+var restoreVersionID string
+
+func init() {
+	CertVersionsCmd.PersistentFlags().StringVarP(&versionsCertName, "name", "n", "", "certificate name")
+	CertVersionsCmd.PersistentFlags().StringVarP(&versionsStorageType, "storage", "s", string(certificates.Default), "certificate storage (default|lego|certbot)")
+	CertVersionsCmd.PersistentFlags().StringVar(&restoreVersionID, "restore", "", "restore the certificate version and redeploy it to the hosts")
+}
@@ -33,7 +33,7 @@ var CommonDirCmd = &cobra.Command{
 			return fmt.Errorf("domain is not specified")
 		}
 
-		supportedWebServerCodes := webserver.GetSupportedWebServers()
+		supportedWebServerCodes := webserver.GetWebServers(config.ToMap())
 
 		if webServerCode == "" {
 			return fmt.Errorf("webserver is not specified")
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"path/filepath"
 	"slices"
+	"sync"
 
 	"github.com/r2dtools/sslbot/config"
 	"github.com/r2dtools/sslbot/internal/certificates/deploy"
+	"github.com/r2dtools/sslbot/internal/certificates/redirect"
+	"github.com/r2dtools/sslbot/internal/certificates/tlsprofile"
 	"github.com/r2dtools/sslbot/internal/logger"
 	"github.com/r2dtools/sslbot/internal/webserver"
 	"github.com/r2dtools/sslbot/internal/webserver/hostmng"
@@ -30,13 +33,13 @@ var DeployCertificateCmd = &cobra.Command{
 			return err
 		}
 
-		supportedWebServerCodes := webserver.GetSupportedWebServers()
+		supportedWebServerCodes := webserver.GetWebServers(config.ToMap())
 
 		if webServerCode == "" {
 			return fmt.Errorf("webserver is not specified")
 		}
 
-		if serverName == "" {
+		if serverName == "" && streamServerKey == "" {
 			return fmt.Errorf("domain is not specified")
 		}
 
@@ -44,91 +47,273 @@ var DeployCertificateCmd = &cobra.Command{
 			return fmt.Errorf("invalid webserver %s", webServerCode)
 		}
 
-		webServer, err := webserver.CreateWebServer(webServerCode, config.ToMap())
+		if tlsProfile != "" {
+			if _, err := tlsprofile.GetProfile(tlsProfile); err != nil {
+				return err
+			}
+		}
+
+		webServerFactory := func() (webserver.WebServer, error) {
+			return webserver.CreateWebServer(webServerCode, config.ToMap())
+		}
+
+		if !dryRun {
+			return deployCertificate(webServerFactory, log)
+		}
+
+		webServer, err := webServerFactory()
 
 		if err != nil {
 			return err
 		}
 
-		processManager, err := webServer.GetProcessManager()
+		sandbox, err := webserver.CreateSandbox(webServer)
 
 		if err != nil {
 			return err
 		}
 
-		vhost, err := webServer.GetVhostByName(serverName)
+		defer sandbox.Remove()
 
-		if err != nil {
+		if err = deployCertificate(sandbox.CreateWebServer, log); err != nil {
 			return err
 		}
 
-		sReverter, err := reverter.CreateReverter(webServer, log)
+		diffs, err := sandbox.GetDiffs()
 
 		if err != nil {
 			return err
 		}
 
-		if vhost == nil {
-			return fmt.Errorf("could not find virtual host '%s'", serverName)
+		for _, diff := range diffs {
+			fmt.Print(diff.Diff)
 		}
 
-		deployer, err := deploy.GetCertificateDeployer(webServer, sReverter, log)
+		return nil
+	},
+}
+
+// deployCertificate deploys the certificate to the host or the stream server of the webserver created by the factory
+func deployCertificate(webServerFactory func() (webserver.WebServer, error), log logger.Logger) error {
+	webServer, err := webServerFactory()
 
-		if err != nil {
-			return err
+	if err != nil {
+		return err
+	}
+
+	if streamServerKey != "" {
+		return deployStreamCertificate(webServer, log)
+	}
+
+	processManager, err := webServer.GetProcessManager()
+
+	if err != nil {
+		return err
+	}
+
+	vhost, err := webServer.GetVhostByName(serverName)
+
+	if err != nil {
+		return err
+	}
+
+	sReverter, err := reverter.CreateReverter(webServer, log)
+
+	if err != nil {
+		return err
+	}
+
+	if vhost == nil {
+		return fmt.Errorf("could not find virtual host '%s'", serverName)
+	}
+
+	deployer, err := deploy.GetCertificateDeployer(webServer, sReverter, log)
+
+	if err != nil {
+		return err
+	}
+
+	sslConfigFilePath, originEnabledConfigFilePath, err := deployer.DeployCertificate(vhost, certPath, certKeyPath)
+
+	if err != nil {
+		if rErr := sReverter.Rollback(); rErr != nil {
+			log.Error(fmt.Sprintf("failed to rallback webserver configuration on cert deploy: %v", rErr))
 		}
 
-		sslConfigFilePath, originEnabledConfigFilePath, err := deployer.DeployCertificate(vhost, certPath, certKeyPath)
+		return err
+	}
 
-		if err != nil {
-			if rErr := sReverter.Rollback(); rErr != nil {
-				log.Error(fmt.Sprintf("failed to rallback webserver configuration on cert deploy: %v", rErr))
-			}
+	hostManager, err := hostmng.CreateHostManager(webServer)
 
-			return err
+	if err != nil {
+		return err
+	}
+
+	enabledConfigPath, err := hostManager.Enable(sslConfigFilePath, filepath.Dir(originEnabledConfigFilePath))
+
+	if err != nil {
+		if rErr := sReverter.Rollback(); rErr != nil {
+			log.Error(fmt.Sprintf("failed to rallback webserver configuration on host enabling: %v", rErr))
 		}
 
-		hostManager, err := hostmng.CreateHostManager(webServer)
+		return err
+	}
 
-		if err != nil {
-			return err
+	sReverter.AddConfigToDisable(enabledConfigPath)
+
+	if err = webserver.TestConfig(webServer); err != nil {
+		if rErr := sReverter.Rollback(); rErr != nil {
+			log.Error(fmt.Sprintf("failed to rallback webserver configuration on config test: %v", rErr))
 		}
 
-		enabledConfigPath, err := hostManager.Enable(sslConfigFilePath, filepath.Dir(originEnabledConfigFilePath))
+		return err
+	}
 
-		if err != nil {
-			if rErr := sReverter.Rollback(); rErr != nil {
-				log.Error(fmt.Sprintf("failed to rallback webserver configuration on host enabling: %v", rErr))
-			}
+	if err = processManager.Reload(); err != nil {
+		if rErr := sReverter.Rollback(); rErr != nil {
+			log.Error(fmt.Sprintf("failed to rallback webserver configuration on webserver reload: %v", rErr))
+		}
 
-			return err
+		return err
+	}
+
+	if err = sReverter.Commit(); err != nil {
+		if rErr := sReverter.Rollback(); rErr != nil {
+			log.Error(fmt.Sprintf("failed to commit webserver configuration: %v", rErr))
 		}
+	}
 
-		sReverter.AddConfigToDisable(enabledConfigPath)
+	if !enableRedirect && tlsProfile == "" {
+		return nil
+	}
 
-		if err = processManager.Reload(); err != nil {
-			if rErr := sReverter.Rollback(); rErr != nil {
-				log.Error(fmt.Sprintf("failed to rallback webserver configuration on webserver reload: %v", rErr))
-			}
+	// the config is parsed again to include the deployed ssl host
+	webServer, err = webServerFactory()
+
+	if err != nil {
+		return err
+	}
 
+	if enableRedirect {
+		if err = enableHostRedirect(webServer, log); err != nil {
 			return err
 		}
+	}
 
-		if err = sReverter.Commit(); err != nil {
-			if rErr := sReverter.Rollback(); rErr != nil {
-				log.Error(fmt.Sprintf("failed to commit webserver configuration: %v", rErr))
-			}
+	if tlsProfile != "" {
+		return applyHostTlsProfile(webServer, log)
+	}
+
+	return nil
+}
+
+func enableHostRedirect(webServer webserver.WebServer, log logger.Logger) error {
+	sReverter, err := reverter.CreateReverter(webServer, log)
+
+	if err != nil {
+		return err
+	}
+
+	redirectCommand, err := redirect.CreateRedirectChangeCommand(webServer, sReverter, log, &sync.Mutex{})
+
+	if err != nil {
+		return err
+	}
+
+	return redirectCommand.EnableRedirect(serverName)
+}
+
+func applyHostTlsProfile(webServer webserver.WebServer, log logger.Logger) error {
+	sReverter, err := reverter.CreateReverter(webServer, log)
+
+	if err != nil {
+		return err
+	}
+
+	tlsProfileCommand, err := tlsprofile.CreateTlsProfileChangeCommand(webServer, sReverter, log, &sync.Mutex{})
+
+	if err != nil {
+		return err
+	}
+
+	return tlsProfileCommand.ApplyTlsProfile(serverName, tlsProfile)
+}
+
+func deployStreamCertificate(webServer webserver.WebServer, log logger.Logger) error {
+	streamWebServer, ok := webServer.(webserver.StreamWebServer)
+
+	if !ok {
+		return fmt.Errorf("webserver %s does not support stream servers", webServer.GetCode())
+	}
+
+	server, err := streamWebServer.GetStreamServer(streamServerKey)
+
+	if err != nil {
+		return err
+	}
+
+	if server == nil {
+		return fmt.Errorf("could not find stream server '%s'", streamServerKey)
+	}
+
+	processManager, err := webServer.GetProcessManager()
+
+	if err != nil {
+		return err
+	}
+
+	sReverter, err := reverter.CreateReverter(webServer, log)
+
+	if err != nil {
+		return err
+	}
+
+	deployer, err := deploy.GetStreamCertificateDeployer(webServer, sReverter, log)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = deployer.DeployStreamCertificate(server, certPath, certKeyPath); err != nil {
+		if rErr := sReverter.Rollback(); rErr != nil {
+			log.Error(fmt.Sprintf("failed to rallback webserver configuration on cert deploy: %v", rErr))
 		}
 
-		return nil
-	},
+		return err
+	}
+
+	if err = webserver.TestConfig(webServer); err != nil {
+		if rErr := sReverter.Rollback(); rErr != nil {
+			log.Error(fmt.Sprintf("failed to rallback webserver configuration on config test: %v", rErr))
+		}
+
+		return err
+	}
+
+	if err = processManager.Reload(); err != nil {
+		if rErr := sReverter.Rollback(); rErr != nil {
+			log.Error(fmt.Sprintf("failed to rallback webserver configuration on webserver reload: %v", rErr))
+		}
+
+		return err
+	}
+
+	return sReverter.Commit()
 }
 
 var certPath string
 var certKeyPath string
+var streamServerKey string
+var enableRedirect bool
+var tlsProfile string
+var dryRun bool
 
 func init() {
 	DeployCertificateCmd.PersistentFlags().StringVarP(&serverName, "domain", "d", "", "domain to deploy a certificate")
 	DeployCertificateCmd.PersistentFlags().StringVarP(&certPath, "cert", "c", "", "path to a certificate file")
 	DeployCertificateCmd.PersistentFlags().StringVarP(&certKeyPath, "key", "k", "", "path to a certificate key path")
+	DeployCertificateCmd.PersistentFlags().StringVarP(&streamServerKey, "stream", "s", "", "listen address or name of a stream server to deploy a certificate")
+	DeployCertificateCmd.PersistentFlags().BoolVar(&enableRedirect, "redirect", false, "enable HTTP to HTTPS redirect for the domain")
+	DeployCertificateCmd.PersistentFlags().StringVar(&tlsProfile, "tls-profile", "", "TLS profile to apply to the domain (modern|intermediate|old)")
+	DeployCertificateCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "print a unified diff of config changes without applying them")
 }
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates"
+	"github.com/r2dtools/sslbot/internal/certificates/tlsprofile"
 	"github.com/r2dtools/sslbot/internal/dto"
 	"github.com/r2dtools/sslbot/internal/logger"
 	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -30,7 +34,7 @@ var HostsCmd = &cobra.Command{
 			return err
 		}
 
-		supportedWebServerCodes := webserver.GetSupportedWebServers()
+		supportedWebServerCodes := webserver.GetWebServers(conf.ToMap())
 		webServerCodes := supportedWebServerCodes
 
 		if webServerCode != "" {
@@ -41,6 +45,18 @@ var HostsCmd = &cobra.Command{
 			webServerCodes = []string{webServerCode}
 		}
 
+		certManager, err := certificates.CreateCertificateManager(
+			conf,
+			webserver.CreateWebServer,
+			reverter.CreateReverter,
+			log,
+			&sync.Mutex{},
+		)
+
+		if err != nil {
+			return err
+		}
+
 		var vhosts []dto.VirtualHost
 
 		for _, webServerCode := range webServerCodes {
@@ -58,7 +74,13 @@ var HostsCmd = &cobra.Command{
 				return err
 			}
 
-			vhosts = append(vhosts, hosts...)
+			vhosts = append(vhosts, tlsprofile.ResolveVhostTlsProfiles(webServer, hosts)...)
+		}
+
+		vhosts, err = certManager.ResolveVhostCertificates(vhosts)
+
+		if err != nil {
+			return err
 		}
 
 		if isJson {
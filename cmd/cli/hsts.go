@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates/hsts"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/spf13/cobra"
+)
+
+var HstsCmd = &cobra.Command{
+	Use:   "hsts",
+	Short: "Manage HSTS policy for a host",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := config.GetConfig()
+
+		if err != nil {
+			return err
+		}
+
+		log, err := logger.NewLogger(config)
+
+		if err != nil {
+			return err
+		}
+
+		if serverName == "" {
+			return fmt.Errorf("domain is not specified")
+		}
+
+		supportedWebServerCodes := webserver.GetWebServers(config.ToMap())
+
+		if webServerCode == "" {
+			return fmt.Errorf("webserver is not specified")
+		}
+
+		if !slices.Contains(supportedWebServerCodes, webServerCode) {
+			return fmt.Errorf("invalid webserver %s", webServerCode)
+		}
+
+		webServer, err := webserver.CreateWebServer(webServerCode, config.ToMap())
+
+		if err != nil {
+			return err
+		}
+
+		sReverter, err := reverter.CreateReverter(webServer, log)
+
+		if err != nil {
+			return err
+		}
+
+		hstsQuery, err := hsts.CreateHstsStatusQuery(webServer)
+
+		if err != nil {
+			return err
+		}
+
+		hstsCommand, err := hsts.CreateHstsChangeCommand(webServer, sReverter, log, &sync.Mutex{})
+
+		if err != nil {
+			return err
+		}
+
+		if enableHsts {
+			err = hstsCommand.EnableHsts(serverName, hsts.Hsts{
+				MaxAge:            hstsMaxAge,
+				IncludeSubDomains: hstsIncludeSubDomains,
+				Preload:           hstsPreload,
+			})
+		} else if disableHsts {
+			err = hstsCommand.DisableHsts(serverName)
+		} else {
+			policy := hstsQuery.GetHstsStatus(serverName)
+
+			if !policy.Enabled {
+				fmt.Printf("HSTS status for host %s: false\n", serverName)
+
+				return nil
+			}
+
+			fmt.Printf("HSTS status for host %s: true (%s)\n", serverName, hsts.FormatHeaderValue(policy))
+
+			return nil
+		}
+
+		return err
+	},
+}
+
+var enableHsts bool
+var disableHsts bool
+var hstsMaxAge int
+var hstsIncludeSubDomains bool
+var hstsPreload bool
+
+func init() {
+	HstsCmd.PersistentFlags().StringVarP(&serverName, "domain", "d", "", "domain to manage HSTS policy")
+	HstsCmd.PersistentFlags().BoolVar(&enableHsts, "enable", false, "enable HSTS or update its policy")
+	HstsCmd.PersistentFlags().BoolVar(&disableHsts, "disable", false, "disable HSTS")
+	HstsCmd.PersistentFlags().IntVar(&hstsMaxAge, "max-age", hsts.DefaultMaxAge, "HSTS max-age in seconds")
+	HstsCmd.PersistentFlags().BoolVar(&hstsIncludeSubDomains, "include-subdomains", false, "add includeSubDomains directive")
+	HstsCmd.PersistentFlags().BoolVar(&hstsPreload, "preload", false, "add preload directive")
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/utils"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/spf13/cobra"
+)
+
+var ImportCertificateCmd = &cobra.Command{
+	Use:   "import-cert",
+	Short: "Import PEM or PKCS#12 (PFX) certificate to a storage",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := config.GetConfig()
+
+		if err != nil {
+			return err
+		}
+
+		log, err := logger.NewLogger(config)
+
+		if err != nil {
+			return err
+		}
+
+		if importFilePath == "" {
+			return fmt.Errorf("certificate file is not specified")
+		}
+
+		data, err := os.ReadFile(importFilePath)
+
+		if err != nil {
+			return err
+		}
+
+		if importCertName == "" {
+			importCertName = strings.TrimSuffix(filepath.Base(importFilePath), filepath.Ext(importFilePath))
+		}
+
+		certManager, err := certificates.CreateCertificateManager(
+			config,
+			webserver.CreateWebServer,
+			reverter.CreateReverter,
+			log,
+			&sync.Mutex{},
+		)
+
+		if err != nil {
+			return err
+		}
+
+		var certPath string
+		var addedIntermediates []string
+
+		if block, _ := pem.Decode(data); block != nil {
+			certPath, addedIntermediates, err = certManager.AddCertificateToStorage(importCertName, string(data), importStorageType)
+		} else {
+			certPath, addedIntermediates, err = certManager.ImportPkcs12StorageCertificate(importCertName, data, importPassword, importStorageType)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		cert, err := utils.GetCertificateFromFile(certPath)
+
+		if err != nil {
+			return err
+		}
+
+		for _, intermediate := range addedIntermediates {
+			log.Info("intermediate certificate added to the chain: %s", intermediate)
+		}
+
+		output, err := json.MarshalIndent(cert, "", " ")
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(output))
+
+		return nil
+	},
+}
+
+var importFilePath string
+var importCertName string
+var importPassword string
+var importStorageType string
+
+func init() {
+	ImportCertificateCmd.PersistentFlags().StringVarP(&importFilePath, "file", "f", "", "path to a PEM or PKCS#12 (PFX) certificate file")
+	ImportCertificateCmd.PersistentFlags().StringVarP(&importCertName, "name", "n", "", "certificate name in the storage (file name by default)")
+	ImportCertificateCmd.PersistentFlags().StringVarP(&importPassword, "password", "p", "", "PKCS#12 password")
+	ImportCertificateCmd.PersistentFlags().StringVarP(&importStorageType, "storage", "s", string(certificates.Default), "storage to import the certificate to (default|s3)")
+}
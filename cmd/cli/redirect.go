@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates/redirect"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/spf13/cobra"
+)
+
+var RedirectCmd = &cobra.Command{
+	Use:   "redirect",
+	Short: "Manage HTTP to HTTPS redirect for a host",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := config.GetConfig()
+
+		if err != nil {
+			return err
+		}
+
+		log, err := logger.NewLogger(config)
+
+		if err != nil {
+			return err
+		}
+
+		if serverName == "" {
+			return fmt.Errorf("domain is not specified")
+		}
+
+		supportedWebServerCodes := webserver.GetWebServers(config.ToMap())
+
+		if webServerCode == "" {
+			return fmt.Errorf("webserver is not specified")
+		}
+
+		if !slices.Contains(supportedWebServerCodes, webServerCode) {
+			return fmt.Errorf("invalid webserver %s", webServerCode)
+		}
+
+		webServer, err := webserver.CreateWebServer(webServerCode, config.ToMap())
+
+		if err != nil {
+			return err
+		}
+
+		sReverter, err := reverter.CreateReverter(webServer, log)
+
+		if err != nil {
+			return err
+		}
+
+		redirectQuery, err := redirect.CreateRedirectStatusQuery(webServer)
+
+		if err != nil {
+			return err
+		}
+
+		redirectCommand, err := redirect.CreateRedirectChangeCommand(webServer, sReverter, log, &sync.Mutex{})
+
+		if err != nil {
+			return err
+		}
+
+		if enableHttpsRedirect {
+			err = redirectCommand.EnableRedirect(serverName)
+		} else if disableHttpsRedirect {
+			err = redirectCommand.DisableRedirect(serverName)
+		} else {
+			fmt.Printf("Redirect status for host %s: %t\n", serverName, redirectQuery.GetRedirectStatus(serverName).Enabled)
+
+			return nil
+		}
+
+		return err
+	},
+}
+
+var enableHttpsRedirect bool
+var disableHttpsRedirect bool
+
+func init() {
+	RedirectCmd.PersistentFlags().StringVarP(&serverName, "domain", "d", "", "domain to enable redirect")
+	RedirectCmd.PersistentFlags().BoolVar(&enableHttpsRedirect, "enable", false, "enable redirect")
+	RedirectCmd.PersistentFlags().BoolVar(&disableHttpsRedirect, "disable", false, "disable redirect")
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates"
+	"github.com/r2dtools/sslbot/internal/certificates/acme"
+	"github.com/r2dtools/sslbot/internal/certificates/request"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/spf13/cobra"
+)
+
+var ReissueCertificateCmd = &cobra.Command{
+	Use:   "reissue-cert",
+	Short: "Reissue domain certificate with domain names it does not cover",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := config.GetConfig()
+
+		if err != nil {
+			return err
+		}
+
+		log, err := logger.NewLogger(config)
+
+		if err != nil {
+			return err
+		}
+
+		if serverName == "" {
+			return fmt.Errorf("domain is not specified")
+		}
+
+		supportedWebServerCodes := webserver.GetWebServers(config.ToMap())
+
+		if webServerCode == "" {
+			return fmt.Errorf("webserver is not specified")
+		}
+
+		if !slices.Contains(supportedWebServerCodes, webServerCode) {
+			return fmt.Errorf("invalid webserver %s", webServerCode)
+		}
+
+		certManager, err := certificates.CreateCertificateManager(
+			config,
+			webserver.CreateWebServer,
+			reverter.CreateReverter,
+			log,
+			&sync.Mutex{},
+		)
+
+		if err != nil {
+			return err
+		}
+
+		cert, err := certManager.Reissue(request.ReissueRequest{
+			ServerName:    serverName,
+			WebServer:     webServerCode,
+			Email:         email,
+			ChallengeType: reissueChallengeType,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(cert, "", " ")
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(data))
+
+		return nil
+	},
+}
+
+var reissueChallengeType string
+
+func init() {
+	ReissueCertificateCmd.PersistentFlags().StringVarP(&serverName, "domain", "d", "", "domain to reissue a certificate for")
+	ReissueCertificateCmd.PersistentFlags().StringVarP(&email, "email", "e", "", "certificate email address")
+	ReissueCertificateCmd.PersistentFlags().StringVar(&reissueChallengeType, "challenge", acme.HttpChallengeTypeCode, "challenge type: http or dns")
+}
@@ -22,12 +22,22 @@ func Create() *cobra.Command {
 	cli.AddCommand(ServeCmd)
 	cli.AddCommand(VersionCmd)
 	cli.AddCommand(HostsCmd)
+	cli.AddCommand(StreamsCmd)
 	cli.AddCommand(DeployCertificateCmd)
+	cli.AddCommand(UndeployCertificateCmd)
 	cli.AddCommand(IssueCertificateCmd)
+	cli.AddCommand(ReissueCertificateCmd)
 	cli.AddCommand(GenerateTokenCmd)
 	cli.AddCommand(CommonDirCmd)
+	cli.AddCommand(RedirectCmd)
+	cli.AddCommand(HstsCmd)
+	cli.AddCommand(TlsProfileCmd)
 	cli.AddCommand(ShowTokenCmd)
-	cli.PersistentFlags().StringVarP(&webServerCode, "webserver", "w", "", "webserver (nginx|apache)")
+	cli.AddCommand(ImportCertificateCmd)
+	cli.AddCommand(CertVersionsCmd)
+	cli.AddCommand(AdoptCertificateCmd)
+	cli.AddCommand(StorageCmd)
+	cli.PersistentFlags().StringVarP(&webServerCode, "webserver", "w", "", "webserver (nginx|apache|haproxy|lighttpd|traefik) or webserver instance name")
 
 	return cli
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/r2dtools/sslbot/cmd/tcp/router"
 	"github.com/r2dtools/sslbot/config"
 	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
 	"github.com/spf13/cobra"
 )
 
@@ -28,8 +29,21 @@ var ServeCmd = &cobra.Command{
 		}
 
 		mx := &sync.Mutex{}
-		mainHandler := handler.CreateMainHandler(conf, logger, mx)
-		certificatesHandler, err := handler.CreateCertificatesHandler(conf, logger, mx)
+		webServerCache, err := webserver.CreateWebServerCache()
+
+		if err != nil {
+			return err
+		}
+
+		defer webServerCache.Close()
+
+		mainHandler, err := handler.CreateMainHandler(conf, logger, mx, webServerCache)
+
+		if err != nil {
+			return err
+		}
+
+		certificatesHandler, err := handler.CreateCertificatesHandler(conf, logger, mx, webServerCache)
 
 		if err != nil {
 			return err
@@ -48,8 +62,15 @@ var ServeCmd = &cobra.Command{
 
 		conf.OnChange(func() {
 			logger.Info("reload router ...")
-			mainHandler = handler.CreateMainHandler(conf, logger, mx)
-			certificatesHandler, err = handler.CreateCertificatesHandler(conf, logger, mx)
+			mainHandler, err = handler.CreateMainHandler(conf, logger, mx, webServerCache)
+
+			if err != nil {
+				logger.Error("router reload failed: %v", err)
+
+				return
+			}
+
+			certificatesHandler, err = handler.CreateCertificatesHandler(conf, logger, mx, webServerCache)
 
 			if err != nil {
 				logger.Error("router reload failed: %v", err)
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/spf13/cobra"
+)
+
+var StorageCmd = &cobra.Command{
+	Use:   "storage",
+	Short: "Manage certificate storages",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Usage()
+	},
+}
+
+var StoragePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired, unreferenced or superseded certificates from storages",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conf, err := config.GetConfig()
+
+		if err != nil {
+			return err
+		}
+
+		log, err := logger.NewLogger(conf)
+
+		if err != nil {
+			return err
+		}
+
+		certManager, err := certificates.CreateCertificateManager(
+			conf,
+			webserver.CreateWebServer,
+			reverter.CreateReverter,
+			log,
+			&sync.Mutex{},
+		)
+
+		if err != nil {
+			return err
+		}
+
+		candidates, err := certManager.GetPruneCandidates(pruneReasons)
+
+		if err != nil {
+			return err
+		}
+
+		if isJson && pruneDryRun {
+			output, err := json.Marshal(candidates)
+
+			if err != nil {
+				return err
+			}
+
+			return writeOutput(cmd, string(output))
+		}
+
+		if len(candidates) == 0 {
+			return writeOutput(cmd, "Nothing to prune\n")
+		}
+
+		var keys []string
+
+		for _, candidate := range candidates {
+			keys = append(keys, candidate.Key())
+			writeOutput(cmd, fmt.Sprintf(
+				"%s\t%s\tvalid to %s\t%s\n",
+				candidate.StorageType,
+				candidate.CertName,
+				candidate.Certificate.ValidTo,
+				strings.Join(candidate.Reasons, ", "),
+			))
+		}
+
+		if pruneDryRun {
+			return nil
+		}
+
+		if !pruneConfirmed {
+			writeOutput(cmd, fmt.Sprintf("Remove %d certificate(s)? [y/N]: ", len(candidates)))
+			answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+
+			if answer != "y" && answer != "yes" {
+				return writeOutput(cmd, "Aborted\n")
+			}
+		}
+
+		removed, err := certManager.Prune(pruneReasons, keys)
+
+		if err != nil {
+			return err
+		}
+
+		return writeOutput(cmd, fmt.Sprintf("%d certificate(s) removed\n", len(removed)))
+	},
+}
+
+var pruneDryRun bool
+var pruneConfirmed bool
+var pruneReasons []string
+
+func init() {
+	StoragePruneCmd.PersistentFlags().BoolVar(&pruneDryRun, "dry-run", false, "only show certificates to remove")
+	StoragePruneCmd.PersistentFlags().BoolVarP(&pruneConfirmed, "yes", "y", false, "remove certificates without confirmation")
+	StoragePruneCmd.PersistentFlags().StringSliceVarP(&pruneReasons, "reason", "r", nil, "prune reasons (expired|unreferenced|superseded), all by default")
+	StorageCmd.AddCommand(StoragePruneCmd)
+}
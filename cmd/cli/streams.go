@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates"
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var StreamsCmd = &cobra.Command{
+	Use:   "streams",
+	Short: "Show TCP/TLS stream servers of web servers",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conf, err := config.GetConfig()
+
+		if err != nil {
+			return err
+		}
+
+		log, err := logger.NewLogger(conf)
+
+		if err != nil {
+			return err
+		}
+
+		certManager, err := certificates.CreateCertificateManager(
+			conf,
+			webserver.CreateWebServer,
+			reverter.CreateReverter,
+			log,
+			&sync.Mutex{},
+		)
+
+		if err != nil {
+			return err
+		}
+
+		servers, err := certManager.GetStreamServers()
+
+		if err != nil {
+			return err
+		}
+
+		if webServerCode != "" {
+			var wServers []dto.StreamServer
+
+			for _, server := range servers {
+				if server.WebServer == webServerCode {
+					wServers = append(wServers, server)
+				}
+			}
+
+			servers = wServers
+		}
+
+		if isJson {
+			output, err := json.Marshal(servers)
+
+			if err != nil {
+				return err
+			}
+
+			return writeOutput(cmd, string(output))
+		}
+
+		var outputParts []string
+
+		for _, server := range servers {
+			output, err := yaml.Marshal(server)
+
+			if err != nil {
+				return err
+			}
+
+			outputParts = append(outputParts, string(output))
+		}
+
+		return writeOutput(cmd, strings.Join(outputParts, "\n"))
+	},
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates/tlsprofile"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/spf13/cobra"
+)
+
+var TlsProfileCmd = &cobra.Command{
+	Use:   "tls-profile",
+	Short: "Show or apply TLS profile of a host",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := config.GetConfig()
+
+		if err != nil {
+			return err
+		}
+
+		log, err := logger.NewLogger(config)
+
+		if err != nil {
+			return err
+		}
+
+		if serverName == "" {
+			return fmt.Errorf("domain is not specified")
+		}
+
+		supportedWebServerCodes := webserver.GetWebServers(config.ToMap())
+
+		if webServerCode == "" {
+			return fmt.Errorf("webserver is not specified")
+		}
+
+		if !slices.Contains(supportedWebServerCodes, webServerCode) {
+			return fmt.Errorf("invalid webserver %s", webServerCode)
+		}
+
+		webServer, err := webserver.CreateWebServer(webServerCode, config.ToMap())
+
+		if err != nil {
+			return err
+		}
+
+		if applyTlsProfile == "" {
+			tlsProfileQuery, err := tlsprofile.CreateTlsProfileQuery(webServer)
+
+			if err != nil {
+				return err
+			}
+
+			profile := tlsProfileQuery.GetTlsProfile(serverName)
+
+			if profile == "" {
+				profile = "webserver defaults"
+			}
+
+			fmt.Printf("TLS profile of host %s: %s\n", serverName, profile)
+
+			return nil
+		}
+
+		sReverter, err := reverter.CreateReverter(webServer, log)
+
+		if err != nil {
+			return err
+		}
+
+		tlsProfileCommand, err := tlsprofile.CreateTlsProfileChangeCommand(webServer, sReverter, log, &sync.Mutex{})
+
+		if err != nil {
+			return err
+		}
+
+		return tlsProfileCommand.ApplyTlsProfile(serverName, applyTlsProfile)
+	},
+}
+
+var applyTlsProfile string
+
+func init() {
+	TlsProfileCmd.PersistentFlags().StringVarP(&serverName, "domain", "d", "", "domain to show or apply TLS profile")
+	TlsProfileCmd.PersistentFlags().StringVar(&applyTlsProfile, "apply", "", "TLS profile to apply (modern|intermediate|old)")
+}
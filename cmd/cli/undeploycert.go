@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates"
+	"github.com/r2dtools/sslbot/internal/certificates/request"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/spf13/cobra"
+)
+
+var UndeployCertificateCmd = &cobra.Command{
+	Use:   "undeploy-cert",
+	Short: "Remove certificate and ssl configuration from a domain",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config, err := config.GetConfig()
+
+		if err != nil {
+			return err
+		}
+
+		log, err := logger.NewLogger(config)
+
+		if err != nil {
+			return err
+		}
+
+		if serverName == "" {
+			return fmt.Errorf("domain is not specified")
+		}
+
+		supportedWebServerCodes := webserver.GetWebServers(config.ToMap())
+
+		if webServerCode == "" {
+			return fmt.Errorf("webserver is not specified")
+		}
+
+		if !slices.Contains(supportedWebServerCodes, webServerCode) {
+			return fmt.Errorf("invalid webserver %s", webServerCode)
+		}
+
+		certManager, err := certificates.CreateCertificateManager(
+			config,
+			webserver.CreateWebServer,
+			reverter.CreateReverter,
+			log,
+			&sync.Mutex{},
+		)
+
+		if err != nil {
+			return err
+		}
+
+		return certManager.Unassign(request.UnassignRequest{
+			ServerName: serverName,
+			WebServer:  webServerCode,
+		})
+	},
+}
+
+func init() {
+	UndeployCertificateCmd.PersistentFlags().StringVarP(&serverName, "domain", "d", "", "domain to remove a certificate from")
+}
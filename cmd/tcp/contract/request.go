@@ -5,7 +5,17 @@ import (
 	"github.com/r2dtools/sslbot/internal/certificates/request"
 )
 
-func ConvertIssueRequest(r agentintegration.CertificateIssueRequestData) request.IssueRequest {
+type CertificateIssueRequestData struct {
+	agentintegration.CertificateIssueRequestData `mapstructure:",squash"`
+	// Redirect enables HTTP to HTTPS redirect for the host after the certificate is deployed
+	Redirect bool
+	// TlsProfile is applied to the host after the certificate is deployed if set
+	TlsProfile string
+	// DryRun returns config changes of the certificate deployment, nothing is written or reloaded
+	DryRun bool
+}
+
+func ConvertIssueRequest(r CertificateIssueRequestData) request.IssueRequest {
 	return request.IssueRequest{
 		Email:         r.Email,
 		ServerName:    r.ServerName,
@@ -14,15 +24,29 @@ func ConvertIssueRequest(r agentintegration.CertificateIssueRequestData) request
 		Subjects:      r.Subjects,
 		Assign:        r.Assign,
 		PreventReload: r.PreventReload,
+		Redirect:      r.Redirect,
+		TlsProfile:    r.TlsProfile,
 	}
 }
 
-func ConvertAssignRequest(r agentintegration.CertificateAssignRequestData) request.AssignRequest {
+type CertificateAssignRequestData struct {
+	agentintegration.CertificateAssignRequestData `mapstructure:",squash"`
+	// Redirect enables HTTP to HTTPS redirect for the host after the certificate is deployed
+	Redirect bool
+	// TlsProfile is applied to the host after the certificate is deployed if set
+	TlsProfile string
+	// DryRun returns config changes of the certificate deployment, nothing is written or reloaded
+	DryRun bool
+}
+
+func ConvertAssignRequest(r CertificateAssignRequestData) request.AssignRequest {
 	return request.AssignRequest{
 		ServerName:  r.ServerName,
 		WebServer:   r.WebServer,
 		CertName:    r.CertName,
 		StorageType: r.StorageType,
+		Redirect:    r.Redirect,
+		TlsProfile:  r.TlsProfile,
 	}
 }
 
@@ -34,3 +58,155 @@ func ConvertUploadRequest(r agentintegration.CertificateUploadRequestData) reque
 		PemCertificate: r.PemCertificate,
 	}
 }
+
+const (
+	CertificateFormatPem    = "pem"
+	CertificateFormatPkcs12 = "pkcs12"
+)
+
+type CertificateStorageUploadRequestData struct {
+	agentintegration.CertificateUploadRequestData `mapstructure:",squash"`
+	// Pkcs12Certificate is base64 encoded PKCS#12 (PFX) data. It is used instead of PemCertificate if specified.
+	Pkcs12Certificate string
+	Pkcs12Password    string
+	// StorageType is a writable storage to upload the certificate to (default storage if empty)
+	StorageType string
+}
+
+type CertificateStorageDownloadRequestData struct {
+	agentintegration.CertificateDownloadRequestData `mapstructure:",squash"`
+	Format                                          string
+	Pkcs12Password                                  string
+}
+
+type CertificateVersionsRequestData struct {
+	CertName    string
+	StorageType string
+}
+
+type CertificateRestoreVersionRequestData struct {
+	CertName    string
+	StorageType string
+	VersionID   string
+}
+
+type CertificateAdoptRequestData struct {
+	CertPath      string
+	CertName      string
+	Link          bool
+	RewriteVhosts bool
+}
+
+func ConvertAdoptRequest(r CertificateAdoptRequestData) request.AdoptRequest {
+	return request.AdoptRequest{
+		CertPath:      r.CertPath,
+		CertName:      r.CertName,
+		Link:          r.Link,
+		RewriteVhosts: r.RewriteVhosts,
+	}
+}
+
+type StoragePruneRequestData struct {
+	// Reasons limit candidates to expired, unreferenced or superseded certificates. All reasons are used if empty.
+	Reasons []string
+	// Confirm removes candidates, otherwise they are only returned
+	Confirm bool
+	// Keys limit removed candidates to the certificates previewed by the panel, they are required on confirm
+	Keys []string
+}
+
+type CertificateUnassignRequestData struct {
+	WebServer  string
+	ServerName string
+}
+
+func ConvertUnassignRequest(r CertificateUnassignRequestData) request.UnassignRequest {
+	return request.UnassignRequest{
+		ServerName: r.ServerName,
+		WebServer:  r.WebServer,
+	}
+}
+
+type CertificateReissueRequestData struct {
+	WebServer     string
+	ServerName    string
+	Email         string
+	ChallengeType string
+}
+
+func ConvertReissueRequest(r CertificateReissueRequestData) request.ReissueRequest {
+	return request.ReissueRequest{
+		ServerName:    r.ServerName,
+		WebServer:     r.WebServer,
+		Email:         r.Email,
+		ChallengeType: r.ChallengeType,
+	}
+}
+
+type StreamCertificateAssignRequestData struct {
+	// ServerKey is the listen address or the name of the stream server
+	ServerKey   string
+	WebServer   string
+	CertName    string
+	StorageType string
+}
+
+func ConvertStreamAssignRequest(r StreamCertificateAssignRequestData) request.StreamAssignRequest {
+	return request.StreamAssignRequest{
+		ServerKey:   r.ServerKey,
+		WebServer:   r.WebServer,
+		CertName:    r.CertName,
+		StorageType: r.StorageType,
+	}
+}
+
+type CommonDirChangeStatusRequestData struct {
+	agentintegration.CommonDirChangeStatusRequestData `mapstructure:",squash"`
+	// DryRun returns config changes of the status change, nothing is written or reloaded
+	DryRun bool
+}
+
+type RedirectStatusRequestData struct {
+	WebServer  string
+	ServerName string
+}
+
+type RedirectChangeStatusRequestData struct {
+	WebServer  string
+	ServerName string
+	Status     bool
+}
+
+type HstsStatusRequestData struct {
+	WebServer  string
+	ServerName string
+}
+
+type HstsChangeStatusRequestData struct {
+	WebServer         string
+	ServerName        string
+	Status            bool
+	MaxAge            int
+	IncludeSubDomains bool
+	Preload           bool
+}
+
+type TlsProfileApplyRequestData struct {
+	WebServer  string
+	ServerName string
+	Profile    string
+}
+
+type VirtualHostConfigSetRequestData struct {
+	agentintegration.VirtualHostConfigRequestData `mapstructure:",squash"`
+	Content                                       string
+	// Checksum of the replaced content returned by getvhostconfig. The config is not changed if the file was changed since then.
+	Checksum string
+}
+
+type VhostResolveRequestData struct {
+	WebServer string
+	HostName  string
+	// Port is optional, the host is resolved among hosts of all ports if it is empty
+	Port string
+}
@@ -5,7 +5,42 @@ import (
 	"github.com/r2dtools/sslbot/internal/dto"
 )
 
-func ConvertVirtualHost(vhost *dto.VirtualHost) *agentintegration.VirtualHost {
+type VirtualHost struct {
+	agentintegration.VirtualHost
+	CertificateStorage string
+	CertificateName    string
+	TlsProfile         string
+	MatchType          string
+	DefaultServer      bool
+	// CertificateCoverage is nil for hosts without a certificate
+	CertificateCoverage *CertificateCoverage
+}
+
+type CertificateCoverage struct {
+	CoveredNames    []string
+	UncoveredNames  []string
+	WildcardMatches map[string]string
+}
+
+type StorageCertificate struct {
+	agentintegration.Certificate
+	Vhosts []StorageCertificateVhost
+	// UsageUnknown is set if hosts that use the certificate could not be checked
+	UsageUnknown bool
+}
+
+type StorageCertificateVhost struct {
+	WebServer  string
+	ServerName string
+	FilePath   string
+	Stream     bool
+}
+
+type CertificatesResponseData struct {
+	Certificates map[string]*StorageCertificate
+}
+
+func ConvertVirtualHost(vhost *dto.VirtualHost) *VirtualHost {
 	addresses := []agentintegration.VirtualHostAddress{}
 
 	for _, address := range vhost.Addresses {
@@ -22,20 +57,40 @@ func ConvertVirtualHost(vhost *dto.VirtualHost) *agentintegration.VirtualHost {
 		certificate = ConvertCertificate(vhost.Certificate)
 	}
 
-	return &agentintegration.VirtualHost{
-		FilePath:    vhost.FilePath,
-		ServerName:  vhost.ServerName,
-		DocRoot:     vhost.DocRoot,
-		WebServer:   vhost.WebServer,
-		Aliases:     vhost.Aliases,
-		Ssl:         vhost.Ssl,
-		Addresses:   addresses,
-		Certificate: certificate,
+	return &VirtualHost{
+		VirtualHost: agentintegration.VirtualHost{
+			FilePath:    vhost.FilePath,
+			ServerName:  vhost.ServerName,
+			DocRoot:     vhost.DocRoot,
+			WebServer:   vhost.WebServer,
+			Aliases:     vhost.Aliases,
+			Ssl:         vhost.Ssl,
+			Addresses:   addresses,
+			Certificate: certificate,
+		},
+		CertificateStorage:  vhost.CertificateStorage,
+		CertificateName:     vhost.CertificateName,
+		TlsProfile:          vhost.TlsProfile,
+		MatchType:           vhost.MatchType,
+		DefaultServer:       vhost.IsDefaultServer(),
+		CertificateCoverage: convertCertificateCoverage(vhost.CertificateCoverage),
+	}
+}
+
+func convertCertificateCoverage(coverage *dto.CertificateCoverage) *CertificateCoverage {
+	if coverage == nil {
+		return nil
+	}
+
+	return &CertificateCoverage{
+		CoveredNames:    coverage.CoveredNames,
+		UncoveredNames:  coverage.UncoveredNames,
+		WildcardMatches: coverage.WildcardMatches,
 	}
 }
 
-func ConvertVirtualHosts(vhosts []dto.VirtualHost) []agentintegration.VirtualHost {
-	cVhosts := []agentintegration.VirtualHost{}
+func ConvertVirtualHosts(vhosts []dto.VirtualHost) []VirtualHost {
+	cVhosts := []VirtualHost{}
 
 	for _, vhost := range vhosts {
 		cVhosts = append(cVhosts, *ConvertVirtualHost(&vhost))
@@ -65,3 +120,160 @@ func ConvertCertificate(cert *dto.Certificate) *agentintegration.Certificate {
 		Issuer:         issuer,
 	}
 }
+
+type UploadedCertificate struct {
+	agentintegration.Certificate
+	AddedIntermediates []string
+}
+
+func ConvertUploadedCertificate(cert *dto.Certificate, addedIntermediates []string) *UploadedCertificate {
+	return &UploadedCertificate{
+		Certificate:        *ConvertCertificate(cert),
+		AddedIntermediates: addedIntermediates,
+	}
+}
+
+type CertificateDownloadResponseData struct {
+	agentintegration.CertificateDownloadResponseData
+	// Format is pem or pkcs12. PKCS#12 content is base64 encoded.
+	Format string
+}
+
+type CertificateVersion struct {
+	ID          string
+	CreatedAt   string
+	Fingerprint string
+	Certificate *agentintegration.Certificate
+}
+
+type CertificateVersionsResponseData struct {
+	Versions []CertificateVersion
+}
+
+func ConvertCertificateVersions(versions []dto.CertificateVersion) *CertificateVersionsResponseData {
+	cVersions := []CertificateVersion{}
+
+	for _, version := range versions {
+		cVersions = append(cVersions, CertificateVersion{
+			ID:          version.ID,
+			CreatedAt:   version.CreatedAt,
+			Fingerprint: version.Fingerprint,
+			Certificate: ConvertCertificate(version.Certificate),
+		})
+	}
+
+	return &CertificateVersionsResponseData{Versions: cVersions}
+}
+
+type UnmanagedCertificate struct {
+	CertPath    string
+	KeyPath     string
+	Certificate *agentintegration.Certificate
+	Vhosts      []StorageCertificateVhost
+}
+
+type UnmanagedCertificatesResponseData struct {
+	Certificates []UnmanagedCertificate
+}
+
+type StoragePruneCandidate struct {
+	Key         string
+	StorageType string
+	CertName    string
+	Reasons     []string
+	Certificate *agentintegration.Certificate
+}
+
+type StoragePruneResponseData struct {
+	Candidates []StoragePruneCandidate
+	Removed    bool
+}
+
+type StreamServer struct {
+	Key                string
+	Name               string
+	FilePath           string
+	WebServer          string
+	Ssl                bool
+	Addresses          []agentintegration.VirtualHostAddress
+	Certificate        *agentintegration.Certificate
+	CertificateStorage string
+	CertificateName    string
+}
+
+func ConvertStreamServers(servers []dto.StreamServer) []StreamServer {
+	cServers := []StreamServer{}
+
+	for _, server := range servers {
+		addresses := []agentintegration.VirtualHostAddress{}
+
+		for _, address := range server.Addresses {
+			addresses = append(addresses, agentintegration.VirtualHostAddress{
+				IsIpv6: address.IsIpv6,
+				Host:   address.Host,
+				Port:   address.Port,
+			})
+		}
+
+		var certificate *agentintegration.Certificate
+
+		if server.Certificate != nil {
+			certificate = ConvertCertificate(server.Certificate)
+		}
+
+		cServers = append(cServers, StreamServer{
+			Key:                server.Key,
+			Name:               server.Name,
+			FilePath:           server.FilePath,
+			WebServer:          server.WebServer,
+			Ssl:                server.Ssl,
+			Addresses:          addresses,
+			Certificate:        certificate,
+			CertificateStorage: server.CertificateStorage,
+			CertificateName:    server.CertificateName,
+		})
+	}
+
+	return cServers
+}
+
+type VirtualHostConfigResponseData struct {
+	agentintegration.VirtualHostConfigResponseData
+	// Checksum is passed to setvhostconfig to replace this version of the config
+	Checksum string
+}
+
+type ConfigDiff struct {
+	FilePath string
+	Symlink  bool
+	Diff     string
+}
+
+type ConfigDiffResponseData struct {
+	Diffs []ConfigDiff
+}
+
+func ConvertConfigDiffs(diffs []dto.ConfigDiff) *ConfigDiffResponseData {
+	cDiffs := []ConfigDiff{}
+
+	for _, diff := range diffs {
+		cDiffs = append(cDiffs, ConfigDiff{
+			FilePath: diff.FilePath,
+			Symlink:  diff.Symlink,
+			Diff:     diff.Diff,
+		})
+	}
+
+	return &ConfigDiffResponseData{Diffs: cDiffs}
+}
+
+type RedirectStatusResponseData struct {
+	Status bool
+}
+
+type HstsStatusResponseData struct {
+	Status            bool
+	MaxAge            int
+	IncludeSubDomains bool
+	Preload           bool
+}
@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"sync"
 
 	"github.com/mitchellh/mapstructure"
@@ -13,17 +15,33 @@ import (
 	"github.com/r2dtools/sslbot/config"
 	"github.com/r2dtools/sslbot/internal/certificates"
 	"github.com/r2dtools/sslbot/internal/certificates/commondir"
+	"github.com/r2dtools/sslbot/internal/certificates/hsts"
+	"github.com/r2dtools/sslbot/internal/certificates/redirect"
+	"github.com/r2dtools/sslbot/internal/certificates/tlsprofile"
 	"github.com/r2dtools/sslbot/internal/logger"
 	"github.com/r2dtools/sslbot/internal/utils"
 	"github.com/r2dtools/sslbot/internal/webserver"
 	"github.com/r2dtools/sslbot/internal/webserver/reverter"
 )
 
+// certificatesHandlerReadOnlyActions do not change webserver configs
+var certificatesHandlerReadOnlyActions = []string{
+	"storagecertificates",
+	"storagecertdownload",
+	"storagecertversions",
+	"unmanagedcertificates",
+	"commondirstatus",
+	"redirectstatus",
+	"hstsstatus",
+}
+
 type CertificatesHandler struct {
 	certManager *certificates.CertificateManager
 	logger      logger.Logger
 	config      *config.Config
 	mx          *sync.Mutex
+	// webServerCache is used by read only actions, other actions parse webservers on every call
+	webServerCache *webserver.WebServerCache
 }
 
 func (h *CertificatesHandler) Handle(request router.Request) (interface{}, error) {
@@ -43,27 +61,68 @@ func (h *CertificatesHandler) Handle(request router.Request) (interface{}, error
 		err = h.removeCertFromStorage(request.Data)
 	case "storagecertdownload":
 		response, err = h.downloadCertFromStorage(request.Data)
+	case "storagecertversions":
+		response, err = h.storageCertVersions(request.Data)
+	case "storagecertrestore":
+		response, err = h.restoreStorageCertVersion(request.Data)
+	case "unmanagedcertificates":
+		response, err = h.unmanagedCertificates()
+	case "adopt":
+		response, err = h.adoptCertificate(request.Data)
+	case "storageprune":
+		response, err = h.pruneStorage(request.Data)
 	case "domainassign":
 		response, err = h.assignCertificateToDomain(request.Data)
+	case "domainunassign":
+		err = h.unassignCertificateFromDomain(request.Data)
+	case "reissue":
+		response, err = h.reissueCertificate(request.Data)
+	case "streamassign":
+		response, err = h.assignCertificateToStream(request.Data)
 	case "commondirstatus":
 		response, err = h.commonDirStatus(request.Data)
 	case "changecommondirstatus":
-		err = h.changeCommonDirStatus(request.Data)
+		response, err = h.changeCommonDirStatus(request.Data)
+	case "redirectstatus":
+		response, err = h.redirectStatus(request.Data)
+	case "changeredirectstatus":
+		err = h.changeRedirectStatus(request.Data)
+	case "hstsstatus":
+		response, err = h.hstsStatus(request.Data)
+	case "changehstsstatus":
+		err = h.changeHstsStatus(request.Data)
+	case "applytlsprofile":
+		err = h.applyTlsProfile(request.Data)
 	default:
 		response, err = nil, fmt.Errorf("invalid action '%s' for module '%s'", action, request.GetModule())
 	}
 
+	// the cache is invalidated before the watcher reports the agent's own changes
+	if !slices.Contains(certificatesHandlerReadOnlyActions, request.GetAction()) {
+		h.webServerCache.Invalidate()
+	}
+
 	return response, err
 }
 
-func (h *CertificatesHandler) issueCertificateToDomain(data any) (*agentintegration.Certificate, error) {
-	var request agentintegration.CertificateIssueRequestData
+func (h *CertificatesHandler) issueCertificateToDomain(data any) (any, error) {
+	var request contract.CertificateIssueRequestData
 	err := mapstructure.Decode(data, &request)
 
 	if err != nil {
 		return nil, fmt.Errorf("invalid request data: %v", err)
 	}
 
+	if request.DryRun {
+		diffs, err := h.certManager.DryRunIssue(contract.ConvertIssueRequest(request))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return contract.ConvertConfigDiffs(diffs), nil
+	}
+
 	cert, err := h.certManager.Issue(contract.ConvertIssueRequest(request))
 
 	if err != nil {
@@ -73,7 +132,7 @@ func (h *CertificatesHandler) issueCertificateToDomain(data any) (*agentintegrat
 	return contract.ConvertCertificate(cert), nil
 }
 
-func (h *CertificatesHandler) uploadCertificateToDomain(data any) (*agentintegration.Certificate, error) {
+func (h *CertificatesHandler) uploadCertificateToDomain(data any) (*contract.UploadedCertificate, error) {
 	var request agentintegration.CertificateUploadRequestData
 	err := mapstructure.Decode(data, &request)
 
@@ -85,33 +144,48 @@ func (h *CertificatesHandler) uploadCertificateToDomain(data any) (*agentintegra
 		return nil, errors.New("domain name is missed")
 	}
 
-	cert, err := h.certManager.Upload(contract.ConvertUploadRequest(request))
+	cert, addedIntermediates, err := h.certManager.Upload(contract.ConvertUploadRequest(request))
 
 	if err != nil {
 		return nil, err
 	}
 
-	return contract.ConvertCertificate(cert), nil
+	return contract.ConvertUploadedCertificate(cert, addedIntermediates), nil
 }
 
-func (h *CertificatesHandler) storageCertificates() (*agentintegration.CertificatesResponseData, error) {
+func (h *CertificatesHandler) storageCertificates() (*contract.CertificatesResponseData, error) {
 	certItems, err := h.certManager.GetStorageCertificates()
 
 	if err != nil {
 		return nil, err
 	}
 
-	certsMap := map[string]*agentintegration.Certificate{}
+	certsMap := map[string]*contract.StorageCertificate{}
 
 	for _, item := range certItems {
-		certsMap[item.Key()] = contract.ConvertCertificate(item.Certificate)
+		vhosts := []contract.StorageCertificateVhost{}
+
+		for _, vhost := range item.Vhosts {
+			vhosts = append(vhosts, contract.StorageCertificateVhost{
+				WebServer:  vhost.WebServer,
+				ServerName: vhost.ServerName,
+				FilePath:   vhost.FilePath,
+				Stream:     vhost.Stream,
+			})
+		}
+
+		certsMap[item.Key()] = &contract.StorageCertificate{
+			Certificate:  *contract.ConvertCertificate(item.Certificate),
+			Vhosts:       vhosts,
+			UsageUnknown: item.UsageUnknown,
+		}
 	}
 
-	return &agentintegration.CertificatesResponseData{Certificates: certsMap}, nil
+	return &contract.CertificatesResponseData{Certificates: certsMap}, nil
 }
 
-func (h *CertificatesHandler) uploadCertToStorage(data any) (*agentintegration.Certificate, error) {
-	var request agentintegration.CertificateUploadRequestData
+func (h *CertificatesHandler) uploadCertToStorage(data any) (*contract.UploadedCertificate, error) {
+	var request contract.CertificateStorageUploadRequestData
 	err := mapstructure.Decode(data, &request)
 
 	if err != nil {
@@ -122,10 +196,32 @@ func (h *CertificatesHandler) uploadCertToStorage(data any) (*agentintegration.C
 		return nil, errors.New("certificate name is missed")
 	}
 
-	certPath, err := h.certManager.AddStorageCertificate(request.CertName, request.PemCertificate)
+	var certPath string
+	var addedIntermediates []string
+	storageType := request.StorageType
 
-	if err != nil {
-		return nil, err
+	if storageType == "" {
+		storageType = string(certificates.Default)
+	}
+
+	if request.Pkcs12Certificate != "" {
+		pkcs12Data, err := base64.StdEncoding.DecodeString(request.Pkcs12Certificate)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid PKCS#12 data: %v", err)
+		}
+
+		certPath, addedIntermediates, err = h.certManager.ImportPkcs12StorageCertificate(request.CertName, pkcs12Data, request.Pkcs12Password, storageType)
+
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		certPath, addedIntermediates, err = h.certManager.AddCertificateToStorage(request.CertName, request.PemCertificate, storageType)
+
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	cert, err := utils.GetCertificateFromFile(certPath)
@@ -134,7 +230,7 @@ func (h *CertificatesHandler) uploadCertToStorage(data any) (*agentintegration.C
 		return nil, err
 	}
 
-	return contract.ConvertCertificate(cert), nil
+	return contract.ConvertUploadedCertificate(cert, addedIntermediates), nil
 }
 
 func (h *CertificatesHandler) removeCertFromStorage(data any) error {
@@ -148,35 +244,198 @@ func (h *CertificatesHandler) removeCertFromStorage(data any) error {
 	return h.certManager.RemoveStorageCertificate(request.CertName, request.StorageType)
 }
 
-func (h *CertificatesHandler) downloadCertFromStorage(data any) (*agentintegration.CertificateDownloadResponseData, error) {
-	var request agentintegration.CertificateRemoveRequestData
+func (h *CertificatesHandler) downloadCertFromStorage(data any) (*contract.CertificateDownloadResponseData, error) {
+	var request contract.CertificateStorageDownloadRequestData
+	err := mapstructure.Decode(data, &request)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid request data: %v", err)
+	}
+
+	var certDownloadResponse contract.CertificateDownloadResponseData
+
+	switch request.Format {
+	case "", contract.CertificateFormatPem:
+		certPath, certContent, err := h.certManager.GetStorageCertificateAsString(request.CertName, request.StorageType)
+
+		if err != nil {
+			return nil, err
+		}
+
+		certDownloadResponse.CertFileName = filepath.Base(certPath)
+		certDownloadResponse.CertContent = certContent
+		certDownloadResponse.Format = contract.CertificateFormatPem
+	case contract.CertificateFormatPkcs12:
+		pkcs12Data, err := h.certManager.GetStorageCertificateAsPkcs12(request.CertName, request.StorageType, request.Pkcs12Password)
+
+		if err != nil {
+			return nil, err
+		}
+
+		certDownloadResponse.CertFileName = request.CertName + ".pfx"
+		certDownloadResponse.CertContent = base64.StdEncoding.EncodeToString(pkcs12Data)
+		certDownloadResponse.Format = contract.CertificateFormatPkcs12
+	default:
+		return nil, fmt.Errorf("unsupported certificate format: %s", request.Format)
+	}
+
+	return &certDownloadResponse, nil
+}
+
+func (h *CertificatesHandler) storageCertVersions(data any) (*contract.CertificateVersionsResponseData, error) {
+	var request contract.CertificateVersionsRequestData
 	err := mapstructure.Decode(data, &request)
 
 	if err != nil {
 		return nil, fmt.Errorf("invalid request data: %v", err)
 	}
 
-	certPath, certContent, err := h.certManager.GetStorageCertificateAsString(request.CertName, request.StorageType)
+	versions, err := h.certManager.GetStorageCertificateVersions(request.CertName, request.StorageType)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var certDownloadResponse agentintegration.CertificateDownloadResponseData
-	certDownloadResponse.CertFileName = filepath.Base(certPath)
-	certDownloadResponse.CertContent = certContent
+	return contract.ConvertCertificateVersions(versions), nil
+}
 
-	return &certDownloadResponse, nil
+func (h *CertificatesHandler) restoreStorageCertVersion(data any) (*agentintegration.Certificate, error) {
+	var request contract.CertificateRestoreVersionRequestData
+	err := mapstructure.Decode(data, &request)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid request data: %v", err)
+	}
+
+	if request.VersionID == "" {
+		return nil, errors.New("certificate version is missed")
+	}
+
+	cert, err := h.certManager.RestoreStorageCertificateVersion(request.CertName, request.StorageType, request.VersionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return contract.ConvertCertificate(cert), nil
+}
+
+func (h *CertificatesHandler) unmanagedCertificates() (*contract.UnmanagedCertificatesResponseData, error) {
+	unmanagedCerts, err := h.certManager.GetUnmanagedCertificates()
+
+	if err != nil {
+		return nil, err
+	}
+
+	certs := []contract.UnmanagedCertificate{}
+
+	for _, unmanagedCert := range unmanagedCerts {
+		cert := contract.UnmanagedCertificate{
+			CertPath: unmanagedCert.CertPath,
+			KeyPath:  unmanagedCert.KeyPath,
+			Vhosts:   []contract.StorageCertificateVhost{},
+		}
+
+		if unmanagedCert.Certificate != nil {
+			cert.Certificate = contract.ConvertCertificate(unmanagedCert.Certificate)
+		}
+
+		for _, vhost := range unmanagedCert.Vhosts {
+			cert.Vhosts = append(cert.Vhosts, contract.StorageCertificateVhost{
+				WebServer:  vhost.WebServer,
+				ServerName: vhost.ServerName,
+				FilePath:   vhost.FilePath,
+			})
+		}
+
+		certs = append(certs, cert)
+	}
+
+	return &contract.UnmanagedCertificatesResponseData{Certificates: certs}, nil
+}
+
+func (h *CertificatesHandler) adoptCertificate(data any) (*agentintegration.Certificate, error) {
+	var request contract.CertificateAdoptRequestData
+	err := mapstructure.Decode(data, &request)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid request data: %v", err)
+	}
+
+	if request.CertPath == "" {
+		return nil, errors.New("certificate path is missed")
+	}
+
+	cert, err := h.certManager.Adopt(contract.ConvertAdoptRequest(request))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return contract.ConvertCertificate(cert), nil
 }
 
-func (h *CertificatesHandler) assignCertificateToDomain(data any) (*agentintegration.Certificate, error) {
-	var request agentintegration.CertificateAssignRequestData
+func (h *CertificatesHandler) pruneStorage(data any) (*contract.StoragePruneResponseData, error) {
+	var request contract.StoragePruneRequestData
 	err := mapstructure.Decode(data, &request)
 
 	if err != nil {
 		return nil, fmt.Errorf("invalid request data: %v", err)
 	}
 
+	var candidates []certificates.PruneCandidate
+
+	// unreferenced is a default reason, so removing without the previewed keys could remove any unused certificate
+	if request.Confirm && len(request.Keys) == 0 {
+		return nil, errors.New("keys of the previewed certificates are required to confirm pruning")
+	}
+
+	if request.Confirm {
+		candidates, err = h.certManager.Prune(request.Reasons, request.Keys)
+	} else {
+		candidates, err = h.certManager.GetPruneCandidates(request.Reasons)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	response := &contract.StoragePruneResponseData{
+		Candidates: []contract.StoragePruneCandidate{},
+		Removed:    request.Confirm,
+	}
+
+	for _, candidate := range candidates {
+		response.Candidates = append(response.Candidates, contract.StoragePruneCandidate{
+			Key:         candidate.Key(),
+			StorageType: string(candidate.StorageType),
+			CertName:    candidate.CertName,
+			Reasons:     candidate.Reasons,
+			Certificate: contract.ConvertCertificate(candidate.Certificate),
+		})
+	}
+
+	return response, nil
+}
+
+func (h *CertificatesHandler) assignCertificateToDomain(data any) (any, error) {
+	var request contract.CertificateAssignRequestData
+	err := mapstructure.Decode(data, &request)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid request data: %v", err)
+	}
+
+	if request.DryRun {
+		diffs, err := h.certManager.DryRunAssign(contract.ConvertAssignRequest(request))
+
+		if err != nil {
+			return nil, err
+		}
+
+		return contract.ConvertConfigDiffs(diffs), nil
+	}
+
 	cert, err := h.certManager.Assign(contract.ConvertAssignRequest(request))
 
 	if err != nil {
@@ -186,6 +445,55 @@ func (h *CertificatesHandler) assignCertificateToDomain(data any) (*agentintegra
 	return contract.ConvertCertificate(cert), nil
 }
 
+func (h *CertificatesHandler) unassignCertificateFromDomain(data any) error {
+	var request contract.CertificateUnassignRequestData
+	err := mapstructure.Decode(data, &request)
+
+	if err != nil {
+		return fmt.Errorf("invalid request data: %v", err)
+	}
+
+	return h.certManager.Unassign(contract.ConvertUnassignRequest(request))
+}
+
+func (h *CertificatesHandler) reissueCertificate(data any) (*agentintegration.Certificate, error) {
+	var request contract.CertificateReissueRequestData
+	err := mapstructure.Decode(data, &request)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid request data: %v", err)
+	}
+
+	cert, err := h.certManager.Reissue(contract.ConvertReissueRequest(request))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return contract.ConvertCertificate(cert), nil
+}
+
+func (h *CertificatesHandler) assignCertificateToStream(data any) (*agentintegration.Certificate, error) {
+	var request contract.StreamCertificateAssignRequestData
+	err := mapstructure.Decode(data, &request)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid request data: %v", err)
+	}
+
+	if request.ServerKey == "" {
+		return nil, errors.New("stream server key is missed")
+	}
+
+	cert, err := h.certManager.AssignStream(contract.ConvertStreamAssignRequest(request))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return contract.ConvertCertificate(cert), nil
+}
+
 func (h *CertificatesHandler) commonDirStatus(data any) (*agentintegration.CommonDirStatusResponseData, error) {
 	var requestData agentintegration.CommonDirChangeStatusRequestData
 	err := mapstructure.Decode(data, &requestData)
@@ -195,7 +503,7 @@ func (h *CertificatesHandler) commonDirStatus(data any) (*agentintegration.Commo
 	}
 
 	options := h.config.ToMap()
-	wServer, err := webserver.CreateWebServer(requestData.WebServer, options)
+	wServer, err := h.webServerCache.GetWebServer(requestData.WebServer, options)
 
 	if err != nil {
 		return nil, err
@@ -212,8 +520,101 @@ func (h *CertificatesHandler) commonDirStatus(data any) (*agentintegration.Commo
 	return &agentintegration.CommonDirStatusResponseData{Status: status.Enabled}, nil
 }
 
-func (h *CertificatesHandler) changeCommonDirStatus(data any) error {
-	var requestData agentintegration.CommonDirChangeStatusRequestData
+// changeCommonDirStatus changes the common dir status of the host. Changes are made to the sandbox config copy
+// and returned as diffs on dry run.
+func (h *CertificatesHandler) changeCommonDirStatus(data any) (*contract.ConfigDiffResponseData, error) {
+	var requestData contract.CommonDirChangeStatusRequestData
+	err := mapstructure.Decode(data, &requestData)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid request data: %v", err)
+	}
+
+	options := h.config.ToMap()
+	wServer, err := webserver.CreateWebServer(requestData.WebServer, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mx := h.mx
+	var sandbox *webserver.Sandbox
+
+	if requestData.DryRun {
+		sandbox, err = webserver.CreateSandbox(wServer)
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer sandbox.Remove()
+
+		if wServer, err = sandbox.CreateWebServer(); err != nil {
+			return nil, err
+		}
+
+		mx = &sync.Mutex{}
+	}
+
+	sReverter, err := reverter.CreateReverter(wServer, h.logger)
+
+	if err != nil {
+		return nil, err
+	}
+
+	commonDirCommand, err := commondir.CreateCommonDirChangeCommand(h.config, wServer, sReverter, h.logger, mx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if requestData.Status {
+		err = commonDirCommand.EnableCommonDir(requestData.ServerName)
+	} else {
+		err = commonDirCommand.DisableCommonDir(requestData.ServerName)
+	}
+
+	if err != nil || sandbox == nil {
+		return nil, err
+	}
+
+	diffs, err := sandbox.GetDiffs()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return contract.ConvertConfigDiffs(diffs), nil
+}
+
+func (h *CertificatesHandler) redirectStatus(data any) (*contract.RedirectStatusResponseData, error) {
+	var requestData contract.RedirectStatusRequestData
+	err := mapstructure.Decode(data, &requestData)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid request data: %v", err)
+	}
+
+	options := h.config.ToMap()
+	wServer, err := h.webServerCache.GetWebServer(requestData.WebServer, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	redirectQuery, err := redirect.CreateRedirectStatusQuery(wServer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	status := redirectQuery.GetRedirectStatus(requestData.ServerName)
+
+	return &contract.RedirectStatusResponseData{Status: status.Enabled}, nil
+}
+
+func (h *CertificatesHandler) changeRedirectStatus(data any) error {
+	var requestData contract.RedirectChangeStatusRequestData
 	err := mapstructure.Decode(data, &requestData)
 
 	if err != nil {
@@ -233,22 +634,128 @@ func (h *CertificatesHandler) changeCommonDirStatus(data any) error {
 		return err
 	}
 
-	commonDirCommand, err := commondir.CreateCommonDirChangeCommand(h.config, wServer, sReverter, h.logger, h.mx)
+	redirectCommand, err := redirect.CreateRedirectChangeCommand(wServer, sReverter, h.logger, h.mx)
 
 	if err != nil {
 		return err
 	}
 
 	if requestData.Status {
-		err = commonDirCommand.EnableCommonDir(requestData.ServerName)
+		err = redirectCommand.EnableRedirect(requestData.ServerName)
 	} else {
-		err = commonDirCommand.DisableCommonDir(requestData.ServerName)
+		err = redirectCommand.DisableRedirect(requestData.ServerName)
+	}
+
+	return err
+}
+
+func (h *CertificatesHandler) hstsStatus(data any) (*contract.HstsStatusResponseData, error) {
+	var requestData contract.HstsStatusRequestData
+	err := mapstructure.Decode(data, &requestData)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid request data: %v", err)
+	}
+
+	options := h.config.ToMap()
+	wServer, err := h.webServerCache.GetWebServer(requestData.WebServer, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	hstsQuery, err := hsts.CreateHstsStatusQuery(wServer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	status := hstsQuery.GetHstsStatus(requestData.ServerName)
+
+	return &contract.HstsStatusResponseData{
+		Status:            status.Enabled,
+		MaxAge:            status.MaxAge,
+		IncludeSubDomains: status.IncludeSubDomains,
+		Preload:           status.Preload,
+	}, nil
+}
+
+func (h *CertificatesHandler) changeHstsStatus(data any) error {
+	var requestData contract.HstsChangeStatusRequestData
+	err := mapstructure.Decode(data, &requestData)
+
+	if err != nil {
+		return fmt.Errorf("invalid request data: %v", err)
+	}
+
+	options := h.config.ToMap()
+	wServer, err := webserver.CreateWebServer(requestData.WebServer, options)
+
+	if err != nil {
+		return err
+	}
+
+	sReverter, err := reverter.CreateReverter(wServer, h.logger)
+
+	if err != nil {
+		return err
+	}
+
+	hstsCommand, err := hsts.CreateHstsChangeCommand(wServer, sReverter, h.logger, h.mx)
+
+	if err != nil {
+		return err
+	}
+
+	if requestData.Status {
+		err = hstsCommand.EnableHsts(requestData.ServerName, hsts.Hsts{
+			MaxAge:            requestData.MaxAge,
+			IncludeSubDomains: requestData.IncludeSubDomains,
+			Preload:           requestData.Preload,
+		})
+	} else {
+		err = hstsCommand.DisableHsts(requestData.ServerName)
 	}
 
 	return err
 }
 
-func CreateCertificatesHandler(config *config.Config, logger logger.Logger, mx *sync.Mutex) (router.HandlerInterface, error) {
+func (h *CertificatesHandler) applyTlsProfile(data any) error {
+	var requestData contract.TlsProfileApplyRequestData
+	err := mapstructure.Decode(data, &requestData)
+
+	if err != nil {
+		return fmt.Errorf("invalid request data: %v", err)
+	}
+
+	options := h.config.ToMap()
+	wServer, err := webserver.CreateWebServer(requestData.WebServer, options)
+
+	if err != nil {
+		return err
+	}
+
+	sReverter, err := reverter.CreateReverter(wServer, h.logger)
+
+	if err != nil {
+		return err
+	}
+
+	tlsProfileCommand, err := tlsprofile.CreateTlsProfileChangeCommand(wServer, sReverter, h.logger, h.mx)
+
+	if err != nil {
+		return err
+	}
+
+	return tlsProfileCommand.ApplyTlsProfile(requestData.ServerName, requestData.Profile)
+}
+
+func CreateCertificatesHandler(
+	config *config.Config,
+	logger logger.Logger,
+	mx *sync.Mutex,
+	webServerCache *webserver.WebServerCache,
+) (router.HandlerInterface, error) {
 	certManager, err := certificates.CreateCertificateManager(
 		config,
 		webserver.CreateWebServer,
@@ -262,9 +769,10 @@ func CreateCertificatesHandler(config *config.Config, logger logger.Logger, mx *
 	}
 
 	return &CertificatesHandler{
-		logger:      logger,
-		certManager: certManager,
-		config:      config,
-		mx:          mx,
+		logger:         logger,
+		certManager:    certManager,
+		config:         config,
+		mx:             mx,
+		webServerCache: webServerCache,
 	}, nil
 }
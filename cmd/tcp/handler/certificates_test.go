@@ -0,0 +1,16 @@
+//go:build common
+
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneStorageConfirmRequiresKeys(t *testing.T) {
+	handler := &CertificatesHandler{}
+
+	_, err := handler.pruneStorage(map[string]any{"Confirm": true})
+	assert.ErrorContains(t, err, "keys of the previewed certificates are required")
+}
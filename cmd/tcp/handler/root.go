@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"slices"
 	"sync"
 
 	"github.com/mitchellh/mapstructure"
@@ -12,17 +14,34 @@ import (
 	"github.com/r2dtools/sslbot/cmd/tcp/contract"
 	"github.com/r2dtools/sslbot/cmd/tcp/router"
 	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates"
 	"github.com/r2dtools/sslbot/internal/certificates/acme/client/certbot"
+	"github.com/r2dtools/sslbot/internal/certificates/tlsprofile"
 	"github.com/r2dtools/sslbot/internal/logger"
 	"github.com/r2dtools/sslbot/internal/utils"
 	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
 	"github.com/shirou/gopsutil/host"
 )
 
+// mainHandlerReadOnlyActions do not change webserver configs
+var mainHandlerReadOnlyActions = []string{
+	"getserverdata",
+	"getVhosts",
+	"getstreamservers",
+	"getVhostCertificate",
+	"getvhostconfig",
+	"resolvevhost",
+	"reloadwebserver",
+}
+
 type MainHandler struct {
-	config *config.Config
-	logger logger.Logger
-	mx     *sync.Mutex
+	certManager *certificates.CertificateManager
+	config      *config.Config
+	logger      logger.Logger
+	mx          *sync.Mutex
+	// webServerCache is used by read only actions, other actions parse webservers on every call
+	webServerCache *webserver.WebServerCache
 }
 
 func (h *MainHandler) Handle(request router.Request) (any, error) {
@@ -34,10 +53,16 @@ func (h *MainHandler) Handle(request router.Request) (any, error) {
 		response, err = h.getServerData()
 	case "getVhosts":
 		response, err = h.getVhosts()
+	case "getstreamservers":
+		response, err = h.getStreamServers()
 	case "getVhostCertificate":
 		response, err = h.getVhostCertificate(request.Data)
 	case "getvhostconfig":
 		response, err = h.getVhostConfig(request.Data)
+	case "setvhostconfig":
+		response, err = h.setVhostConfig(request.Data)
+	case "resolvevhost":
+		response, err = h.resolveVhost(request.Data)
 	case "reloadwebserver":
 		err = h.reloadWebServer(request.Data)
 	case "changecertbotstatus":
@@ -46,6 +71,11 @@ func (h *MainHandler) Handle(request router.Request) (any, error) {
 		response, err = nil, fmt.Errorf("invalid action '%s' for module '%s'", action, request.GetModule())
 	}
 
+	// the cache is invalidated before the watcher reports the agent's own changes
+	if !slices.Contains(mainHandlerReadOnlyActions, request.GetAction()) {
+		h.webServerCache.Invalidate()
+	}
+
 	return response, err
 }
 
@@ -81,13 +111,13 @@ func (h *MainHandler) getServerData() (agentintegration.ServerData, error) {
 	return serverData, nil
 }
 
-func (h *MainHandler) getVhosts() ([]agentintegration.VirtualHost, error) {
-	webServerCodes := webserver.GetSupportedWebServers()
-	var vhosts []agentintegration.VirtualHost
+func (h *MainHandler) getVhosts() ([]contract.VirtualHost, error) {
+	var vhosts []contract.VirtualHost
 	options := h.config.ToMap()
+	webServerCodes := webserver.GetWebServers(options)
 
 	for _, webServerCode := range webServerCodes {
-		webserver, err := webserver.CreateWebServer(webServerCode, options)
+		webserver, err := h.webServerCache.GetWebServer(webServerCode, options)
 
 		if err != nil {
 			h.logger.Error(err.Error())
@@ -101,12 +131,30 @@ func (h *MainHandler) getVhosts() ([]agentintegration.VirtualHost, error) {
 			continue
 		}
 
+		wVhosts, err = h.certManager.ResolveVhostCertificates(wVhosts)
+
+		if err != nil {
+			return nil, err
+		}
+
+		wVhosts = tlsprofile.ResolveVhostTlsProfiles(webserver, wVhosts)
+
 		vhosts = append(vhosts, contract.ConvertVirtualHosts(wVhosts)...)
 	}
 
 	return vhosts, nil
 }
 
+func (h *MainHandler) getStreamServers() ([]contract.StreamServer, error) {
+	servers, err := h.certManager.GetStreamServers()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return contract.ConvertStreamServers(servers), nil
+}
+
 func (h *MainHandler) getVhostCertificate(data any) (*agentintegration.Certificate, error) {
 	mData, ok := data.(map[string]any)
 
@@ -138,8 +186,8 @@ func (h *MainHandler) getVhostCertificate(data any) (*agentintegration.Certifica
 	return contract.ConvertCertificate(cert), nil
 }
 
-func (h *MainHandler) getVhostConfig(data any) (agentintegration.VirtualHostConfigResponseData, error) {
-	var response agentintegration.VirtualHostConfigResponseData
+func (h *MainHandler) getVhostConfig(data any) (contract.VirtualHostConfigResponseData, error) {
+	var response contract.VirtualHostConfigResponseData
 	var request agentintegration.VirtualHostConfigRequestData
 
 	err := mapstructure.Decode(data, &request)
@@ -149,7 +197,7 @@ func (h *MainHandler) getVhostConfig(data any) (agentintegration.VirtualHostConf
 	}
 
 	options := h.config.ToMap()
-	wServer, err := webserver.CreateWebServer(request.WebServer, options)
+	wServer, err := h.webServerCache.GetWebServer(request.WebServer, options)
 
 	if err != nil {
 		return response, err
@@ -178,10 +226,115 @@ func (h *MainHandler) getVhostConfig(data any) (agentintegration.VirtualHostConf
 	}
 
 	response.Content = string(content)
+	response.Checksum = utils.GetContentChecksum(content)
 
 	return response, nil
 }
 
+// setVhostConfig replaces content of the vhost config file. The checksum of the replaced content must match the file,
+// so changes made after the config was read are not overwritten. Changes are rolled back if config test or reload fails.
+func (h *MainHandler) setVhostConfig(data any) (*contract.VirtualHostConfigResponseData, error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	var request contract.VirtualHostConfigSetRequestData
+	err := mapstructure.Decode(data, &request)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid request data: %v", err)
+	}
+
+	if request.Checksum == "" {
+		return nil, errors.New("invalid request data: checksum of the replaced config is not specified")
+	}
+
+	wServer, err := webserver.CreateWebServer(request.WebServer, h.config.ToMap())
+
+	if err != nil {
+		return nil, err
+	}
+
+	vhost, err := wServer.GetVhostByName(request.ServerName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if vhost == nil {
+		return nil, fmt.Errorf("vhost %s not found", request.ServerName)
+	}
+
+	// the symlink target is changed, so the backup is not created in the directory of enabled hosts
+	filePath, err := filepath.EvalSymlinks(vhost.FilePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(filePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(filePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if utils.GetContentChecksum(content) != request.Checksum {
+		return nil, fmt.Errorf("vhost %s config was changed after it was read", request.ServerName)
+	}
+
+	sReverter, err := reverter.CreateReverter(wServer, h.logger)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = reverter.ApplyChanges(wServer, sReverter, h.logger, []string{filePath}, func() error {
+		return os.WriteFile(filePath, []byte(request.Content), info.Mode().Perm())
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var response contract.VirtualHostConfigResponseData
+	response.Content = request.Content
+	response.Checksum = utils.GetContentChecksum([]byte(request.Content))
+
+	return &response, nil
+}
+
+func (h *MainHandler) resolveVhost(data any) (*contract.VirtualHost, error) {
+	var request contract.VhostResolveRequestData
+	err := mapstructure.Decode(data, &request)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid request data: %v", err)
+	}
+
+	wServer, err := h.webServerCache.GetWebServer(request.WebServer, h.config.ToMap())
+
+	if err != nil {
+		return nil, err
+	}
+
+	vhost, err := webserver.ResolveVhost(wServer, request.HostName, request.Port)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if vhost == nil {
+		return nil, fmt.Errorf("no vhost serves %s", request.HostName)
+	}
+
+	return contract.ConvertVirtualHost(vhost), nil
+}
+
 func (h *MainHandler) reloadWebServer(data any) error {
 	h.mx.Lock()
 	defer h.mx.Unlock()
@@ -194,7 +347,7 @@ func (h *MainHandler) reloadWebServer(data any) error {
 		return fmt.Errorf("invalid request data: %v", err)
 	}
 
-	wServer, err := webserver.CreateWebServer(request.WebServer, h.config.ToMap())
+	wServer, err := h.webServerCache.GetWebServer(request.WebServer, h.config.ToMap())
 
 	if err != nil {
 		return err
@@ -243,10 +396,29 @@ func (h *MainHandler) changeCertbotstatus(data any) (agentintegration.ChangeCert
 	return response, nil
 }
 
-func CreateMainHandler(config *config.Config, logger logger.Logger, mx *sync.Mutex) *MainHandler {
-	return &MainHandler{
-		config: config,
-		logger: logger,
-		mx:     mx,
+func CreateMainHandler(
+	config *config.Config,
+	logger logger.Logger,
+	mx *sync.Mutex,
+	webServerCache *webserver.WebServerCache,
+) (*MainHandler, error) {
+	certManager, err := certificates.CreateCertificateManager(
+		config,
+		webserver.CreateWebServer,
+		reverter.CreateReverter,
+		logger,
+		mx,
+	)
+
+	if err != nil {
+		return nil, err
 	}
+
+	return &MainHandler{
+		certManager:    certManager,
+		config:         config,
+		logger:         logger,
+		mx:             mx,
+		webServerCache: webServerCache,
+	}, nil
 }
@@ -0,0 +1,130 @@
+//go:build common
+
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/utils"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const vhostConfigNginxConfig = `events {}
+
+http {
+    include sites-enabled/*.conf;
+}
+`
+
+const vhostConfigNginxHostConfig = `server {
+    listen 80;
+    server_name example.com;
+    root /var/www/html;
+}
+`
+
+const vhostConfigNginxChangedHostConfig = `server {
+    listen 80;
+    server_name example.com;
+    root /var/www/example.com;
+}
+`
+
+func TestSetVhostConfig(t *testing.T) {
+	hostPath := createVhostConfigNginx(t, "true")
+	handler := createVhostConfigHandler(t)
+
+	response, err := handler.setVhostConfig(getVhostConfigSetRequest(vhostConfigNginxChangedHostConfig, vhostConfigNginxHostConfig))
+	require.Nil(t, err)
+	assert.Equal(t, vhostConfigNginxChangedHostConfig, response.Content)
+	assert.Equal(t, utils.GetContentChecksum([]byte(vhostConfigNginxChangedHostConfig)), response.Checksum)
+
+	content, err := os.ReadFile(hostPath)
+	require.Nil(t, err)
+	assert.Equal(t, vhostConfigNginxChangedHostConfig, string(content))
+}
+
+func TestSetVhostConfigChecksumMismatch(t *testing.T) {
+	hostPath := createVhostConfigNginx(t, "true")
+	handler := createVhostConfigHandler(t)
+
+	// the checksum of the config read before the host was changed
+	_, err := handler.setVhostConfig(getVhostConfigSetRequest(vhostConfigNginxChangedHostConfig, "server {}\n"))
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "was changed after it was read")
+
+	content, err := os.ReadFile(hostPath)
+	require.Nil(t, err)
+	assert.Equal(t, vhostConfigNginxHostConfig, string(content))
+}
+
+func TestSetVhostConfigRollback(t *testing.T) {
+	hostPath := createVhostConfigNginx(t, "false")
+	handler := createVhostConfigHandler(t)
+
+	_, err := handler.setVhostConfig(getVhostConfigSetRequest(vhostConfigNginxChangedHostConfig, vhostConfigNginxHostConfig))
+	require.NotNil(t, err)
+
+	content, err := os.ReadFile(hostPath)
+	require.Nil(t, err)
+	assert.Equal(t, vhostConfigNginxHostConfig, string(content))
+
+	// the enabled host is still a symlink to the original config
+	linkPath := filepath.Join(filepath.Dir(filepath.Dir(hostPath)), "sites-enabled", "example.com.conf")
+	target, err := os.Readlink(linkPath)
+	require.Nil(t, err)
+	assert.Equal(t, hostPath, target)
+}
+
+// createVhostConfigNginx creates nginx config with the host enabled by symlink, nginxBin is used for config test
+func createVhostConfigNginx(t *testing.T, nginxBin string) string {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(vhostConfigNginxConfig), 0644))
+
+	for _, name := range []string{"sites-available", "sites-enabled"} {
+		require.Nil(t, os.MkdirAll(filepath.Join(dir, name), 0755))
+	}
+
+	hostPath := filepath.Join(dir, "sites-available", "example.com.conf")
+	require.Nil(t, os.WriteFile(hostPath, []byte(vhostConfigNginxHostConfig), 0644))
+	require.Nil(t, os.Symlink(hostPath, filepath.Join(dir, "sites-enabled", "example.com.conf")))
+
+	options := map[string]string{
+		config.NginxRootOpt:           dir,
+		config.NginxBinOpt:            nginxBin,
+		config.NginxReloadStrategyOpt: "command",
+		config.NginxReloadCommandOpt:  "true",
+	}
+
+	for key, value := range options {
+		viper.Set(key, value)
+		t.Cleanup(func() { viper.Set(key, nil) })
+	}
+
+	return hostPath
+}
+
+func createVhostConfigHandler(t *testing.T) *MainHandler {
+	return &MainHandler{
+		config: &config.Config{},
+		logger: &logger.TestLogger{T: t},
+		mx:     &sync.Mutex{},
+	}
+}
+
+func getVhostConfigSetRequest(content, readContent string) map[string]any {
+	return map[string]any{
+		"WebServer":  webserver.WebServerNginxCode,
+		"ServerName": "example.com",
+		"Content":    content,
+		"Checksum":   utils.GetContentChecksum([]byte(readContent)),
+	}
+}
@@ -11,37 +11,65 @@ import (
 )
 
 const (
-	defaultPort                = 60150
-	defaultCaServer            = "https://acme-v02.api.letsencrypt.org/directory"
-	defaultVarDir              = "/usr/local/r2dtools/sslbot/var"
-	defaultCertBotDataDir      = "/etc/letsencrypt/live"
-	defaultCertBotBin          = "certbot"
-	defaultNginxRoot           = "/etc/nginx"
-	defaultNginxAcmeCommonDir  = "/var/www/html/"
-	defaultApacheRoot          = "/etc/apache2"
-	defaultApacheAcmeCommonDir = "/var/www/html/"
+	defaultPort                  = 60150
+	defaultCaServer              = "https://acme-v02.api.letsencrypt.org/directory"
+	defaultVarDir                = "/usr/local/r2dtools/sslbot/var"
+	defaultCertBotDataDir        = "/etc/letsencrypt/live"
+	defaultCertBotBin            = "certbot"
+	defaultNginxRoot             = "/etc/nginx"
+	defaultNginxAcmeCommonDir    = "/var/www/html/"
+	defaultApacheRoot            = "/etc/apache2"
+	defaultRhelApacheRoot        = "/etc/httpd"
+	defaultApacheAcmeCommonDir   = "/var/www/html/"
+	defaultCertHistorySize       = 5
+	defaultHAProxyConfig         = "/etc/haproxy/haproxy.cfg"
+	defaultLighttpdConfig        = "/etc/lighttpd/lighttpd.conf"
+	defaultLighttpdAcmeCommonDir = "/var/www/html/"
+	defaultTraefikDynamicConfig  = "/etc/traefik/dynamic"
+	defaultReloadStrategy        = "signal"
+	defaultNginxBin              = "nginx"
+	defaultNginxSystemdUnit      = "nginx"
+	defaultApachectlBin          = "apachectl"
+	defaultHAProxyBin            = "haproxy"
+	defaultLighttpdBin           = "lighttpd"
+	defaultDeployVerifyTimeout   = 10
 )
 
 var isDevMode = true
 var Version string
 
 type Config struct {
-	LogFile             string
-	Port                int
-	Token               string
-	IsDevMode           bool
-	Version             string
-	LegoBin             string
-	CaServer            string
-	ConfigFilePath      string
-	VarDir              string
-	CertBotEnabled      bool
-	CertBotBin          string
-	CertBotWokrDir      string
-	NginxAcmeCommonDir  string
-	ApacheAcmeCommonDir string
-	Debug               bool
-	rootPath            string
+	LogFile               string
+	Port                  int
+	Token                 string
+	IsDevMode             bool
+	Version               string
+	LegoBin               string
+	CaServer              string
+	ConfigFilePath        string
+	VarDir                string
+	CertBotEnabled        bool
+	CertBotBin            string
+	CertBotWokrDir        string
+	NginxAcmeCommonDir    string
+	ApacheAcmeCommonDir   string
+	LighttpdAcmeCommonDir string
+	IntermediatesDir      string
+	AiaFetchEnabled       bool
+	AiaEndpoint           string
+	CertHistorySize       int
+	S3Endpoint            string
+	S3Bucket              string
+	S3Prefix              string
+	S3Region              string
+	S3AccessKey           string
+	S3SecretKey           string
+	S3UseSsl              bool
+	WebServerInstances    []WebServerInstance
+	DeployVerifyEnabled   bool
+	DeployVerifyTimeout   int
+	Debug                 bool
+	rootPath              string
 }
 
 func GetConfig() (*Config, error) {
@@ -86,8 +114,24 @@ func GetConfig() (*Config, error) {
 	viper.SetDefault(NginxAcmeCommonDirOpt, defaultNginxAcmeCommonDir)
 	viper.SetDefault(ApacheAcmeCommonDirOpt, defaultApacheAcmeCommonDir)
 	viper.SetDefault(NginxRootOpt, defaultNginxRoot)
-	viper.SetDefault(ApacheRootOpt, defaultApacheRoot)
+	viper.SetDefault(ApacheRootOpt, detectRoot(defaultApacheRoot, defaultRhelApacheRoot))
+	viper.SetDefault(HAProxyConfigOpt, defaultHAProxyConfig)
+	viper.SetDefault(LighttpdConfigOpt, defaultLighttpdConfig)
+	viper.SetDefault(LighttpdAcmeCommonDirOpt, defaultLighttpdAcmeCommonDir)
+	viper.SetDefault(TraefikDynamicConfigOpt, defaultTraefikDynamicConfig)
+	viper.SetDefault(NginxReloadStrategyOpt, defaultReloadStrategy)
+	viper.SetDefault(NginxBinOpt, defaultNginxBin)
+	viper.SetDefault(NginxSystemdUnitOpt, defaultNginxSystemdUnit)
+	viper.SetDefault(ApacheReloadStrategyOpt, defaultReloadStrategy)
+	viper.SetDefault(ApachectlBinOpt, defaultApachectlBin)
+	viper.SetDefault(HAProxyBinOpt, defaultHAProxyBin)
+	viper.SetDefault(LighttpdBinOpt, defaultLighttpdBin)
 	viper.SetDefault(DebugOpt, false)
+	viper.SetDefault(AiaFetchEnabledOpt, false)
+	viper.SetDefault(CertHistorySizeOpt, defaultCertHistorySize)
+	viper.SetDefault(S3UseSslOpt, true)
+	viper.SetDefault(DeployVerifyEnabledOpt, false)
+	viper.SetDefault(DeployVerifyTimeoutOpt, defaultDeployVerifyTimeout)
 
 	if com.IsFile(configFilePath) {
 		configFile, err := os.OpenFile(configFilePath, os.O_RDONLY, 0644)
@@ -136,6 +180,10 @@ func (c *Config) GetPathInsideVarDir(path ...string) string {
 	return filepath.Join(parts...)
 }
 
+func (c *Config) IsS3StorageEnabled() bool {
+	return c.S3Endpoint != "" && c.S3Bucket != ""
+}
+
 func (c *Config) ToMap() map[string]string {
 	settings := viper.AllSettings()
 	options := make(map[string]string)
@@ -146,6 +194,8 @@ func (c *Config) ToMap() map[string]string {
 		}
 	}
 
+	addWebServerInstanceOptions(options, c.WebServerInstances)
+
 	return options
 }
 
@@ -199,7 +249,26 @@ func setDynamicParams(c *Config) {
 	c.CertBotWokrDir = viper.GetString(CertBotWorkDirOpt)
 	c.NginxAcmeCommonDir = viper.GetString(NginxAcmeCommonDirOpt)
 	c.ApacheAcmeCommonDir = viper.GetString(ApacheAcmeCommonDirOpt)
+	c.LighttpdAcmeCommonDir = viper.GetString(LighttpdAcmeCommonDirOpt)
 	c.Debug = viper.GetBool(DebugOpt)
+	c.IntermediatesDir = viper.GetString(IntermediatesDirOpt)
+	c.AiaFetchEnabled = viper.GetBool(AiaFetchEnabledOpt)
+	c.AiaEndpoint = viper.GetString(AiaEndpointOpt)
+	c.CertHistorySize = viper.GetInt(CertHistorySizeOpt)
+	c.S3Endpoint = viper.GetString(S3EndpointOpt)
+	c.S3Bucket = viper.GetString(S3BucketOpt)
+	c.S3Prefix = viper.GetString(S3PrefixOpt)
+	c.S3Region = viper.GetString(S3RegionOpt)
+	c.S3AccessKey = viper.GetString(S3AccessKeyOpt)
+	c.S3SecretKey = viper.GetString(S3SecretKeyOpt)
+	c.S3UseSsl = viper.GetBool(S3UseSslOpt)
+	c.WebServerInstances = loadWebServerInstances()
+	c.DeployVerifyEnabled = viper.GetBool(DeployVerifyEnabledOpt)
+	c.DeployVerifyTimeout = viper.GetInt(DeployVerifyTimeoutOpt)
+
+	if c.IntermediatesDir == "" {
+		c.IntermediatesDir = c.GetPathInsideVarDir("intermediates")
+	}
 }
 
 func (c *Config) OnChange(callback func()) {
@@ -208,3 +277,14 @@ func (c *Config) OnChange(callback func()) {
 		callback()
 	})
 }
+
+// detectRoot returns the first existing directory, Debian root is returned if none of them exists
+func detectRoot(roots ...string) string {
+	for _, root := range roots {
+		if com.IsDir(root) {
+			return root
+		}
+	}
+
+	return roots[0]
+}
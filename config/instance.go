@@ -0,0 +1,115 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// WebServerInstance is an additional instance of a webserver with its own config root.
+// The instance is addressed by its name everywhere a webserver code is accepted.
+type WebServerInstance struct {
+	Name      string `mapstructure:"name"`
+	WebServer string `mapstructure:"webserver"`
+	// Root is the config root of nginx/apache, the config file of haproxy/lighttpd or the dynamic config of traefik
+	Root          string `mapstructure:"root"`
+	PidFile       string `mapstructure:"pid_file"`
+	ReloadCommand string `mapstructure:"reload_command"`
+}
+
+// keys of the flattened instance options: webserver_instances.<name>.<key>
+const (
+	instanceTypeKey          = "webserver"
+	instanceRootKey          = "root"
+	instancePidFileKey       = "pid_file"
+	instanceReloadCommandKey = "reload_command"
+)
+
+// instanceRootOptions maps webserver codes to the options the instance root overrides
+var instanceRootOptions = map[string]string{
+	"nginx":    NginxRootOpt,
+	"apache":   ApacheRootOpt,
+	"haproxy":  HAProxyConfigOpt,
+	"lighttpd": LighttpdConfigOpt,
+	"traefik":  TraefikDynamicConfigOpt,
+}
+
+// GetWebServerInstanceNames returns names of the instances flattened by ToMap
+func GetWebServerInstanceNames(options map[string]string) []string {
+	var names []string
+	prefix := WebServerInstancesOpt + "."
+	suffix := "." + instanceTypeKey
+
+	for key := range options {
+		if strings.HasPrefix(key, prefix) && strings.HasSuffix(key, suffix) {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(key, prefix), suffix))
+		}
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// GetWebServerInstanceOptions returns the webserver code of the instance and the options to create the webserver with.
+// The instance root replaces the root option of the webserver.
+func GetWebServerInstanceOptions(name string, options map[string]string) (string, map[string]string, bool) {
+	code := options[getInstanceOptionKey(name, instanceTypeKey)]
+
+	if code == "" {
+		return "", nil, false
+	}
+
+	instanceOptions := maps.Clone(options)
+	instanceOptions[WebServerInstanceOpt] = name
+	instanceOptions[WebServerInstancePidFileOpt] = options[getInstanceOptionKey(name, instancePidFileKey)]
+	instanceOptions[WebServerInstanceReloadCommandOpt] = options[getInstanceOptionKey(name, instanceReloadCommandKey)]
+
+	if rootOption, ok := instanceRootOptions[code]; ok {
+		if root := options[getInstanceOptionKey(name, instanceRootKey)]; root != "" {
+			instanceOptions[rootOption] = root
+		}
+	}
+
+	return code, instanceOptions, true
+}
+
+func loadWebServerInstances() []WebServerInstance {
+	var instances []WebServerInstance
+
+	if err := viper.UnmarshalKey(WebServerInstancesOpt, &instances); err != nil {
+		return nil
+	}
+
+	var validInstances []WebServerInstance
+
+	for _, instance := range instances {
+		if instance.Name == "" || instance.WebServer == "" {
+			continue
+		}
+
+		// an instance can not shadow a webserver
+		if _, ok := instanceRootOptions[instance.Name]; ok {
+			continue
+		}
+
+		validInstances = append(validInstances, instance)
+	}
+
+	return validInstances
+}
+
+func addWebServerInstanceOptions(options map[string]string, instances []WebServerInstance) {
+	for _, instance := range instances {
+		options[getInstanceOptionKey(instance.Name, instanceTypeKey)] = instance.WebServer
+		options[getInstanceOptionKey(instance.Name, instanceRootKey)] = instance.Root
+		options[getInstanceOptionKey(instance.Name, instancePidFileKey)] = instance.PidFile
+		options[getInstanceOptionKey(instance.Name, instanceReloadCommandKey)] = instance.ReloadCommand
+	}
+}
+
+func getInstanceOptionKey(name, option string) string {
+	return WebServerInstancesOpt + "." + name + "." + option
+}
@@ -1,16 +1,52 @@
 package config
 
 const (
-	TokenOpt               = "token"
-	VarDirOpt              = "var_dir"
-	PortOpt                = "port"
-	CaServerOpt            = "ca_server"
-	NginxRootOpt           = "nginx_root"
-	NginxAcmeCommonDirOpt  = "nginx_acme_common_dir"
-	ApacheAcmeCommonDirOpt = "apache_acme_common_dir"
-	CertBotBinOpt          = "certbot_bin"
-	CertBotWorkDirOpt      = "certbot_work_dir"
-	CertBotEnabledOpt      = "certbot_enabled"
-	DebugOpt               = "debug"
-	ApacheRootOpt          = "apache_root"
+	TokenOpt                 = "token"
+	VarDirOpt                = "var_dir"
+	PortOpt                  = "port"
+	CaServerOpt              = "ca_server"
+	NginxRootOpt             = "nginx_root"
+	NginxAcmeCommonDirOpt    = "nginx_acme_common_dir"
+	ApacheAcmeCommonDirOpt   = "apache_acme_common_dir"
+	CertBotBinOpt            = "certbot_bin"
+	CertBotWorkDirOpt        = "certbot_work_dir"
+	CertBotEnabledOpt        = "certbot_enabled"
+	DebugOpt                 = "debug"
+	ApacheRootOpt            = "apache_root"
+	IntermediatesDirOpt      = "intermediates_dir"
+	AiaFetchEnabledOpt       = "aia_fetch_enabled"
+	AiaEndpointOpt           = "aia_endpoint"
+	CertHistorySizeOpt       = "cert_history_size"
+	S3EndpointOpt            = "s3_endpoint"
+	S3BucketOpt              = "s3_bucket"
+	S3PrefixOpt              = "s3_prefix"
+	S3RegionOpt              = "s3_region"
+	S3AccessKeyOpt           = "s3_access_key"
+	S3SecretKeyOpt           = "s3_secret_key"
+	S3UseSslOpt              = "s3_use_ssl"
+	HAProxyConfigOpt         = "haproxy_config"
+	HAProxyMasterSocketOpt   = "haproxy_master_socket"
+	HAProxyBinOpt            = "haproxy_bin"
+	LighttpdConfigOpt        = "lighttpd_config"
+	LighttpdAcmeCommonDirOpt = "lighttpd_acme_common_dir"
+	LighttpdBinOpt           = "lighttpd_bin"
+	TraefikDynamicConfigOpt  = "traefik_dynamic_config"
+	WebServerInstancesOpt    = "webserver_instances"
+	NginxReloadStrategyOpt   = "nginx_reload_strategy"
+	NginxReloadCommandOpt    = "nginx_reload_command"
+	NginxBinOpt              = "nginx_bin"
+	NginxSystemdUnitOpt      = "nginx_systemd_unit"
+	ApacheReloadStrategyOpt  = "apache_reload_strategy"
+	ApacheReloadCommandOpt   = "apache_reload_command"
+	ApachectlBinOpt          = "apachectl_bin"
+	ApacheSystemdUnitOpt     = "apache_systemd_unit"
+	DeployVerifyEnabledOpt   = "deploy_verify_enabled"
+	DeployVerifyTimeoutOpt   = "deploy_verify_timeout"
+)
+
+// Options of a webserver instance. They are set in the options the instance webserver is created with.
+const (
+	WebServerInstanceOpt              = "webserver_instance"
+	WebServerInstancePidFileOpt       = "webserver_instance_pid_file"
+	WebServerInstanceReloadCommandOpt = "webserver_instance_reload_command"
 )
@@ -0,0 +1,179 @@
+package certificates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/r2dtools/sslbot/internal/certificates/request"
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/utils"
+	"github.com/unknwon/com"
+)
+
+type UnmanagedCertificate struct {
+	CertPath    string
+	KeyPath     string
+	Certificate *dto.Certificate
+	Vhosts      []CertStorageVhost
+}
+
+// GetUnmanagedCertificates returns certificates referenced by the hosts that are outside every storage
+func (c *CertificateManager) GetUnmanagedCertificates() ([]UnmanagedCertificate, error) {
+	certPathMap, err := c.getStorageCertPathMap()
+
+	if err != nil {
+		return nil, err
+	}
+
+	certs := []UnmanagedCertificate{}
+	certIndexMap := map[string]int{}
+
+	for _, vhost := range c.getVhosts() {
+		if vhost.CertificatePath == "" || resolveVhostCertificate(&vhost, certPathMap) {
+			continue
+		}
+
+		certPath := filepath.Clean(vhost.CertificatePath)
+		index, ok := certIndexMap[certPath]
+
+		if !ok {
+			keyPath := vhost.CertificateKeyPath
+
+			if keyPath != "" {
+				keyPath = filepath.Clean(keyPath)
+			}
+
+			certs = append(certs, UnmanagedCertificate{
+				CertPath:    certPath,
+				KeyPath:     keyPath,
+				Certificate: vhost.Certificate,
+			})
+			index = len(certs) - 1
+			certIndexMap[certPath] = index
+		}
+
+		certs[index].Vhosts = append(certs[index].Vhosts, CertStorageVhost{
+			WebServer:  vhost.WebServer,
+			ServerName: vhost.ServerName,
+			FilePath:   vhost.FilePath,
+		})
+	}
+
+	return certs, nil
+}
+
+// Adopt moves an unmanaged certificate to the default storage by copying or linking it.
+// Optionally the hosts that use the certificate are switched to the storage path.
+func (c *CertificateManager) Adopt(request request.AdoptRequest) (*dto.Certificate, error) {
+	if request.CertName == "" {
+		return nil, errors.New("certificate name is not specified")
+	}
+
+	unmanagedCerts, err := c.GetUnmanagedCertificates()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var unmanagedCert *UnmanagedCertificate
+	certPath := filepath.Clean(request.CertPath)
+
+	for _, cert := range unmanagedCerts {
+		if cert.CertPath == certPath {
+			unmanagedCert = &cert
+
+			break
+		}
+	}
+
+	if unmanagedCert == nil {
+		return nil, fmt.Errorf("unmanaged certificate %s not found", request.CertPath)
+	}
+
+	storage, err := c.getStorage(Default)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defaultStorage, ok := storage.(*DefaultStorage)
+
+	if !ok {
+		return nil, errors.New("invalid storage")
+	}
+
+	storageCertPath, _, err := defaultStorage.GetCertificatePath(request.CertName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if com.IsExist(storageCertPath) {
+		return nil, fmt.Errorf("certificate %s already exists in the storage", request.CertName)
+	}
+
+	if request.Link {
+		if unmanagedCert.KeyPath != "" && unmanagedCert.KeyPath != unmanagedCert.CertPath {
+			return nil, errors.New("certificate with a separate private key file can not be linked, copy it instead")
+		}
+
+		if err := defaultStorage.LinkCertificate(request.CertName, unmanagedCert.CertPath); err != nil {
+			return nil, err
+		}
+	} else {
+		pemData, err := readCertificateWithKey(unmanagedCert.CertPath, unmanagedCert.KeyPath)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if storageCertPath, _, err = c.AddStorageCertificate(request.CertName, pemData); err != nil {
+			return nil, err
+		}
+	}
+
+	if request.RewriteVhosts {
+		var errs []error
+
+		for _, vhost := range unmanagedCert.Vhosts {
+			if err := c.deployCertificate(vhost.WebServer, vhost.ServerName, storageCertPath, storageCertPath); err != nil {
+				errs = append(errs, fmt.Errorf("failed to switch %s to the adopted certificate: %v", vhost.ServerName, err))
+			}
+		}
+
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+	}
+
+	return utils.GetCertificateFromFile(storageCertPath)
+}
+
+func readCertificateWithKey(certPath, keyPath string) (string, error) {
+	certContent, err := os.ReadFile(certPath)
+
+	if err != nil {
+		return "", fmt.Errorf("could not read certificate content: %v", err)
+	}
+
+	pemData := string(certContent)
+
+	if keyPath == "" || keyPath == certPath {
+		return pemData, nil
+	}
+
+	keyContent, err := os.ReadFile(keyPath)
+
+	if err != nil {
+		return "", fmt.Errorf("could not read private key content: %v", err)
+	}
+
+	if !strings.HasSuffix(pemData, "\n") {
+		pemData += "\n"
+	}
+
+	return pemData + string(keyContent), nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/r2dtools/sslbot/config"
 	"github.com/r2dtools/sslbot/internal/certificates/deploy"
@@ -35,10 +36,13 @@ func (d *NilCertificateDeployer) DeployCertificate(
 }
 
 type DefaultCertificateDeployer struct {
-	mx        *sync.Mutex
-	webServer webserver.WebServer
-	reverter  reverter.Reverter
-	logger    logger.Logger
+	mx            *sync.Mutex
+	webServer     webserver.WebServer
+	reverter      reverter.Reverter
+	logger        logger.Logger
+	verifyTimeout time.Duration
+	// options are used to parse the webserver config again on verification
+	options map[string]string
 }
 
 func (d *DefaultCertificateDeployer) DeployCertificate(
@@ -82,6 +86,129 @@ func (d *DefaultCertificateDeployer) DeployCertificate(
 		return err
 	}
 
+	if err = webserver.TestConfig(d.webServer); err != nil {
+		d.rollback()
+
+		return err
+	}
+
+	if !preventReload {
+		if err := d.reloadWebServer(); err != nil {
+			d.rollback()
+
+			return err
+		}
+
+		if err := d.verifyDeployment(vhost.WebServer, serverName, certPath); err != nil {
+			d.rollback()
+
+			if rErr := d.reloadWebServer(); rErr != nil {
+				d.logger.Error(fmt.Sprintf("failed to reload webserver after rollback: %v", rErr))
+			}
+
+			return err
+		}
+	}
+
+	if err = d.reverter.Commit(); err != nil {
+		d.logger.Error(fmt.Sprintf("commit configuration changes failed: %v", err))
+	}
+
+	return nil
+}
+
+// UndeployCertificate removes ssl from the host and reloads webserver. Changes are rolled back on failure.
+func (d *DefaultCertificateDeployer) UndeployCertificate(serverName string, preventReload bool) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	vhost, err := d.webServer.GetVhostByName(serverName)
+
+	if err != nil {
+		return err
+	}
+
+	if vhost == nil {
+		return fmt.Errorf("virtual host %s not found", serverName)
+	}
+
+	undeployer, err := deploy.GetCertificateUndeployer(d.webServer, d.reverter, d.logger)
+
+	if err != nil {
+		return err
+	}
+
+	if err = undeployer.UndeployCertificate(vhost); err != nil {
+		d.rollback()
+
+		return err
+	}
+
+	if err = webserver.TestConfig(d.webServer); err != nil {
+		d.rollback()
+
+		return err
+	}
+
+	if !preventReload {
+		if err := d.reloadWebServer(); err != nil {
+			d.rollback()
+
+			return err
+		}
+	}
+
+	if err = d.reverter.Commit(); err != nil {
+		d.logger.Error(fmt.Sprintf("commit configuration changes failed: %v", err))
+	}
+
+	return nil
+}
+
+// DeployStreamCertificate deploys the certificate to the TCP/TLS stream server with the key
+func (d *DefaultCertificateDeployer) DeployStreamCertificate(
+	serverKey string,
+	certPath string,
+	keyPath string,
+	preventReload bool,
+) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+
+	wServer, ok := d.webServer.(webserver.StreamWebServer)
+
+	if !ok {
+		return fmt.Errorf("webserver %s does not support stream servers", d.webServer.GetCode())
+	}
+
+	server, err := wServer.GetStreamServer(serverKey)
+
+	if err != nil {
+		return err
+	}
+
+	if server == nil {
+		return fmt.Errorf("stream server %s not found", serverKey)
+	}
+
+	deployer, err := deploy.GetStreamCertificateDeployer(d.webServer, d.reverter, d.logger)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = deployer.DeployStreamCertificate(server, certPath, keyPath); err != nil {
+		d.rollback()
+
+		return err
+	}
+
+	if err = webserver.TestConfig(d.webServer); err != nil {
+		d.rollback()
+
+		return err
+	}
+
 	if !preventReload {
 		if err := d.reloadWebServer(); err != nil {
 			d.rollback()
@@ -126,6 +253,32 @@ func (d *DefaultCertificateDeployer) reloadWebServer() error {
 	return processManager.Reload()
 }
 
+// verifyDeployment checks the certificate served after reload, verification is skipped if timeout is not set.
+// The config is parsed again since the host parsed before deployment has no ssl addresses added by the deployer.
+func (d *DefaultCertificateDeployer) verifyDeployment(webServerName, serverName, certPath string) error {
+	if d.verifyTimeout <= 0 {
+		return nil
+	}
+
+	wServer, err := webserver.CreateWebServer(webServerName, d.options)
+
+	if err != nil {
+		return err
+	}
+
+	vhost, err := wServer.GetVhostByName(serverName)
+
+	if err != nil {
+		return err
+	}
+
+	if vhost == nil {
+		return fmt.Errorf("virtual host %s not found", serverName)
+	}
+
+	return verifyServedCertificate(vhost, certPath, d.verifyTimeout)
+}
+
 func (d *DefaultCertificateDeployer) rollback() {
 	if rErr := d.reverter.Rollback(); rErr != nil {
 		d.logger.Error("rollback failed: %v", rErr)
@@ -144,6 +297,44 @@ func createCertificateDeployer(
 		return &NilCertificateDeployer{}
 	}
 
+	var verifyTimeout time.Duration
+
+	if config.DeployVerifyEnabled {
+		verifyTimeout = time.Duration(config.DeployVerifyTimeout) * time.Second
+	}
+
+	return &DefaultCertificateDeployer{
+		mx:            mx,
+		webServer:     webServer,
+		reverter:      reverter,
+		logger:        logger,
+		verifyTimeout: verifyTimeout,
+		options:       config.ToMap(),
+	}
+}
+
+// createCertificateUndeployer returns the default deployer regardless of certbot: certbot does not remove certificates from hosts
+func createCertificateUndeployer(
+	webServer webserver.WebServer,
+	reverter reverter.Reverter,
+	logger logger.Logger,
+	mx *sync.Mutex,
+) *DefaultCertificateDeployer {
+	return &DefaultCertificateDeployer{
+		mx:        mx,
+		webServer: webServer,
+		reverter:  reverter,
+		logger:    logger,
+	}
+}
+
+// createStreamCertificateDeployer returns the default deployer regardless of certbot: certbot does not manage stream servers
+func createStreamCertificateDeployer(
+	webServer webserver.WebServer,
+	reverter reverter.Reverter,
+	logger logger.Logger,
+	mx *sync.Mutex,
+) *DefaultCertificateDeployer {
 	return &DefaultCertificateDeployer{
 		mx:        mx,
 		webServer: webServer,
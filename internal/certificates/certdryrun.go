@@ -0,0 +1,146 @@
+package certificates
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/r2dtools/sslbot/internal/certificates/redirect"
+	"github.com/r2dtools/sslbot/internal/certificates/request"
+	"github.com/r2dtools/sslbot/internal/certificates/tlsprofile"
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/webserver"
+)
+
+// DryRunIssue returns config changes the deployment of the issued certificate would make. The certificate is not issued,
+// the deployment uses paths the certificate would be stored at.
+func (c *CertificateManager) DryRunIssue(request request.IssueRequest) ([]dto.ConfigDiff, error) {
+	if !request.Assign {
+		return []dto.ConfigDiff{}, nil
+	}
+
+	if c.config.CertBotEnabled {
+		return nil, errors.New("dry run is not supported for certificates deployed by certbot")
+	}
+
+	storage, err := c.getStorage(c.getAcmeStorageType())
+
+	if err != nil {
+		return nil, err
+	}
+
+	certPath, keyPath, err := storage.GetCertificatePath(request.ServerName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.dryRunDeploy(request.WebServer, request.ServerName, certPath, keyPath, request.Redirect, request.TlsProfile)
+}
+
+// DryRunAssign returns config changes the assignment of the storage certificate would make
+func (c *CertificateManager) DryRunAssign(request request.AssignRequest) ([]dto.ConfigDiff, error) {
+	storage, err := c.getStorage(CertStorageType(request.StorageType))
+
+	if err != nil {
+		return nil, err
+	}
+
+	certPath, keyPath, err := storage.GetCertificatePath(request.CertName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.dryRunDeploy(request.WebServer, request.ServerName, certPath, keyPath, request.Redirect, request.TlsProfile)
+}
+
+// dryRunDeploy deploys the certificate to the sandbox copy of the webserver config.
+// Redirect and TLS profile are applied to the config parsed again as it is done on deploy.
+func (c *CertificateManager) dryRunDeploy(
+	webServerCode string,
+	serverName string,
+	certPath string,
+	keyPath string,
+	enableRedirect bool,
+	tlsProfile string,
+) ([]dto.ConfigDiff, error) {
+	if tlsProfile != "" {
+		if _, err := tlsprofile.GetProfile(tlsProfile); err != nil {
+			return nil, err
+		}
+	}
+
+	wServer, err := c.wServerFactory(webServerCode, c.config.ToMap())
+
+	if err != nil {
+		return nil, err
+	}
+
+	sandbox, err := webserver.CreateSandbox(wServer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer sandbox.Remove()
+
+	sWebServer, err := sandbox.CreateWebServer()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sReverter, err := c.reverterFactory(sWebServer, c.logger)
+
+	if err != nil {
+		return nil, err
+	}
+
+	certDeployer := createCertificateDeployer(c.config, sWebServer, sReverter, c.logger, &sync.Mutex{})
+
+	if err = certDeployer.DeployCertificate(serverName, certPath, keyPath, true); err != nil {
+		return nil, err
+	}
+
+	if enableRedirect {
+		if sWebServer, err = sandbox.CreateWebServer(); err != nil {
+			return nil, err
+		}
+
+		if sReverter, err = c.reverterFactory(sWebServer, c.logger); err != nil {
+			return nil, err
+		}
+
+		redirectCommand, err := redirect.CreateRedirectChangeCommand(sWebServer, sReverter, c.logger, &sync.Mutex{})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err = redirectCommand.EnableRedirect(serverName); err != nil {
+			return nil, err
+		}
+	}
+
+	if tlsProfile != "" {
+		if sWebServer, err = sandbox.CreateWebServer(); err != nil {
+			return nil, err
+		}
+
+		if sReverter, err = c.reverterFactory(sWebServer, c.logger); err != nil {
+			return nil, err
+		}
+
+		tlsProfileCommand, err := tlsprofile.CreateTlsProfileChangeCommand(sWebServer, sReverter, c.logger, &sync.Mutex{})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err = tlsProfileCommand.ApplyTlsProfile(serverName, tlsProfile); err != nil {
+			return nil, err
+		}
+	}
+
+	return sandbox.GetDiffs()
+}
@@ -0,0 +1,274 @@
+package certificates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/utils"
+	"github.com/unknwon/com"
+)
+
+const (
+	historyCertFileName = "cert.pem"
+	historyKeyFileName  = "key.pem"
+)
+
+// CertHistory keeps previous versions of storage certificates.
+// Versions are stored as <var_dir>/history/<storage>/<cert name>/<version id>/{cert.pem,key.pem}
+// key.pem exists only if the private key is stored separately from the certificate.
+type CertHistory struct {
+	*sync.Mutex
+	path   string
+	size   int
+	logger logger.Logger
+}
+
+// Save copies the current certificate files to the history. Nothing is done if the certificate does not exist yet
+// or equals to the latest saved version.
+func (h *CertHistory) Save(storageType CertStorageType, certName, certPath, keyPath string) error {
+	if h.size <= 0 || !com.IsFile(certPath) {
+		return nil
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	certContent, err := os.ReadFile(certPath)
+
+	if err != nil {
+		return fmt.Errorf("could not read certificate content: %v", err)
+	}
+
+	fingerprint, err := utils.GetCertificateFingerprint(certContent)
+
+	if err != nil {
+		return err
+	}
+
+	versionIDs, err := h.getVersionIDs(storageType, certName)
+
+	if err != nil {
+		return err
+	}
+
+	if len(versionIDs) > 0 {
+		latestContent, err := os.ReadFile(filepath.Join(h.getCertPath(storageType, certName), versionIDs[0], historyCertFileName))
+
+		if err == nil {
+			if latestFingerprint, err := utils.GetCertificateFingerprint(latestContent); err == nil && latestFingerprint == fingerprint {
+				return nil
+			}
+		}
+	}
+
+	versionID := strconv.FormatInt(time.Now().UnixNano(), 10)
+	versionPath := filepath.Join(h.getCertPath(storageType, certName), versionID)
+
+	if err := os.MkdirAll(versionPath, 0700); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(versionPath, historyCertFileName), certContent, 0600); err != nil {
+		return fmt.Errorf("could not save certificate version: %v", err)
+	}
+
+	if keyPath != "" && keyPath != certPath {
+		keyContent, err := os.ReadFile(keyPath)
+
+		if err != nil {
+			return fmt.Errorf("could not read private key content: %v", err)
+		}
+
+		if err := os.WriteFile(filepath.Join(versionPath, historyKeyFileName), keyContent, 0600); err != nil {
+			return fmt.Errorf("could not save private key version: %v", err)
+		}
+	}
+
+	return h.prune(storageType, certName)
+}
+
+// GetVersions returns saved versions of the certificate, the newest first
+func (h *CertHistory) GetVersions(storageType CertStorageType, certName string) ([]dto.CertificateVersion, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	versionIDs, err := h.getVersionIDs(storageType, certName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []dto.CertificateVersion{}
+
+	for _, versionID := range versionIDs {
+		version, err := h.getVersion(storageType, certName, versionID)
+
+		if err != nil {
+			h.logger.Error("failed to load certificate %s version %s: %v", certName, versionID, err)
+
+			continue
+		}
+
+		versions = append(versions, *version)
+	}
+
+	return versions, nil
+}
+
+// Restore writes the certificate version content to the certificate files
+func (h *CertHistory) Restore(storageType CertStorageType, certName, versionID, certPath, keyPath string) error {
+	h.Lock()
+	defer h.Unlock()
+
+	versionPath := filepath.Join(h.getCertPath(storageType, certName), filepath.Base(versionID))
+
+	if !com.IsDir(versionPath) {
+		return fmt.Errorf("certificate %s version %s not found", certName, versionID)
+	}
+
+	certContent, err := os.ReadFile(filepath.Join(versionPath, historyCertFileName))
+
+	if err != nil {
+		return fmt.Errorf("could not read certificate version: %v", err)
+	}
+
+	historyKeyPath := filepath.Join(versionPath, historyKeyFileName)
+
+	if keyPath != "" && keyPath != certPath {
+		if !com.IsFile(historyKeyPath) {
+			return fmt.Errorf("private key of certificate %s version %s not found", certName, versionID)
+		}
+
+		keyContent, err := os.ReadFile(historyKeyPath)
+
+		if err != nil {
+			return fmt.Errorf("could not read private key version: %v", err)
+		}
+
+		if err := os.WriteFile(keyPath, keyContent, 0600); err != nil {
+			return fmt.Errorf("could not restore private key: %v", err)
+		}
+	}
+
+	if err := os.WriteFile(certPath, certContent, 0644); err != nil {
+		return fmt.Errorf("could not restore certificate: %v", err)
+	}
+
+	return nil
+}
+
+func (h *CertHistory) getVersion(storageType CertStorageType, certName, versionID string) (*dto.CertificateVersion, error) {
+	nanoseconds, err := strconv.ParseInt(versionID, 10, 64)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid version id: %v", err)
+	}
+
+	certPath := filepath.Join(h.getCertPath(storageType, certName), versionID, historyCertFileName)
+	certContent, err := os.ReadFile(certPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := utils.GetCertificateFingerprint(certContent)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := utils.GetCertificateFromFile(certPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.CertificateVersion{
+		ID:          versionID,
+		CreatedAt:   time.Unix(0, nanoseconds).Format(time.RFC3339),
+		Fingerprint: fingerprint,
+		Certificate: cert,
+	}, nil
+}
+
+// getVersionIDs returns version ids sorted from the newest to the oldest
+func (h *CertHistory) getVersionIDs(storageType CertStorageType, certName string) ([]string, error) {
+	entries, err := os.ReadDir(h.getCertPath(storageType, certName))
+
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("could not read certificate history: %v", err)
+	}
+
+	var versionIDs []int64
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		versionID, err := strconv.ParseInt(entry.Name(), 10, 64)
+
+		if err != nil {
+			continue
+		}
+
+		versionIDs = append(versionIDs, versionID)
+	}
+
+	slices.Sort(versionIDs)
+	slices.Reverse(versionIDs)
+
+	ids := []string{}
+
+	for _, versionID := range versionIDs {
+		ids = append(ids, strconv.FormatInt(versionID, 10))
+	}
+
+	return ids, nil
+}
+
+func (h *CertHistory) prune(storageType CertStorageType, certName string) error {
+	versionIDs, err := h.getVersionIDs(storageType, certName)
+
+	if err != nil {
+		return err
+	}
+
+	if len(versionIDs) <= h.size {
+		return nil
+	}
+
+	for _, versionID := range versionIDs[h.size:] {
+		if err := os.RemoveAll(filepath.Join(h.getCertPath(storageType, certName), versionID)); err != nil {
+			h.logger.Error("failed to remove certificate %s version %s: %v", certName, versionID, err)
+		}
+	}
+
+	return nil
+}
+
+func (h *CertHistory) getCertPath(storageType CertStorageType, certName string) string {
+	return filepath.Join(h.path, string(storageType), filepath.Base(certName))
+}
+
+func CreateCertHistory(config *config.Config, logger logger.Logger) *CertHistory {
+	return &CertHistory{
+		Mutex:  &sync.Mutex{},
+		path:   config.GetPathInsideVarDir("history"),
+		size:   config.CertHistorySize,
+		logger: logger,
+	}
+}
@@ -0,0 +1,126 @@
+//go:build common
+
+package certificates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertHistory(t *testing.T) {
+	history := &CertHistory{
+		Mutex:  &sync.Mutex{},
+		path:   t.TempDir(),
+		size:   2,
+		logger: &logger.TestLogger{T: t},
+	}
+
+	certPath := filepath.Join(t.TempDir(), "example.com.pem")
+	example, err := os.ReadFile("../../test/certificate/example.com.pem")
+	assert.Nil(t, err)
+	example2, err := os.ReadFile("../../test/certificate/example2.com.pem")
+	assert.Nil(t, err)
+
+	assert.Nil(t, history.Save(Default, "example.com", certPath, certPath))
+
+	versions, err := history.GetVersions(Default, "example.com")
+	assert.Nil(t, err)
+	assert.Len(t, versions, 0)
+
+	assert.Nil(t, os.WriteFile(certPath, example, 0644))
+	assert.Nil(t, history.Save(Default, "example.com", certPath, certPath))
+	// the same certificate is not saved twice
+	assert.Nil(t, history.Save(Default, "example.com", certPath, certPath))
+
+	assert.Nil(t, os.WriteFile(certPath, example2, 0644))
+	assert.Nil(t, history.Save(Default, "example.com", certPath, certPath))
+
+	versions, err = history.GetVersions(Default, "example.com")
+	assert.Nil(t, err)
+	assert.Len(t, versions, 2)
+	assert.Equal(t, "example2.com", versions[0].Certificate.CN)
+	assert.Equal(t, "example.com", versions[1].Certificate.CN)
+	assert.NotEqual(t, versions[0].Fingerprint, versions[1].Fingerprint)
+
+	assert.Nil(t, history.Restore(Default, "example.com", versions[1].ID, certPath, certPath))
+	content, err := os.ReadFile(certPath)
+	assert.Nil(t, err)
+	assert.Equal(t, example, content)
+
+	assert.Nil(t, os.WriteFile(certPath, []byte(""), 0644))
+	assert.NotNil(t, history.Save(Default, "example.com", certPath, certPath))
+	assert.NotNil(t, history.Restore(Default, "example.com", "1", certPath, certPath))
+}
+
+func TestRestoreStorageCertificateVersionRedeployFailure(t *testing.T) {
+	example, err := os.ReadFile("../../test/certificate/example.com.pem")
+	require.Nil(t, err)
+	example2, err := os.ReadFile("../../test/certificate/example2.com.pem")
+	require.Nil(t, err)
+
+	storage := &pruneTestStorage{certPath: filepath.Join(t.TempDir(), "example.com.pem")}
+	require.Nil(t, os.WriteFile(storage.certPath, example2, 0600))
+
+	history := &CertHistory{
+		Mutex:  &sync.Mutex{},
+		path:   t.TempDir(),
+		size:   2,
+		logger: &logger.TestLogger{T: t},
+	}
+	require.Nil(t, history.Save(Default, "example.com", storage.certPath, storage.certPath))
+	require.Nil(t, os.WriteFile(storage.certPath, example, 0600))
+
+	versions, err := history.GetVersions(Default, "example.com")
+	require.Nil(t, err)
+	require.Len(t, versions, 1)
+
+	nginxRoot := t.TempDir()
+	nginxConfig := fmt.Sprintf(`events {}
+http {
+    server {
+        listen 443 ssl;
+        server_name example.com;
+        ssl_certificate %[1]s;
+        ssl_certificate_key %[1]s;
+    }
+}
+`, storage.certPath)
+	require.Nil(t, os.WriteFile(filepath.Join(nginxRoot, "nginx.conf"), []byte(nginxConfig), 0644))
+
+	// the config test fails, so the certificate can not be redeployed
+	options := map[string]string{config.NginxRootOpt: nginxRoot, config.NginxBinOpt: "false"}
+
+	for key, value := range options {
+		viper.Set(key, value)
+		t.Cleanup(func() { viper.Set(key, nil) })
+	}
+
+	certManager := &CertificateManager{
+		wServerFactory:  webserver.CreateWebServer,
+		reverterFactory: reverter.CreateReverter,
+		certStorages:    map[CertStorageType]CertStorage{Default: storage},
+		certHistory:     history,
+		config:          &config.Config{},
+		logger:          &logger.TestLogger{T: t},
+		mx:              &sync.Mutex{},
+	}
+
+	_, err = certManager.RestoreStorageCertificateVersion("example.com", string(Default), versions[0].ID)
+	assert.ErrorContains(t, err, "failed to deploy certificate example.com to example.com")
+
+	// the current certificate is kept
+	content, err := os.ReadFile(storage.certPath)
+	require.Nil(t, err)
+	assert.Equal(t, example, content)
+}
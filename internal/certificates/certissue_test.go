@@ -0,0 +1,150 @@
+//go:build common
+
+package certificates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates/request"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const issueNginxConfig = `events {}
+
+http {
+    include sites-enabled/*.conf;
+}
+`
+
+const issueNginxCombinedHostConfig = `server {
+    listen 80;
+    listen 443 ssl;
+    server_name example.com;
+    root /var/www/html;
+    ssl_certificate %[1]s/test/certificate/example2.com.crt;
+    ssl_certificate_key %[1]s/test/certificate/example2.com.key;
+}
+`
+
+const issueNginxHostConfig = `server {
+    listen 80;
+    server_name example.com;
+    root /var/www/html;
+}
+
+server {
+    listen 443 ssl;
+    server_name example.com;
+    root /var/www/html;
+    ssl_certificate %[1]s/test/certificate/example2.com.crt;
+    ssl_certificate_key %[1]s/test/certificate/example2.com.key;
+}
+`
+
+type issueTestAcmeClient struct {
+	certPath string
+	keyPath  string
+	// issueRequest is the last issue request
+	issueRequest request.IssueRequest
+}
+
+func (c *issueTestAcmeClient) Issue(docRoot string, request request.IssueRequest) (string, string, bool, error) {
+	c.issueRequest = request
+
+	return c.certPath, c.keyPath, false, nil
+}
+
+func TestIssueReturnsCertificateOnPostDeployError(t *testing.T) {
+	configPath, _, certManager := createIssueTestManager(t, issueNginxCombinedHostConfig)
+
+	cert, err := certManager.Issue(request.IssueRequest{
+		ServerName: "example.com",
+		WebServer:  webserver.WebServerNginxCode,
+		Assign:     true,
+		Redirect:   true,
+	})
+	require.NotNil(t, err)
+
+	var postDeployError *PostDeployError
+	require.True(t, errors.As(err, &postDeployError))
+	assert.Equal(t, "redirect enabling", postDeployError.Step)
+
+	// the new certificate is deployed and returned
+	require.NotNil(t, cert)
+	assert.Equal(t, "example.com", cert.CN)
+
+	content, err := os.ReadFile(configPath)
+	require.Nil(t, err)
+	assert.Contains(t, string(content), "certificate/example.com.crt;")
+}
+
+func TestIssuePreventReloadWithRedirect(t *testing.T) {
+	configPath, marker, certManager := createIssueTestManager(t, issueNginxHostConfig)
+
+	cert, err := certManager.Issue(request.IssueRequest{
+		ServerName:    "example.com",
+		WebServer:     webserver.WebServerNginxCode,
+		Assign:        true,
+		Redirect:      true,
+		PreventReload: true,
+	})
+	require.Nil(t, err)
+	require.NotNil(t, cert)
+
+	content, err := os.ReadFile(configPath)
+	require.Nil(t, err)
+	assert.Contains(t, string(content), "return 301")
+	assert.NoFileExists(t, marker)
+}
+
+// createIssueTestManager creates the manager issuing the example.com certificate.
+// The host config path and the marker created on nginx reload are returned.
+func createIssueTestManager(t *testing.T, hostConfig string) (string, string, *CertificateManager) {
+	root, err := filepath.Abs("../..")
+	require.Nil(t, err)
+
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(issueNginxConfig), 0644))
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "sites-enabled"), 0755))
+
+	configPath := filepath.Join(dir, "sites-enabled", "example.com.conf")
+	require.Nil(t, os.WriteFile(configPath, []byte(fmt.Sprintf(hostConfig, root)), 0644))
+
+	marker := filepath.Join(dir, "reloaded")
+	options := map[string]string{
+		config.NginxRootOpt:           dir,
+		config.NginxBinOpt:            "true",
+		config.NginxReloadStrategyOpt: "command",
+		config.NginxReloadCommandOpt:  "touch " + marker,
+	}
+
+	for key, value := range options {
+		viper.Set(key, value)
+		t.Cleanup(func() { viper.Set(key, nil) })
+	}
+
+	certManager := &CertificateManager{
+		wServerFactory:  webserver.CreateWebServer,
+		reverterFactory: reverter.CreateReverter,
+		acmeClient: &issueTestAcmeClient{
+			certPath: filepath.Join(root, "test/certificate/example.com.crt"),
+			keyPath:  filepath.Join(root, "test/certificate/example.com.key"),
+		},
+		config: &config.Config{},
+		logger: &logger.TestLogger{T: t},
+		mx:     &sync.Mutex{},
+	}
+
+	return configPath, marker, certManager
+}
@@ -3,14 +3,20 @@ package certificates
 import (
 	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"sync"
 
 	"github.com/r2dtools/sslbot/config"
 	"github.com/r2dtools/sslbot/internal/certificates/acme/client"
 	"github.com/r2dtools/sslbot/internal/certificates/acme/client/certbot"
 	"github.com/r2dtools/sslbot/internal/certificates/acme/client/lego"
+	"github.com/r2dtools/sslbot/internal/certificates/chain"
 	"github.com/r2dtools/sslbot/internal/certificates/commondir"
+	"github.com/r2dtools/sslbot/internal/certificates/redirect"
 	"github.com/r2dtools/sslbot/internal/certificates/request"
+	"github.com/r2dtools/sslbot/internal/certificates/s3"
+	"github.com/r2dtools/sslbot/internal/certificates/tlsprofile"
 	"github.com/r2dtools/sslbot/internal/dto"
 	"github.com/r2dtools/sslbot/internal/logger"
 	"github.com/r2dtools/sslbot/internal/utils"
@@ -26,24 +32,48 @@ type CertStorage interface {
 	GetCertificatePath(certName string) (certPath string, keyPath string, err error)
 }
 
+// WritableCertStorage is a storage certificates can be uploaded to
+type WritableCertStorage interface {
+	CertStorage
+	AddPemCertificate(certName, pemData string) (certPath string, err error)
+}
+
 type CertStorageType string
 
 const (
 	Default CertStorageType = "default"
 	CertBot CertStorageType = "certbot"
 	Lego    CertStorageType = "lego"
+	S3      CertStorageType = "s3"
 )
 
 type CertStorageItem struct {
 	StorageType CertStorageType
 	CertName    string
 	Certificate *dto.Certificate
+	Vhosts      []CertStorageVhost
+	// UsageUnknown is set if the certificate path could not be got, so hosts that use the certificate are unknown
+	UsageUnknown bool
 }
 
 func (i CertStorageItem) Key() string {
 	return fmt.Sprintf("%s__%s", i.StorageType, i.CertName)
 }
 
+// PostDeployError is returned with the certificate if the certificate is deployed, but a step after deploy failed
+type PostDeployError struct {
+	Step string
+	Err  error
+}
+
+func (e *PostDeployError) Error() string {
+	return fmt.Sprintf("certificate is deployed, but %s failed: %v", e.Step, e.Err)
+}
+
+func (e *PostDeployError) Unwrap() error {
+	return e.Err
+}
+
 type webServerFactory func(code string, options map[string]string) (webserver.WebServer, error)
 type reverterFactory func(wServer webserver.WebServer, logger logger.Logger) (reverter.Reverter, error)
 
@@ -52,6 +82,8 @@ type CertificateManager struct {
 	reverterFactory reverterFactory
 	certStorages    map[CertStorageType]CertStorage
 	acmeClient      client.AcmeClient
+	chainCompleter  *chain.Completer
+	certHistory     *CertHistory
 	logger          logger.Logger
 	config          *config.Config
 	mx              *sync.Mutex
@@ -59,6 +91,13 @@ type CertificateManager struct {
 
 func (c *CertificateManager) Issue(request request.IssueRequest) (*dto.Certificate, error) {
 	serverName := request.ServerName
+
+	if request.TlsProfile != "" {
+		if _, err := tlsprofile.GetProfile(request.TlsProfile); err != nil {
+			return nil, err
+		}
+	}
+
 	wServer, err := c.wServerFactory(request.WebServer, c.config.ToMap())
 
 	if err != nil {
@@ -95,6 +134,8 @@ func (c *CertificateManager) Issue(request request.IssueRequest) (*dto.Certifica
 		docRoot = commonDir.Root
 	}
 
+	c.saveCertificateHistory(c.getAcmeStorageType(), serverName)
+
 	certPath, keyPath, deployed, err := c.acmeClient.Issue(docRoot, request)
 
 	if err != nil {
@@ -104,13 +145,35 @@ func (c *CertificateManager) Issue(request request.IssueRequest) (*dto.Certifica
 	}
 
 	if request.Assign && !deployed {
-		certDeployer.DeployCertificate(serverName, certPath, keyPath, request.PreventReload)
+		if err = certDeployer.DeployCertificate(serverName, certPath, keyPath, request.PreventReload); err != nil {
+			c.logger.Error("failed to deploy certificate to %s host: %v", serverName, err)
+		} else {
+			deployed = true
+		}
 	}
 
-	return utils.GetCertificateFromFile(certPath)
+	cert, err := utils.GetCertificateFromFile(certPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Assign && deployed {
+		if err = c.applyPostDeploySteps(request.WebServer, serverName, request.Redirect, request.TlsProfile, request.PreventReload); err != nil {
+			return cert, err
+		}
+	}
+
+	return cert, nil
 }
 
 func (c *CertificateManager) Assign(request request.AssignRequest) (*dto.Certificate, error) {
+	if request.TlsProfile != "" {
+		if _, err := tlsprofile.GetProfile(request.TlsProfile); err != nil {
+			return nil, err
+		}
+	}
+
 	storageType := CertStorageType(request.StorageType)
 	storage, err := c.getStorage(CertStorageType(storageType))
 
@@ -143,51 +206,79 @@ func (c *CertificateManager) Assign(request request.AssignRequest) (*dto.Certifi
 		return nil, err
 	}
 
-	return utils.GetCertificateFromFile(certPath)
+	cert, err := utils.GetCertificateFromFile(certPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.applyPostDeploySteps(request.WebServer, request.ServerName, request.Redirect, request.TlsProfile, false); err != nil {
+		return cert, err
+	}
+
+	return cert, nil
 }
 
-func (c *CertificateManager) Upload(request request.UploadRequest) (*dto.Certificate, error) {
-	var (
-		certPath string
-		err      error
-	)
+// Unassign removes ssl from the host. HTTP to HTTPS redirect of the host is disabled first,
+// so the host never redirects to the removed ssl host, even if ssl removal fails.
+func (c *CertificateManager) Unassign(request request.UnassignRequest) error {
+	if err := c.disableRedirect(request.WebServer, request.ServerName); err != nil {
+		return err
+	}
 
-	storage, err := c.getStorage(Default)
+	// the config is parsed again to include the redirect change
+	wServer, err := c.wServerFactory(request.WebServer, c.config.ToMap())
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	defaultStorage, ok := storage.(*DefaultStorage)
+	sReverter, err := c.reverterFactory(wServer, c.logger)
 
-	if !ok {
-		return nil, errors.New("invalid storage")
+	if err != nil {
+		return err
 	}
 
-	if certPath, err = defaultStorage.AddPemCertificate(request.CertName, request.PemCertificate); err != nil {
-		return nil, err
+	certUndeployer := createCertificateUndeployer(wServer, sReverter, c.logger, c.mx)
+
+	return certUndeployer.UndeployCertificate(request.ServerName, false)
+}
+
+// Upload adds the certificate to the default storage and deploys it to the host.
+// Intermediates added to complete the certificate chain are returned as well.
+func (c *CertificateManager) Upload(request request.UploadRequest) (*dto.Certificate, []string, error) {
+	certPath, addedIntermediates, err := c.AddStorageCertificate(request.CertName, request.PemCertificate)
+
+	if err != nil {
+		return nil, nil, err
 	}
 
 	wServer, err := c.wServerFactory(request.WebServer, c.config.ToMap())
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	sReverter, err := c.reverterFactory(wServer, c.logger)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	certDeployer := createCertificateDeployer(c.config, wServer, sReverter, c.logger, c.mx)
 	err = certDeployer.DeployCertificate(request.ServerName, certPath, certPath, false)
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return utils.GetCertificateFromFile(certPath)
+	cert, err := utils.GetCertificateFromFile(certPath)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, addedIntermediates, nil
 }
 
 func (c *CertificateManager) GetStorageCertificate(certName, storageType string) (*dto.Certificate, error) {
@@ -210,20 +301,79 @@ func (c *CertificateManager) GetStorageCertificateAsString(certName string, stor
 	return storage.GetCertificateAsString(certName)
 }
 
-func (c *CertificateManager) AddStorageCertificate(certName, pemData string) (string, error) {
-	storage, err := c.getStorage(Default)
+// AddStorageCertificate adds the certificate to the default storage completing its chain with missing intermediates.
+// Subjects of the added intermediates are returned.
+func (c *CertificateManager) AddStorageCertificate(certName, pemData string) (string, []string, error) {
+	return c.AddCertificateToStorage(certName, pemData, string(Default))
+}
+
+// AddCertificateToStorage adds the certificate to the writable storage completing its chain with missing intermediates.
+func (c *CertificateManager) AddCertificateToStorage(certName, pemData, storageType string) (string, []string, error) {
+	storage, err := c.getStorage(CertStorageType(storageType))
 
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	defaultStorage, ok := storage.(*DefaultStorage)
+	writableStorage, ok := storage.(WritableCertStorage)
 
 	if !ok {
-		return "", errors.New("invalid storage")
+		return "", nil, fmt.Errorf("storage %s is read only", storageType)
+	}
+
+	pemData, intermediates, err := c.chainCompleter.Complete(pemData)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	addedIntermediates := []string{}
+
+	for _, intermediate := range intermediates {
+		addedIntermediates = append(addedIntermediates, intermediate.Subject.String())
+	}
+
+	if len(addedIntermediates) > 0 {
+		c.logger.Info("intermediates added to certificate %s chain: %v", certName, addedIntermediates)
+	}
+
+	c.saveCertificateHistory(CertStorageType(storageType), certName)
+
+	certPath, err := writableStorage.AddPemCertificate(certName, pemData)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return certPath, addedIntermediates, nil
+}
+
+// ImportPkcs12StorageCertificate converts PKCS#12 (PFX) data to pem and adds it to the storage.
+func (c *CertificateManager) ImportPkcs12StorageCertificate(certName string, data []byte, password, storageType string) (string, []string, error) {
+	pemData, err := utils.ConvertPkcs12ToPem(data, password)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return c.AddCertificateToStorage(certName, pemData, storageType)
+}
+
+// GetStorageCertificateAsPkcs12 exports the storage certificate with its private key as PKCS#12 (PFX) data.
+func (c *CertificateManager) GetStorageCertificateAsPkcs12(certName, storageType, password string) ([]byte, error) {
+	storage, err := c.getStorage(CertStorageType(storageType))
+
+	if err != nil {
+		return nil, err
+	}
+
+	certPath, keyPath, err := storage.GetCertificatePath(certName)
+
+	if err != nil {
+		return nil, err
 	}
 
-	return defaultStorage.AddPemCertificate(certName, pemData)
+	return utils.ConvertPemFilesToPkcs12(certPath, keyPath, password)
 }
 
 func (c *CertificateManager) RemoveStorageCertificate(certName, storageType string) error {
@@ -236,22 +386,372 @@ func (c *CertificateManager) RemoveStorageCertificate(certName, storageType stri
 	return storage.RemoveCertificate(certName)
 }
 
+// GetStorageCertificates returns certificates of all storages together with the hosts that use them
 func (c *CertificateManager) GetStorageCertificates() ([]CertStorageItem, error) {
-	items := []CertStorageItem{}
+	return c.getStorageCertificates(c.getVhosts(), c.getStreamServers())
+}
+
+func (c *CertificateManager) getStorageCertificates(vhosts []dto.VirtualHost, servers []dto.StreamServer) ([]CertStorageItem, error) {
+	certPathMap, unresolvedItems, err := c.getStorageCertPaths()
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := slices.Clone(unresolvedItems)
+	vhostsMap := map[string][]CertStorageVhost{}
+
+	for _, vhost := range vhosts {
+		if !resolveVhostCertificate(&vhost, certPathMap) {
+			continue
+		}
+
+		key := CertStorageItem{StorageType: CertStorageType(vhost.CertificateStorage), CertName: vhost.CertificateName}.Key()
+		vhostsMap[key] = append(vhostsMap[key], CertStorageVhost{
+			WebServer:  vhost.WebServer,
+			ServerName: vhost.ServerName,
+			FilePath:   vhost.FilePath,
+		})
+	}
+
+	for _, server := range servers {
+		if !resolveStreamServerCertificate(&server, certPathMap) {
+			continue
+		}
+
+		key := CertStorageItem{StorageType: CertStorageType(server.CertificateStorage), CertName: server.CertificateName}.Key()
+		vhostsMap[key] = append(vhostsMap[key], CertStorageVhost{
+			WebServer:  server.WebServer,
+			ServerName: server.Key,
+			FilePath:   server.FilePath,
+			Stream:     true,
+		})
+	}
+
+	added := map[string]struct{}{}
+
+	for _, item := range certPathMap {
+		key := item.Key()
+
+		if _, ok := added[key]; ok {
+			continue
+		}
+
+		added[key] = struct{}{}
+		item.Vhosts = vhostsMap[key]
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (c *CertificateManager) GetStorageCertificateVersions(certName, storageType string) ([]dto.CertificateVersion, error) {
+	if _, err := c.getStorage(CertStorageType(storageType)); err != nil {
+		return nil, err
+	}
+
+	return c.certHistory.GetVersions(CertStorageType(storageType), certName)
+}
+
+// RestoreStorageCertificateVersion replaces the storage certificate with its previous version and
+// redeploys it to the hosts that currently use the certificate. The replaced certificate is saved to the history.
+func (c *CertificateManager) RestoreStorageCertificateVersion(certName, storageType, versionID string) (*dto.Certificate, error) {
+	storage, err := c.getStorage(CertStorageType(storageType))
+
+	if err != nil {
+		return nil, err
+	}
+
+	certPath, keyPath, err := storage.GetCertificatePath(certName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.certHistory.Save(CertStorageType(storageType), certName, certPath, keyPath); err != nil {
+		return nil, err
+	}
+
+	// the history can be disabled, so current files are kept in memory
+	backup, err := backupCertificateFiles(certPath, keyPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.certHistory.Restore(CertStorageType(storageType), certName, versionID, certPath, keyPath); err != nil {
+		return nil, err
+	}
+
+	if err := c.redeployCertificate(CertStorageType(storageType), certName, certPath, keyPath); err != nil {
+		if bErr := backup.restore(); bErr != nil {
+			c.logger.Error("failed to restore certificate %s files: %v", certName, bErr)
+
+			return nil, err
+		}
+
+		// hosts deployed before the failure are reloaded with the current certificate again
+		if rErr := c.redeployCertificate(CertStorageType(storageType), certName, certPath, keyPath); rErr != nil {
+			c.logger.Error("failed to redeploy certificate %s after restoring its files: %v", certName, rErr)
+		}
+
+		return nil, err
+	}
+
+	return utils.GetCertificateFromFile(certPath)
+}
+
+// certificateFilesBackup keeps content of certificate files by path
+type certificateFilesBackup map[string]certificateFileBackup
+
+type certificateFileBackup struct {
+	content []byte
+	mode    os.FileMode
+}
+
+// backupCertificateFiles reads the certificate files, missing files are skipped
+func backupCertificateFiles(paths ...string) (certificateFilesBackup, error) {
+	backup := certificateFilesBackup{}
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
 
-	for storageType, storage := range c.certStorages {
-		certs, err := storage.GetCertificates()
+		info, err := os.Stat(path)
+
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
 
 		if err != nil {
 			return nil, err
 		}
 
-		for certName, cert := range certs {
-			items = append(items, CertStorageItem{StorageType: storageType, CertName: certName, Certificate: cert})
+		content, err := os.ReadFile(path)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not backup certificate file %s: %v", path, err)
 		}
+
+		backup[path] = certificateFileBackup{content: content, mode: info.Mode().Perm()}
 	}
 
-	return items, nil
+	return backup, nil
+}
+
+func (b certificateFilesBackup) restore() error {
+	for path, file := range b {
+		if err := os.WriteFile(path, file.content, file.mode); err != nil {
+			return fmt.Errorf("could not restore certificate file %s: %v", path, err)
+		}
+	}
+
+	return nil
+}
+
+// redeployCertificate deploys the storage certificate again to all hosts that use it
+func (c *CertificateManager) redeployCertificate(storageType CertStorageType, certName, certPath, keyPath string) error {
+	certPathMap, err := c.getStorageCertPathMap()
+
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	key := CertStorageItem{StorageType: storageType, CertName: certName}.Key()
+
+	for _, vhost := range c.getVhosts() {
+		if !resolveVhostCertificate(&vhost, certPathMap) {
+			continue
+		}
+
+		if (CertStorageItem{StorageType: CertStorageType(vhost.CertificateStorage), CertName: vhost.CertificateName}).Key() != key {
+			continue
+		}
+
+		if err := c.deployCertificate(vhost.WebServer, vhost.ServerName, certPath, keyPath); err != nil {
+			errs = append(errs, fmt.Errorf("failed to deploy certificate %s to %s: %v", certName, vhost.ServerName, err))
+		}
+	}
+
+	for _, server := range c.getStreamServers() {
+		if !resolveStreamServerCertificate(&server, certPathMap) {
+			continue
+		}
+
+		if (CertStorageItem{StorageType: CertStorageType(server.CertificateStorage), CertName: server.CertificateName}).Key() != key {
+			continue
+		}
+
+		if err := c.deployStreamCertificate(server.WebServer, server.Key, certPath, keyPath); err != nil {
+			errs = append(errs, fmt.Errorf("failed to deploy certificate %s to stream server %s: %v", certName, server.Key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *CertificateManager) deployCertificate(webServerCode, serverName, certPath, keyPath string) error {
+	wServer, err := c.wServerFactory(webServerCode, c.config.ToMap())
+
+	if err != nil {
+		return err
+	}
+
+	sReverter, err := c.reverterFactory(wServer, c.logger)
+
+	if err != nil {
+		return err
+	}
+
+	certDeployer := createCertificateDeployer(c.config, wServer, sReverter, c.logger, c.mx)
+
+	return certDeployer.DeployCertificate(serverName, certPath, keyPath, false)
+}
+
+// enableRedirect enables HTTP to HTTPS redirect for the host. The webserver config is parsed again
+// since the deployed ssl host could be created by certbot.
+// applyPostDeploySteps enables the redirect and applies the TLS profile to the host the certificate is deployed to.
+// The webserver is not reloaded if preventReload is set.
+func (c *CertificateManager) applyPostDeploySteps(
+	webServerCode string,
+	serverName string,
+	enableRedirect bool,
+	tlsProfile string,
+	preventReload bool,
+) error {
+	if enableRedirect {
+		if err := c.enableRedirect(webServerCode, serverName, preventReload); err != nil {
+			return &PostDeployError{Step: "redirect enabling", Err: err}
+		}
+	}
+
+	if tlsProfile != "" {
+		if err := c.applyTlsProfile(webServerCode, serverName, tlsProfile, preventReload); err != nil {
+			return &PostDeployError{Step: "tls profile applying", Err: err}
+		}
+	}
+
+	return nil
+}
+
+func (c *CertificateManager) enableRedirect(webServerCode, serverName string, preventReload bool) error {
+	wServer, err := c.wServerFactory(webServerCode, c.config.ToMap())
+
+	if err != nil {
+		return err
+	}
+
+	if preventReload {
+		webserver.PreventReload(wServer)
+	}
+
+	sReverter, err := c.reverterFactory(wServer, c.logger)
+
+	if err != nil {
+		return err
+	}
+
+	redirectCommand, err := redirect.CreateRedirectChangeCommand(wServer, sReverter, c.logger, c.mx)
+
+	if err != nil {
+		return err
+	}
+
+	return redirectCommand.EnableRedirect(serverName)
+}
+
+// disableRedirect disables HTTP to HTTPS redirect of the host if it is enabled
+func (c *CertificateManager) disableRedirect(webServerCode, serverName string) error {
+	wServer, err := c.wServerFactory(webServerCode, c.config.ToMap())
+
+	if err != nil {
+		return err
+	}
+
+	redirectQuery, err := redirect.CreateRedirectStatusQuery(wServer)
+
+	if err != nil {
+		// redirect is not supported by the webserver
+		return nil
+	}
+
+	if !redirectQuery.GetRedirectStatus(serverName).Enabled {
+		return nil
+	}
+
+	sReverter, err := c.reverterFactory(wServer, c.logger)
+
+	if err != nil {
+		return err
+	}
+
+	redirectCommand, err := redirect.CreateRedirectChangeCommand(wServer, sReverter, c.logger, c.mx)
+
+	if err != nil {
+		return err
+	}
+
+	return redirectCommand.DisableRedirect(serverName)
+}
+
+// applyTlsProfile applies the TLS profile to the host. The webserver config is parsed again
+// to include changes made on certificate deploy.
+func (c *CertificateManager) applyTlsProfile(webServerCode, serverName, profileName string, preventReload bool) error {
+	wServer, err := c.wServerFactory(webServerCode, c.config.ToMap())
+
+	if err != nil {
+		return err
+	}
+
+	if preventReload {
+		webserver.PreventReload(wServer)
+	}
+
+	sReverter, err := c.reverterFactory(wServer, c.logger)
+
+	if err != nil {
+		return err
+	}
+
+	tlsProfileCommand, err := tlsprofile.CreateTlsProfileChangeCommand(wServer, sReverter, c.logger, c.mx)
+
+	if err != nil {
+		return err
+	}
+
+	return tlsProfileCommand.ApplyTlsProfile(serverName, profileName)
+}
+
+func (c *CertificateManager) saveCertificateHistory(storageType CertStorageType, certName string) {
+	storage, err := c.getStorage(storageType)
+
+	if err != nil {
+		c.logger.Error("failed to save certificate %s history: %v", certName, err)
+
+		return
+	}
+
+	certPath, keyPath, err := storage.GetCertificatePath(certName)
+
+	if err != nil {
+		c.logger.Debug("failed to save certificate %s history: %v", certName, err)
+
+		return
+	}
+
+	if err := c.certHistory.Save(storageType, certName, certPath, keyPath); err != nil {
+		c.logger.Error("failed to save certificate %s history: %v", certName, err)
+	}
+}
+
+func (c *CertificateManager) getAcmeStorageType() CertStorageType {
+	if c.config.CertBotEnabled {
+		return CertBot
+	}
+
+	return Lego
 }
 
 func (c *CertificateManager) getStorage(storageType CertStorageType) (CertStorage, error) {
@@ -296,11 +796,23 @@ func CreateCertificateManager(
 	certbotStorage := certbot.CreateCertStorage(config, logger)
 	certStorages[CertBot] = certbotStorage
 
+	if config.IsS3StorageEnabled() {
+		s3Storage, err := s3.CreateCertStorage(config, logger)
+
+		if err != nil {
+			return nil, err
+		}
+
+		certStorages[S3] = s3Storage
+	}
+
 	certManager := &CertificateManager{
 		mx:              mx,
 		logger:          logger,
 		config:          config,
 		acmeClient:      acmeClient,
+		chainCompleter:  chain.CreateCompleter(config, logger),
+		certHistory:     CreateCertHistory(config, logger),
 		certStorages:    certStorages,
 		wServerFactory:  webServerFactory,
 		reverterFactory: reverterFactory,
@@ -65,7 +65,7 @@ func TestAddRemoveStorageCertificate(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, filepath.Join(varDir, "default", "certificates", "example.com.pem"), certPath)
 
-	certPath, err = certManager.AddStorageCertificate("example3.com", certContent)
+	certPath, _, err = certManager.AddStorageCertificate("example3.com", certContent)
 	assert.Nil(t, err)
 	assert.Equal(t, filepath.Join(varDir, "default", "certificates", "example3.com.pem"), certPath)
 
@@ -129,7 +129,7 @@ func TestUploadCertificateToNginxNonSslHost(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Nil(t, vhost.Certificate)
 
-	cert, err := certManager.Upload(request)
+	cert, _, err := certManager.Upload(request)
 	assert.Nil(t, err)
 	assert.Equal(t, "example2.com", cert.CN)
 
@@ -0,0 +1,176 @@
+package certificates
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/r2dtools/sslbot/internal/dto"
+)
+
+const (
+	PruneReasonExpired      = "expired"
+	PruneReasonUnreferenced = "unreferenced"
+	PruneReasonSuperseded   = "superseded"
+)
+
+type PruneCandidate struct {
+	CertStorageItem
+	Reasons []string
+}
+
+func GetPruneReasons() []string {
+	return []string{PruneReasonExpired, PruneReasonUnreferenced, PruneReasonSuperseded}
+}
+
+// GetPruneCandidates returns storage certificates that match at least one of the reasons.
+// Certificates referenced by a host or with unknown usage are never returned, if references can not be checked an error is returned.
+func (c *CertificateManager) GetPruneCandidates(reasons []string) ([]PruneCandidate, error) {
+	if len(reasons) == 0 {
+		reasons = GetPruneReasons()
+	}
+
+	for _, reason := range reasons {
+		if !slices.Contains(GetPruneReasons(), reason) {
+			return nil, fmt.Errorf("invalid prune reason: %s", reason)
+		}
+	}
+
+	vhosts, err := c.getReferenceVhosts()
+
+	if err != nil {
+		return nil, err
+	}
+
+	servers, err := c.getReferenceStreamServers()
+
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := c.getStorageCertificates(vhosts, servers)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	candidates := []PruneCandidate{}
+
+	for _, item := range items {
+		if len(item.Vhosts) > 0 || item.UsageUnknown || item.Certificate == nil {
+			continue
+		}
+
+		var itemReasons []string
+
+		if slices.Contains(reasons, PruneReasonExpired) && isCertificateExpired(item.Certificate, now) {
+			itemReasons = append(itemReasons, PruneReasonExpired)
+		}
+
+		if slices.Contains(reasons, PruneReasonUnreferenced) {
+			itemReasons = append(itemReasons, PruneReasonUnreferenced)
+		}
+
+		if slices.Contains(reasons, PruneReasonSuperseded) && isCertificateSuperseded(item, items) {
+			itemReasons = append(itemReasons, PruneReasonSuperseded)
+		}
+
+		if len(itemReasons) > 0 {
+			candidates = append(candidates, PruneCandidate{CertStorageItem: item, Reasons: itemReasons})
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b PruneCandidate) int {
+		return strings.Compare(a.Key(), b.Key())
+	})
+
+	return candidates, nil
+}
+
+// Prune removes prune candidates. If keys are specified only candidates with these keys are removed.
+// Candidates are computed again, so a certificate that became referenced after the preview is kept.
+func (c *CertificateManager) Prune(reasons []string, keys []string) ([]PruneCandidate, error) {
+	candidates, err := c.GetPruneCandidates(reasons)
+
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []PruneCandidate{}
+	var errs []error
+
+	for _, candidate := range candidates {
+		if len(keys) > 0 && !slices.Contains(keys, candidate.Key()) {
+			continue
+		}
+
+		if err := c.RemoveStorageCertificate(candidate.CertName, string(candidate.StorageType)); err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		c.logger.Info("certificate %s removed from %s storage: %s", candidate.CertName, candidate.StorageType, strings.Join(candidate.Reasons, ", "))
+		removed = append(removed, candidate)
+	}
+
+	return removed, errors.Join(errs...)
+}
+
+func isCertificateExpired(cert *dto.Certificate, now time.Time) bool {
+	validTo, err := time.Parse(time.RFC822Z, cert.ValidTo)
+
+	if err != nil {
+		return false
+	}
+
+	return validTo.Before(now)
+}
+
+// isCertificateSuperseded checks if there is a certificate with the same names that expires later
+func isCertificateSuperseded(item CertStorageItem, items []CertStorageItem) bool {
+	validTo, err := time.Parse(time.RFC822Z, item.Certificate.ValidTo)
+
+	if err != nil {
+		return false
+	}
+
+	names := getCertificateNames(item.Certificate)
+
+	for _, other := range items {
+		if other.Key() == item.Key() || other.Certificate == nil {
+			continue
+		}
+
+		if !slices.Equal(names, getCertificateNames(other.Certificate)) {
+			continue
+		}
+
+		otherValidTo, err := time.Parse(time.RFC822Z, other.Certificate.ValidTo)
+
+		if err == nil && otherValidTo.After(validTo) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getCertificateNames(cert *dto.Certificate) []string {
+	names := slices.Clone(cert.DNSNames)
+
+	if len(names) == 0 && cert.CN != "" {
+		names = []string{cert.CN}
+	}
+
+	for i := range names {
+		names[i] = strings.ToLower(names[i])
+	}
+
+	slices.Sort(names)
+
+	return slices.Compact(names)
+}
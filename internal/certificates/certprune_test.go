@@ -0,0 +1,168 @@
+//go:build common
+
+package certificates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCertificateSuperseded(t *testing.T) {
+	now := time.Now()
+	oldItem := CertStorageItem{
+		StorageType: Default,
+		CertName:    "old",
+		Certificate: &dto.Certificate{DNSNames: []string{"www.example.com", "example.com"}, ValidTo: now.Format(time.RFC822Z)},
+	}
+	newItem := CertStorageItem{
+		StorageType: Lego,
+		CertName:    "new",
+		Certificate: &dto.Certificate{DNSNames: []string{"example.com", "WWW.example.com"}, ValidTo: now.Add(time.Hour * 24).Format(time.RFC822Z)},
+	}
+	otherItem := CertStorageItem{
+		StorageType: Default,
+		CertName:    "other",
+		Certificate: &dto.Certificate{DNSNames: []string{"example.com"}, ValidTo: now.Add(time.Hour * 48).Format(time.RFC822Z)},
+	}
+	items := []CertStorageItem{oldItem, newItem, otherItem}
+
+	assert.True(t, isCertificateSuperseded(oldItem, items))
+	assert.False(t, isCertificateSuperseded(newItem, items))
+	assert.False(t, isCertificateSuperseded(otherItem, items))
+}
+
+func TestIsCertificateExpired(t *testing.T) {
+	now := time.Now()
+
+	assert.True(t, isCertificateExpired(&dto.Certificate{ValidTo: now.Add(-time.Hour).Format(time.RFC822Z)}, now))
+	assert.False(t, isCertificateExpired(&dto.Certificate{ValidTo: now.Add(time.Hour).Format(time.RFC822Z)}, now))
+	assert.False(t, isCertificateExpired(&dto.Certificate{ValidTo: "invalid"}, now))
+}
+
+type pruneTestStorage struct {
+	certPath string
+	removed  []string
+}
+
+func (s *pruneTestStorage) RemoveCertificate(certName string) error {
+	s.removed = append(s.removed, certName)
+
+	return nil
+}
+
+func (s *pruneTestStorage) GetCertificate(certName string) (*dto.Certificate, error) {
+	return nil, nil
+}
+
+func (s *pruneTestStorage) GetCertificateAsString(certName string) (string, string, error) {
+	return s.certPath, "", nil
+}
+
+func (s *pruneTestStorage) GetCertificates() (map[string]*dto.Certificate, error) {
+	validTo := time.Now().Add(-time.Hour).Format(time.RFC822Z)
+
+	return map[string]*dto.Certificate{"example.com": {CN: "example.com", ValidTo: validTo}}, nil
+}
+
+func (s *pruneTestStorage) GetCertificatePath(certName string) (string, string, error) {
+	return s.certPath, s.certPath, nil
+}
+
+func createPruneTestManager(t *testing.T, factory webServerFactory) (*CertificateManager, *pruneTestStorage) {
+	dir := t.TempDir()
+	storage := &pruneTestStorage{certPath: filepath.Join(dir, "example.com.pem")}
+	assert.Nil(t, os.WriteFile(storage.certPath, []byte{}, 0644))
+
+	return &CertificateManager{
+		wServerFactory: factory,
+		certStorages:   map[CertStorageType]CertStorage{Default: storage},
+		config:         &config.Config{},
+		logger:         &logger.NilLogger{},
+	}, storage
+}
+
+func TestPruneWebServerError(t *testing.T) {
+	nginxRoot := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(nginxRoot, "nginx.conf"), []byte("events {}\n"), 0644))
+	viper.Set(config.NginxRootOpt, nginxRoot)
+	defer viper.Set(config.NginxRootOpt, nil)
+
+	factory := func(code string, options map[string]string) (webserver.WebServer, error) {
+		return nil, errors.New("could not parse config")
+	}
+	certManager, storage := createPruneTestManager(t, factory)
+
+	// the installed nginx could not be parsed, so certificate usage is unknown
+	removed, err := certManager.Prune(nil, nil)
+	assert.ErrorContains(t, err, "could not check certificate usage by nginx")
+	assert.Empty(t, removed)
+	assert.Empty(t, storage.removed)
+
+	// webservers that are not installed are skipped
+	viper.Set(config.NginxRootOpt, filepath.Join(nginxRoot, "missing"))
+
+	removed, err = certManager.Prune(nil, nil)
+	assert.Nil(t, err)
+	assert.Len(t, removed, 1)
+	assert.Equal(t, []string{"example.com"}, storage.removed)
+}
+
+func TestPruneCertificateOnNonStandardPort(t *testing.T) {
+	certManager, storage := createPruneTestManager(t, webserver.CreateWebServer)
+	nginxRoot := t.TempDir()
+	nginxConfig := fmt.Sprintf(`events {}
+http {
+    server {
+        listen 8443 ssl;
+        ssl_certificate %[1]s;
+        ssl_certificate_key %[1]s;
+    }
+}
+`, storage.certPath)
+	assert.Nil(t, os.WriteFile(filepath.Join(nginxRoot, "nginx.conf"), []byte(nginxConfig), 0644))
+	viper.Set(config.NginxRootOpt, nginxRoot)
+	defer viper.Set(config.NginxRootOpt, nil)
+
+	candidates, err := certManager.GetPruneCandidates(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, candidates)
+}
+
+type pruneTestStreamWebServer struct {
+	webserver.WebServer
+}
+
+func (s *pruneTestStreamWebServer) GetVhosts() ([]dto.VirtualHost, error) {
+	return nil, nil
+}
+
+func (s *pruneTestStreamWebServer) GetStreamServers() ([]dto.StreamServer, error) {
+	return nil, errors.New("could not parse stream servers")
+}
+
+func (s *pruneTestStreamWebServer) GetStreamServer(key string) (*dto.StreamServer, error) {
+	return nil, errors.New("could not parse stream servers")
+}
+
+func TestPruneStreamServerError(t *testing.T) {
+	factory := func(code string, options map[string]string) (webserver.WebServer, error) {
+		return &pruneTestStreamWebServer{}, nil
+	}
+	certManager, storage := createPruneTestManager(t, factory)
+
+	removed, err := certManager.Prune(nil, nil)
+	assert.ErrorContains(t, err, "could not parse stream servers")
+	assert.Empty(t, removed)
+	assert.Empty(t, storage.removed)
+}
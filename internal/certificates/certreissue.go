@@ -0,0 +1,81 @@
+package certificates
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/r2dtools/sslbot/internal/certificates/acme"
+	"github.com/r2dtools/sslbot/internal/certificates/request"
+	"github.com/r2dtools/sslbot/internal/dto"
+)
+
+// Reissue issues a certificate with the host names: names covered by the current host certificate and names it does not cover.
+// Certificate names of other hosts are not kept. The certificate is deployed to the host.
+func (c *CertificateManager) Reissue(reissueRequest request.ReissueRequest) (*dto.Certificate, error) {
+	wServer, err := c.wServerFactory(reissueRequest.WebServer, c.config.ToMap())
+
+	if err != nil {
+		return nil, err
+	}
+
+	vhost, err := wServer.GetVhostByName(reissueRequest.ServerName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if vhost == nil {
+		return nil, fmt.Errorf("host %s not found", reissueRequest.ServerName)
+	}
+
+	if vhost.CertificateCoverage == nil {
+		return nil, fmt.Errorf("host %s has no certificate", vhost.ServerName)
+	}
+
+	if len(vhost.CertificateCoverage.UncoveredNames) == 0 {
+		return nil, fmt.Errorf("certificate of host %s covers all host names", vhost.ServerName)
+	}
+
+	if vhost.MatchType != dto.MatchTypeExact {
+		return nil, fmt.Errorf("certificate can not be issued for host %s with %s server name", vhost.ServerName, vhost.MatchType)
+	}
+
+	challengeType := reissueRequest.ChallengeType
+
+	if challengeType == "" {
+		challengeType = acme.HttpChallengeTypeCode
+	}
+
+	subjects := getReissueSubjects(vhost.CertificateCoverage.CoveredNames, vhost.CertificateCoverage.UncoveredNames)
+
+	for _, subject := range subjects {
+		if strings.HasPrefix(subject, "*.") && challengeType == acme.HttpChallengeTypeCode {
+			return nil, fmt.Errorf("wildcard name %s can not be issued with http challenge", subject)
+		}
+	}
+
+	return c.Issue(request.IssueRequest{
+		Email:         reissueRequest.Email,
+		ServerName:    vhost.ServerName,
+		WebServer:     reissueRequest.WebServer,
+		ChallengeType: challengeType,
+		Subjects:      subjects,
+		Assign:        true,
+	})
+}
+
+// getReissueSubjects returns the host names in certificate form, e.g. "*.example.com" for the wildcard server name
+func getReissueSubjects(coveredNames, uncoveredNames []string) []string {
+	var subjects []string
+
+	for _, name := range append(slices.Clone(coveredNames), uncoveredNames...) {
+		name = strings.ToLower(name)
+
+		if !slices.Contains(subjects, name) {
+			subjects = append(subjects, name)
+		}
+	}
+
+	return subjects
+}
@@ -0,0 +1,96 @@
+//go:build common
+
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/r2dtools/sslbot/internal/certificates/acme"
+	"github.com/r2dtools/sslbot/internal/certificates/request"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reissueNginxHostConfig = `server {
+    listen 443 ssl;
+    server_name example.com www.example.com %s;
+    root /var/www/html;
+    ssl_certificate %s;
+    ssl_certificate_key %s;
+}
+`
+
+func TestReissueUsesHostNames(t *testing.T) {
+	configPath, _, certManager := createIssueTestManager(t, issueNginxHostConfig)
+	certPath, keyPath := createReissueTestCertificate(t, []string{"*.example.com", "other.org"})
+	hostConfig := fmt.Sprintf(reissueNginxHostConfig, "", certPath, keyPath)
+	require.Nil(t, os.WriteFile(configPath, []byte(hostConfig), 0644))
+
+	_, err := certManager.Reissue(request.ReissueRequest{ServerName: "example.com", WebServer: webserver.WebServerNginxCode})
+	require.Nil(t, err)
+
+	// other.org is not served by the host, www.example.com is covered by the wildcard certificate name
+	issueRequest := certManager.acmeClient.(*issueTestAcmeClient).issueRequest
+	assert.Equal(t, acme.HttpChallengeTypeCode, issueRequest.ChallengeType)
+	assert.Equal(t, []string{"www.example.com", "example.com"}, issueRequest.Subjects)
+}
+
+func TestReissueWildcardServerName(t *testing.T) {
+	configPath, _, certManager := createIssueTestManager(t, issueNginxHostConfig)
+	certPath, keyPath := createReissueTestCertificate(t, []string{"*.example.com"})
+	hostConfig := fmt.Sprintf(reissueNginxHostConfig, "*.example.com", certPath, keyPath)
+	require.Nil(t, os.WriteFile(configPath, []byte(hostConfig), 0644))
+
+	_, err := certManager.Reissue(request.ReissueRequest{ServerName: "example.com", WebServer: webserver.WebServerNginxCode})
+	assert.ErrorContains(t, err, "wildcard name *.example.com can not be issued with http challenge")
+
+	_, err = certManager.Reissue(request.ReissueRequest{
+		ServerName:    "example.com",
+		WebServer:     webserver.WebServerNginxCode,
+		ChallengeType: acme.DnsChallengeTypeCode,
+	})
+	require.Nil(t, err)
+
+	issueRequest := certManager.acmeClient.(*issueTestAcmeClient).issueRequest
+	assert.Equal(t, acme.DnsChallengeTypeCode, issueRequest.ChallengeType)
+	assert.Equal(t, []string{"www.example.com", "*.example.com", "example.com"}, issueRequest.Subjects)
+}
+
+// createReissueTestCertificate writes a self-signed certificate with the names and its key
+func createReissueTestCertificate(t *testing.T, dnsNames []string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "cert.pem")
+	keyPath := filepath.Join(dir, "key.pem")
+	require.Nil(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer}), 0644))
+	require.Nil(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+
+	return certPath, keyPath
+}
@@ -33,6 +33,24 @@ func (s *DefaultStorage) AddPemCertificate(certName, pemData string) (certPath s
 	return certPath, nil
 }
 
+// LinkCertificate adds the certificate to the storage as a symlink to the existing file
+func (s *DefaultStorage) LinkCertificate(certName, targetPath string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	targetPath, err := filepath.Abs(targetPath)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.Symlink(targetPath, s.getCertificatePath(certName)); err != nil {
+		return fmt.Errorf("could not link certificate to the storage: %v", err)
+	}
+
+	return nil
+}
+
 func (s *DefaultStorage) RemoveCertificate(certName string) error {
 	s.Lock()
 	defer s.Unlock()
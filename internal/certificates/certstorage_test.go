@@ -80,3 +80,18 @@ func getStorage(t *testing.T) *DefaultStorage {
 		logger:  &logger.TestLogger{T: t},
 	}
 }
+
+func TestLinkCertificate(t *testing.T) {
+	storage := getStorage(t)
+	storage.path = t.TempDir()
+
+	err := storage.LinkCertificate("linked.com", "../../test/certificate/example.com.pem")
+	assert.Nil(t, err)
+
+	cert, err := storage.GetCertificate("linked.com")
+	assert.Nil(t, err)
+	assert.Equal(t, "example.com", cert.CN)
+
+	err = storage.LinkCertificate("linked.com", "../../test/certificate/example.com.pem")
+	assert.NotNil(t, err)
+}
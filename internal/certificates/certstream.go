@@ -0,0 +1,147 @@
+package certificates
+
+import (
+	"fmt"
+
+	"github.com/r2dtools/sslbot/internal/certificates/request"
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/utils"
+	"github.com/r2dtools/sslbot/internal/webserver"
+)
+
+// GetStreamServers returns TCP/TLS stream servers of all webservers that support them.
+// Storage and name of the certificate used by a server are resolved as for hosts.
+func (c *CertificateManager) GetStreamServers() ([]dto.StreamServer, error) {
+	certPathMap, err := c.getStorageCertPathMap()
+
+	if err != nil {
+		return nil, err
+	}
+
+	servers := c.getStreamServers()
+
+	for i := range servers {
+		resolveStreamServerCertificate(&servers[i], certPathMap)
+	}
+
+	return servers, nil
+}
+
+func (c *CertificateManager) AssignStream(request request.StreamAssignRequest) (*dto.Certificate, error) {
+	storage, err := c.getStorage(CertStorageType(request.StorageType))
+
+	if err != nil {
+		return nil, err
+	}
+
+	certPath, keyPath, err := storage.GetCertificatePath(request.CertName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = c.deployStreamCertificate(request.WebServer, request.ServerKey, certPath, keyPath); err != nil {
+		return nil, err
+	}
+
+	return utils.GetCertificateFromFile(certPath)
+}
+
+func (c *CertificateManager) deployStreamCertificate(webServerCode, serverKey, certPath, keyPath string) error {
+	wServer, err := c.wServerFactory(webServerCode, c.config.ToMap())
+
+	if err != nil {
+		return err
+	}
+
+	if _, ok := wServer.(webserver.StreamWebServer); !ok {
+		return fmt.Errorf("webserver %s does not support stream servers", webServerCode)
+	}
+
+	sReverter, err := c.reverterFactory(wServer, c.logger)
+
+	if err != nil {
+		return err
+	}
+
+	certDeployer := createStreamCertificateDeployer(wServer, sReverter, c.logger, c.mx)
+
+	return certDeployer.DeployStreamCertificate(serverKey, certPath, keyPath, false)
+}
+
+func (c *CertificateManager) getStreamServers() []dto.StreamServer {
+	var servers []dto.StreamServer
+	options := c.config.ToMap()
+
+	for _, webServerCode := range webserver.GetWebServers(options) {
+		wServer, err := c.wServerFactory(webServerCode, options)
+
+		if err != nil {
+			c.logger.Debug("failed to get %s webserver: %v", webServerCode, err)
+
+			continue
+		}
+
+		streamWebServer, ok := wServer.(webserver.StreamWebServer)
+
+		if !ok {
+			continue
+		}
+
+		wServers, err := streamWebServer.GetStreamServers()
+
+		if err != nil {
+			c.logger.Error("failed to get %s stream servers: %v", webServerCode, err)
+
+			continue
+		}
+
+		servers = append(servers, wServers...)
+	}
+
+	return servers
+}
+
+// getReferenceStreamServers returns stream servers of all webservers. Unlike getStreamServers it fails
+// if servers of an installed webserver could not be read, as getReferenceVhosts does for hosts.
+func (c *CertificateManager) getReferenceStreamServers() ([]dto.StreamServer, error) {
+	var servers []dto.StreamServer
+	options := c.config.ToMap()
+
+	for _, webServerCode := range webserver.GetWebServers(options) {
+		wServer, err := c.wServerFactory(webServerCode, options)
+
+		if err != nil {
+			if !webserver.IsWebServerInstalled(webServerCode, options) {
+				continue
+			}
+
+			return nil, fmt.Errorf("could not check certificate usage by %s: %v", webServerCode, err)
+		}
+
+		streamWebServer, ok := wServer.(webserver.StreamWebServer)
+
+		if !ok {
+			continue
+		}
+
+		wServers, err := streamWebServer.GetStreamServers()
+
+		if err != nil {
+			return nil, fmt.Errorf("could not check certificate usage by %s stream servers: %v", webServerCode, err)
+		}
+
+		servers = append(servers, wServers...)
+	}
+
+	return servers, nil
+}
+
+func resolveStreamServerCertificate(server *dto.StreamServer, certPathMap map[string]CertStorageItem) bool {
+	vhost := dto.VirtualHost{CertificatePath: server.CertificatePath}
+	resolved := resolveVhostCertificate(&vhost, certPathMap)
+	server.CertificateStorage = vhost.CertificateStorage
+	server.CertificateName = vhost.CertificateName
+
+	return resolved
+}
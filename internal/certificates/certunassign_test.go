@@ -0,0 +1,82 @@
+//go:build common
+
+package certificates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/certificates/request"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const unassignNginxConfig = `events {}
+
+http {
+    server {
+        listen 80;
+        server_name example.com;
+
+        location / {
+            return 301 https://$host$request_uri;
+        }
+    }
+
+    server {
+        listen 443 ssl;
+        server_name example.com;
+        ssl_certificate %[1]s/test/certificate/example.com.crt;
+        ssl_certificate_key %[1]s/test/certificate/example.com.key;
+    }
+}
+`
+
+func TestUnassignDisablesRedirectFirst(t *testing.T) {
+	root, err := filepath.Abs("../..")
+	require.Nil(t, err)
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "nginx.conf")
+	nginxConfig := fmt.Sprintf(unassignNginxConfig, root)
+	require.Nil(t, os.WriteFile(configPath, []byte(nginxConfig), 0644))
+
+	// the first reload on redirect disabling succeeds, the reload on ssl removal fails
+	marker := filepath.Join(dir, "reloaded")
+	options := map[string]string{
+		config.NginxRootOpt:           dir,
+		config.NginxBinOpt:            "true",
+		config.NginxReloadStrategyOpt: "command",
+		config.NginxReloadCommandOpt:  fmt.Sprintf("test ! -e %[1]s && touch %[1]s", marker),
+	}
+
+	for key, value := range options {
+		viper.Set(key, value)
+		defer viper.Set(key, nil)
+	}
+
+	certManager := &CertificateManager{
+		wServerFactory:  webserver.CreateWebServer,
+		reverterFactory: reverter.CreateReverter,
+		config:          &config.Config{},
+		logger:          &logger.NilLogger{},
+		mx:              &sync.Mutex{},
+	}
+
+	err = certManager.Unassign(request.UnassignRequest{WebServer: webserver.WebServerNginxCode, ServerName: "example.com"})
+	assert.NotNil(t, err)
+
+	// ssl removal is rolled back, the host does not redirect to https any more
+	content, err := os.ReadFile(configPath)
+	require.Nil(t, err)
+	assert.NotContains(t, string(content), "return 301")
+	assert.Contains(t, string(content), "ssl_certificate ")
+}
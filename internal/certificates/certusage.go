@@ -0,0 +1,164 @@
+package certificates
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/webserver"
+)
+
+const UnmanagedCertStorage = "unmanaged"
+
+type CertStorageVhost struct {
+	WebServer  string
+	ServerName string
+	FilePath   string
+	// Stream is set for TCP/TLS stream servers, ServerName is the server key then
+	Stream bool
+}
+
+// ResolveVhostCertificates sets storage and certificate name for the hosts that use a certificate.
+// Hosts with a certificate outside every storage are marked as unmanaged.
+func (c *CertificateManager) ResolveVhostCertificates(vhosts []dto.VirtualHost) ([]dto.VirtualHost, error) {
+	certPathMap, err := c.getStorageCertPathMap()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range vhosts {
+		resolveVhostCertificate(&vhosts[i], certPathMap)
+	}
+
+	return vhosts, nil
+}
+
+func (c *CertificateManager) getVhosts() []dto.VirtualHost {
+	var vhosts []dto.VirtualHost
+	options := c.config.ToMap()
+
+	for _, webServerCode := range webserver.GetWebServers(options) {
+		wServer, err := c.wServerFactory(webServerCode, options)
+
+		if err != nil {
+			c.logger.Debug("failed to get %s webserver: %v", webServerCode, err)
+
+			continue
+		}
+
+		wVhosts, err := wServer.GetVhosts()
+
+		if err != nil {
+			c.logger.Error("failed to get %s hosts: %v", webServerCode, err)
+
+			continue
+		}
+
+		vhosts = append(vhosts, wVhosts...)
+	}
+
+	return vhosts
+}
+
+// getReferenceVhosts returns hosts of all webservers that use a certificate, on any port.
+// Unlike getVhosts it fails if an installed webserver could not be parsed: a missed host would make its certificate unreferenced.
+func (c *CertificateManager) getReferenceVhosts() ([]dto.VirtualHost, error) {
+	var vhosts []dto.VirtualHost
+	options := c.config.ToMap()
+
+	for _, webServerCode := range webserver.GetWebServers(options) {
+		wServer, err := c.wServerFactory(webServerCode, options)
+
+		if err != nil {
+			if !webserver.IsWebServerInstalled(webServerCode, options) {
+				continue
+			}
+
+			return nil, fmt.Errorf("could not check certificate usage by %s: %v", webServerCode, err)
+		}
+
+		wVhosts, err := webserver.GetCertificateVhosts(wServer)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not check certificate usage by %s: %v", webServerCode, err)
+		}
+
+		vhosts = append(vhosts, wVhosts...)
+	}
+
+	return vhosts, nil
+}
+
+// getStorageCertPathMap maps certificate paths of all storages to the storage items
+func (c *CertificateManager) getStorageCertPathMap() (map[string]CertStorageItem, error) {
+	certPathMap, _, err := c.getStorageCertPaths()
+
+	return certPathMap, err
+}
+
+// getStorageCertPaths maps certificate paths of all storages to the storage items.
+// Certificates which path could not be got are returned separately, their usage is unknown.
+func (c *CertificateManager) getStorageCertPaths() (map[string]CertStorageItem, []CertStorageItem, error) {
+	certPathMap := map[string]CertStorageItem{}
+	var unresolvedItems []CertStorageItem
+
+	for storageType, storage := range c.certStorages {
+		certs, err := storage.GetCertificates()
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for certName, cert := range certs {
+			item := CertStorageItem{StorageType: storageType, CertName: certName, Certificate: cert}
+			certPath, _, err := storage.GetCertificatePath(certName)
+
+			if err != nil {
+				c.logger.Error("failed to get certificate %s path: %v", certName, err)
+				item.UsageUnknown = true
+				unresolvedItems = append(unresolvedItems, item)
+
+				continue
+			}
+
+			for _, path := range getCertPathVariants(certPath) {
+				certPathMap[path] = item
+			}
+		}
+	}
+
+	return certPathMap, unresolvedItems, nil
+}
+
+func resolveVhostCertificate(vhost *dto.VirtualHost, certPathMap map[string]CertStorageItem) bool {
+	if vhost.CertificatePath == "" {
+		return false
+	}
+
+	for _, path := range getCertPathVariants(vhost.CertificatePath) {
+		if item, ok := certPathMap[path]; ok {
+			vhost.CertificateStorage = string(item.StorageType)
+			vhost.CertificateName = item.CertName
+
+			return true
+		}
+	}
+
+	vhost.CertificateStorage = UnmanagedCertStorage
+	vhost.CertificateName = ""
+
+	return false
+}
+
+// getCertPathVariants returns the cleaned path and the path with resolved symlinks (certbot live certificates are symlinks)
+func getCertPathVariants(path string) []string {
+	path = filepath.Clean(path)
+	variants := []string{path}
+
+	if realPath, err := filepath.EvalSymlinks(path); err == nil && realPath != path {
+		variants = append(variants, realPath)
+	}
+
+	return variants
+}
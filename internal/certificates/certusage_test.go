@@ -0,0 +1,75 @@
+//go:build common
+
+package certificates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveVhostCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "example.com.pem")
+	linkPath := filepath.Join(dir, "fullchain.pem")
+	assert.Nil(t, os.WriteFile(certPath, []byte{}, 0644))
+	assert.Nil(t, os.Symlink(certPath, linkPath))
+
+	certPathMap := map[string]CertStorageItem{
+		certPath: {StorageType: Default, CertName: "example.com"},
+	}
+
+	vhost := dto.VirtualHost{CertificatePath: linkPath}
+	assert.True(t, resolveVhostCertificate(&vhost, certPathMap))
+	assert.Equal(t, string(Default), vhost.CertificateStorage)
+	assert.Equal(t, "example.com", vhost.CertificateName)
+
+	vhost = dto.VirtualHost{CertificatePath: "/etc/ssl/certs/example.com.pem"}
+	assert.False(t, resolveVhostCertificate(&vhost, certPathMap))
+	assert.Equal(t, UnmanagedCertStorage, vhost.CertificateStorage)
+
+	vhost = dto.VirtualHost{}
+	assert.False(t, resolveVhostCertificate(&vhost, certPathMap))
+	assert.Equal(t, "", vhost.CertificateStorage)
+}
+
+type usageTestStorage struct {
+	pruneTestStorage
+}
+
+func (s *usageTestStorage) GetCertificatePath(certName string) (string, string, error) {
+	return "", "", errors.New("certificate path is not available")
+}
+
+type usageTestWebServer struct {
+	webserver.WebServer
+}
+
+func (s *usageTestWebServer) GetVhosts() ([]dto.VirtualHost, error) {
+	return nil, nil
+}
+
+func TestGetStorageCertificatesWithoutPath(t *testing.T) {
+	factory := func(code string, options map[string]string) (webserver.WebServer, error) {
+		return &usageTestWebServer{}, nil
+	}
+	certManager, _ := createPruneTestManager(t, factory)
+	certManager.certStorages[Default] = &usageTestStorage{}
+
+	// the certificate is listed, but hosts that use it are unknown
+	items, err := certManager.GetStorageCertificates()
+	require.Nil(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "example.com", items[0].CertName)
+	assert.True(t, items[0].UsageUnknown)
+
+	candidates, err := certManager.GetPruneCandidates(nil)
+	require.Nil(t, err)
+	assert.Empty(t, candidates)
+}
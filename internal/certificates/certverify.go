@@ -0,0 +1,134 @@
+package certificates
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/utils"
+	"github.com/r2dtools/sslbot/internal/webserver"
+)
+
+const (
+	verifyRetryInterval = 500 * time.Millisecond
+	verifyDialTimeout   = 5 * time.Second
+)
+
+// verifyServedCertificate checks that the host serves the deployed certificate on its local ssl addresses
+// for the server name and each alias. Wildcard and regex names can not be sent as SNI and are skipped.
+// Workers can still be restarting after reload, so requests are retried until timeout.
+func verifyServedCertificate(vhost *dto.VirtualHost, certPath string, timeout time.Duration) error {
+	certContent, err := os.ReadFile(certPath)
+
+	if err != nil {
+		return fmt.Errorf("could not read certificate content: %v", err)
+	}
+
+	fingerprint, err := utils.GetCertificateFingerprint(certContent)
+
+	if err != nil {
+		return err
+	}
+
+	var names []string
+
+	for _, name := range append([]string{vhost.ServerName}, vhost.Aliases...) {
+		if webserver.IsExactServerName(name) {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	addresses := getVerifyAddresses(vhost)
+
+	if len(addresses) == 0 {
+		return fmt.Errorf("host %s has no ssl addresses to verify the certificate", vhost.ServerName)
+	}
+
+	deadline := time.Now().Add(timeout)
+
+	for _, address := range addresses {
+		for _, name := range names {
+			if err := verifyAddressCertificate(address, name, fingerprint, deadline); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func verifyAddressCertificate(address, serverName, fingerprint string, deadline time.Time) error {
+	for {
+		servedFingerprint, err := getServedCertificateFingerprint(address, serverName)
+
+		if err == nil && servedFingerprint == fingerprint {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("could not verify certificate for %s on %s: %v", serverName, address, err)
+			}
+
+			return fmt.Errorf("certificate %s is served for %s on %s instead of the deployed one", servedFingerprint, serverName, address)
+		}
+
+		time.Sleep(verifyRetryInterval)
+	}
+}
+
+func getServedCertificateFingerprint(address, serverName string) (string, error) {
+	certs, err := utils.GetX509CertificateFromAddress(address, serverName, verifyDialTimeout)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(certs) == 0 {
+		return "", fmt.Errorf("no certificate is served")
+	}
+
+	return utils.GetX509CertificateFingerprint(certs[0].Raw), nil
+}
+
+// getVerifyAddresses returns local ssl addresses of the host. Wildcard hosts are replaced with the loopback address.
+func getVerifyAddresses(vhost *dto.VirtualHost) []string {
+	var addresses []string
+
+	for _, address := range vhost.Addresses {
+		if !address.Ssl {
+			continue
+		}
+
+		port := address.Port
+
+		if port == "" {
+			port = "443"
+		}
+
+		host := strings.Trim(address.Host, "[]")
+
+		switch host {
+		case "", "*", "0.0.0.0":
+			host = "127.0.0.1"
+		case "::":
+			host = "::1"
+		}
+
+		hostPort := net.JoinHostPort(host, port)
+
+		if !slices.Contains(addresses, hostPort) {
+			addresses = append(addresses, hostPort)
+		}
+	}
+
+	return addresses
+}
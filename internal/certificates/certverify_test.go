@@ -0,0 +1,73 @@
+//go:build common
+
+package certificates
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyServedCertificate(t *testing.T) {
+	cert, err := tls.LoadX509KeyPair("../../test/certificate/example.com.crt", "../../test/certificate/example.com.key")
+	require.Nil(t, err)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	require.Nil(t, err)
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+				conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.Nil(t, err)
+
+	// plain addresses are not dialed, wildcard and regex names are not sent as SNI
+	vhost := &dto.VirtualHost{
+		ServerName: "example.com",
+		Aliases:    []string{"www.example.com", "*.example.com", "~^app\\d+\\.example\\.com$"},
+		Addresses:  []dto.VirtualHostAddress{{Port: "80"}, {Port: "8080"}, {Host: "*", Port: port, Ssl: true}},
+	}
+
+	err = verifyServedCertificate(vhost, "../../test/certificate/example.com.crt", time.Second)
+	assert.Nil(t, err)
+
+	err = verifyServedCertificate(vhost, "../../test/certificate/example2.com.crt", time.Second)
+	assert.ErrorContains(t, err, "instead of the deployed one")
+
+	vhost.Addresses = []dto.VirtualHostAddress{{Port: "8080"}}
+	err = verifyServedCertificate(vhost, "../../test/certificate/example.com.crt", time.Second)
+	assert.ErrorContains(t, err, "has no ssl addresses")
+}
+
+func TestGetVerifyAddresses(t *testing.T) {
+	vhost := &dto.VirtualHost{
+		Addresses: []dto.VirtualHostAddress{
+			{Port: "80"},
+			{Port: "8080"},
+			{Host: "*", Port: "443", Ssl: true},
+			{Host: "[::]", Port: "443", IsIpv6: true, Ssl: true},
+			{Host: "192.168.1.1", Port: "8443", Ssl: true},
+			{Host: "0.0.0.0", Port: "443", Ssl: true},
+		},
+	}
+	assert.Equal(t, []string{"127.0.0.1:443", "[::1]:443", "192.168.1.1:8443"}, getVerifyAddresses(vhost))
+	assert.Empty(t, getVerifyAddresses(&dto.VirtualHost{Addresses: []dto.VirtualHostAddress{{Port: "80"}}}))
+}
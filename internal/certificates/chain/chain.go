@@ -0,0 +1,329 @@
+package chain
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+)
+
+const (
+	maxChainLength  = 10
+	aiaFetchTimeout = 10 * time.Second
+	aiaMaxBodySize  = 1 << 20
+)
+
+type Completer struct {
+	intermediatesDir string
+	aiaFetchEnabled  bool
+	aiaEndpoint      string
+	httpClient       *http.Client
+	logger           logger.Logger
+}
+
+// Complete appends missing intermediate certificates to the pem data so that the chain leads from the leaf up to a root.
+// Certificates are placed first (leaf, then intermediates in issuing order), other pem blocks (private keys) are kept after them.
+// Added intermediates are returned as well.
+func (c *Completer) Complete(pemData string) (string, []*x509.Certificate, error) {
+	certs, otherBlocks, err := decodePem([]byte(pemData))
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(certs) == 0 {
+		return "", nil, errors.New("could not parse certificate")
+	}
+
+	var knownIntermediates []*x509.Certificate
+	knownIntermediatesLoaded := false
+
+	leaf := findLeaf(certs)
+	chain := []*x509.Certificate{leaf}
+	var added []*x509.Certificate
+	current := leaf
+
+	for len(chain) < maxChainLength && !isSelfSigned(current) {
+		issuer := findIssuer(current, certs)
+
+		if issuer == nil {
+			if !knownIntermediatesLoaded {
+				knownIntermediates = c.loadIntermediates()
+				knownIntermediatesLoaded = true
+			}
+
+			issuer = findIssuer(current, knownIntermediates)
+
+			if issuer == nil && c.aiaFetchEnabled {
+				issuer = c.fetchIssuer(current)
+			}
+
+			if issuer == nil {
+				c.logger.Debug("could not find issuer '%s' of certificate '%s'", current.Issuer.String(), current.Subject.String())
+
+				break
+			}
+
+			// roots are trusted by clients and must not be served
+			if isSelfSigned(issuer) {
+				break
+			}
+
+			added = append(added, issuer)
+		}
+
+		if containsCertificate(chain, issuer) {
+			break
+		}
+
+		chain = append(chain, issuer)
+		current = issuer
+	}
+
+	// keep provided certificates that are not part of the chain (e.g. cross-signed) at the end
+	for _, cert := range certs {
+		if !containsCertificate(chain, cert) {
+			chain = append(chain, cert)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	for _, cert := range chain {
+		if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}); err != nil {
+			return "", nil, err
+		}
+	}
+
+	for _, block := range otherBlocks {
+		if err := pem.Encode(&buf, block); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return buf.String(), added, nil
+}
+
+func (c *Completer) loadIntermediates() []*x509.Certificate {
+	var intermediates []*x509.Certificate
+
+	if c.intermediatesDir == "" {
+		return intermediates
+	}
+
+	entries, err := os.ReadDir(c.intermediatesDir)
+
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			c.logger.Error("could not read intermediates directory %s: %v", c.intermediatesDir, err)
+		}
+
+		return intermediates
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		ext := strings.Trim(filepath.Ext(entry.Name()), ".")
+
+		if ext != "pem" && ext != "crt" && ext != "cer" {
+			continue
+		}
+
+		filePath := filepath.Join(c.intermediatesDir, entry.Name())
+		content, err := os.ReadFile(filePath)
+
+		if err != nil {
+			c.logger.Error("could not read intermediate certificate %s: %v", filePath, err)
+
+			continue
+		}
+
+		certs, err := parseCertificates(content)
+
+		if err != nil {
+			c.logger.Error("could not parse intermediate certificate %s: %v", filePath, err)
+
+			continue
+		}
+
+		intermediates = append(intermediates, certs...)
+	}
+
+	return intermediates
+}
+
+func (c *Completer) fetchIssuer(cert *x509.Certificate) *x509.Certificate {
+	for _, issuerURL := range cert.IssuingCertificateURL {
+		issuers, err := c.fetchCertificates(issuerURL)
+
+		if err != nil {
+			c.logger.Error("could not fetch issuer certificate from %s: %v", issuerURL, err)
+
+			continue
+		}
+
+		if issuer := findIssuer(cert, issuers); issuer != nil {
+			return issuer
+		}
+	}
+
+	return nil
+}
+
+func (c *Completer) fetchCertificates(issuerURL string) ([]*x509.Certificate, error) {
+	fetchURL := issuerURL
+
+	if c.aiaEndpoint != "" {
+		endpoint, err := url.Parse(c.aiaEndpoint)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid aia endpoint: %v", err)
+		}
+
+		query := endpoint.Query()
+		query.Set("url", issuerURL)
+		endpoint.RawQuery = query.Encode()
+		fetchURL = endpoint.String()
+	}
+
+	response, err := c.httpClient.Get(fetchURL)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(response.Body, aiaMaxBodySize))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCertificates(content)
+}
+
+// parseCertificates parses pem or der encoded certificates. AIA urls usually point to der files.
+func parseCertificates(content []byte) ([]*x509.Certificate, error) {
+	certs, _, err := decodePem(content)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(certs) > 0 {
+		return certs, nil
+	}
+
+	return x509.ParseCertificates(content)
+}
+
+func decodePem(content []byte) ([]*x509.Certificate, []*pem.Block, error) {
+	var certs []*x509.Certificate
+	var otherBlocks []*pem.Block
+
+	for {
+		block, rest := pem.Decode(content)
+
+		if block == nil {
+			break
+		}
+
+		if block.Type == "CERTIFICATE" {
+			cert, err := x509.ParseCertificate(block.Bytes)
+
+			if err != nil {
+				return nil, nil, fmt.Errorf("could not parse certificate: %v", err)
+			}
+
+			certs = append(certs, cert)
+		} else {
+			otherBlocks = append(otherBlocks, block)
+		}
+
+		content = rest
+	}
+
+	return certs, otherBlocks, nil
+}
+
+// findLeaf returns the first certificate that does not issue any other provided certificate
+func findLeaf(certs []*x509.Certificate) *x509.Certificate {
+	for _, cert := range certs {
+		isIssuer := false
+
+		for _, other := range certs {
+			if other != cert && findIssuer(other, []*x509.Certificate{cert}) != nil {
+				isIssuer = true
+
+				break
+			}
+		}
+
+		if !isIssuer {
+			return cert
+		}
+	}
+
+	return certs[0]
+}
+
+func findIssuer(cert *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range candidates {
+		if !bytes.Equal(cert.RawIssuer, candidate.RawSubject) {
+			continue
+		}
+
+		if cert.CheckSignatureFrom(candidate) == nil {
+			return candidate
+		}
+	}
+
+	return nil
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
+	}
+
+	return cert.CheckSignatureFrom(cert) == nil
+}
+
+func containsCertificate(certs []*x509.Certificate, cert *x509.Certificate) bool {
+	for _, c := range certs {
+		if c.Equal(cert) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func CreateCompleter(config *config.Config, logger logger.Logger) *Completer {
+	return &Completer{
+		intermediatesDir: config.IntermediatesDir,
+		aiaFetchEnabled:  config.AiaFetchEnabled,
+		aiaEndpoint:      config.AiaEndpoint,
+		httpClient:       &http.Client{Timeout: aiaFetchTimeout},
+		logger:           logger,
+	}
+}
@@ -141,6 +141,14 @@ func (c *ApacheCommonDirChangeCommand) EnableCommonDir(serverName string) error
 		return err
 	}
 
+	if err := webserver.TestConfig(c.webServer); err != nil {
+		if rErr := c.reverter.Rollback(); rErr != nil {
+			c.logger.Error(fmt.Sprintf("failed to rollback webserver configuration on config test: %v", rErr))
+		}
+
+		return err
+	}
+
 	if err := processManager.Reload(); err != nil {
 		if rErr := c.reverter.Rollback(); rErr != nil {
 			c.logger.Error(fmt.Sprintf("failed to rollback webserver configuration on webserver reload: %v", rErr))
@@ -219,6 +227,14 @@ func (c *ApacheCommonDirChangeCommand) DisableCommonDir(serverName string) error
 		return err
 	}
 
+	if err := webserver.TestConfig(c.webServer); err != nil {
+		if rErr := c.reverter.Rollback(); rErr != nil {
+			c.logger.Error(fmt.Sprintf("failed to rollback webserver configuration on config test: %v", rErr))
+		}
+
+		return err
+	}
+
 	if err := processManager.Reload(); err != nil {
 		if rErr := c.reverter.Rollback(); rErr != nil {
 			c.logger.Error(fmt.Sprintf("failed to rollback webserver configuration on webserver reload: %v", rErr))
@@ -30,6 +30,8 @@ func CreateCommonDirStatusQuery(webServer webserver.WebServer) (CommonDirQuery,
 		return &NginxCommonDirQuery{webServer: w}, nil
 	case *webserver.ApacheWebServer:
 		return &ApacheCommonDirQuery{webServer: w}, nil
+	case *webserver.LighttpdWebServer:
+		return &LighttpdCommonDirQuery{webServer: w}, nil
 	default:
 		return nil, fmt.Errorf("webserver %s is not supported", webServer.GetCode())
 	}
@@ -59,6 +61,14 @@ func CreateCommonDirChangeCommand(
 			commonDir: config.ApacheAcmeCommonDir,
 			mx:        mx,
 		}, nil
+	case *webserver.LighttpdWebServer:
+		return &LighttpdCommonDirChangeCommand{
+			logger:    logger,
+			webServer: w,
+			reverter:  reverter,
+			commonDir: config.LighttpdAcmeCommonDir,
+			mx:        mx,
+		}, nil
 	default:
 		return nil, fmt.Errorf("webserver %s is not supported", webServer.GetCode())
 	}
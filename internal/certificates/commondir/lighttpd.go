@@ -0,0 +1,182 @@
+package commondir
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/lighttpdconf"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+)
+
+const (
+	lighttpdAcmeLocation = "/.well-known/acme-challenge/"
+	lighttpdAliasKey     = "alias.url"
+)
+
+type LighttpdCommonDirQuery struct {
+	webServer *webserver.LighttpdWebServer
+}
+
+func (q *LighttpdCommonDirQuery) GetCommonDirStatus(serverName string) CommonDir {
+	var commonDir CommonDir
+	hostBlock := findLighttpdHostBlock(q.webServer, serverName)
+
+	if hostBlock == nil {
+		return commonDir
+	}
+
+	_, item := findLighttpdCommonDirAlias(hostBlock)
+
+	if item == nil {
+		return commonDir
+	}
+
+	commonDir.Enabled = true
+	commonDir.Root = strings.TrimSuffix(item.Value, strings.TrimPrefix(lighttpdAcmeLocation, "/"))
+
+	return commonDir
+}
+
+type LighttpdCommonDirChangeCommand struct {
+	webServer *webserver.LighttpdWebServer
+	reverter  reverter.Reverter
+	logger    logger.Logger
+	commonDir string
+	mx        *sync.Mutex
+}
+
+func (c *LighttpdCommonDirChangeCommand) EnableCommonDir(serverName string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	hostBlock := findLighttpdHostBlock(c.webServer, serverName)
+
+	if hostBlock == nil {
+		return fmt.Errorf("lighttpd host %s does not exist", serverName)
+	}
+
+	processManager, err := c.webServer.GetProcessManager()
+
+	if err != nil {
+		return err
+	}
+
+	if _, item := findLighttpdCommonDirAlias(hostBlock); item != nil {
+		c.logger.Info("common directory is already enabled for %s host", serverName)
+
+		return nil
+	}
+
+	alias := lighttpdconf.ArrayItem{
+		Key:   lighttpdAcmeLocation,
+		Value: strings.TrimSuffix(c.commonDir, "/") + lighttpdAcmeLocation,
+	}
+	hostBlock.AddStatement(lighttpdAliasKey, "+=", lighttpdconf.FormatArray([]lighttpdconf.ArrayItem{alias}))
+
+	return c.apply(hostBlock.File, processManager)
+}
+
+func (c *LighttpdCommonDirChangeCommand) DisableCommonDir(serverName string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	hostBlock := findLighttpdHostBlock(c.webServer, serverName)
+
+	if hostBlock == nil {
+		return fmt.Errorf("lighttpd host %s does not exist", serverName)
+	}
+
+	processManager, err := c.webServer.GetProcessManager()
+
+	if err != nil {
+		return err
+	}
+
+	statement, item := findLighttpdCommonDirAlias(hostBlock)
+
+	if item == nil {
+		return nil
+	}
+
+	var items []lighttpdconf.ArrayItem
+
+	for _, alias := range lighttpdconf.ParseArray(statement.Value) {
+		if alias.Key != lighttpdAcmeLocation {
+			items = append(items, alias)
+		}
+	}
+
+	if len(items) == 0 {
+		hostBlock.RemoveEntry(statement)
+	} else {
+		statement.SetValue(lighttpdconf.FormatArray(items))
+	}
+
+	return c.apply(hostBlock.File, processManager)
+}
+
+func (c *LighttpdCommonDirChangeCommand) apply(file *lighttpdconf.File, processManager webserver.ProcessManager) error {
+	if err := c.reverter.BackupConfig(file.Path); err != nil {
+		return err
+	}
+
+	if err := file.Save(); err != nil {
+		if rErr := c.reverter.Rollback(); rErr != nil {
+			c.logger.Error(fmt.Sprintf("failed to rollback webserver configuration on common directory switching: %v", rErr))
+		}
+
+		return err
+	}
+
+	if err := webserver.TestConfig(c.webServer); err != nil {
+		if rErr := c.reverter.Rollback(); rErr != nil {
+			c.logger.Error(fmt.Sprintf("failed to rollback webserver configuration on config test: %v", rErr))
+		}
+
+		return err
+	}
+
+	if err := processManager.Reload(); err != nil {
+		if rErr := c.reverter.Rollback(); rErr != nil {
+			c.logger.Error(fmt.Sprintf("failed to rollback webserver configuration on webserver reload: %v", rErr))
+		}
+
+		return err
+	}
+
+	c.reverter.Commit()
+
+	return nil
+}
+
+// findLighttpdHostBlock returns the host block outside of sockets, it is applied to 80 port as well
+func findLighttpdHostBlock(webServer *webserver.LighttpdWebServer, serverName string) *lighttpdconf.Entry {
+	hosts := webServer.FindHosts(serverName)
+
+	for _, host := range hosts {
+		if host.Socket == nil {
+			return host.Block
+		}
+	}
+
+	if len(hosts) > 0 {
+		return hosts[0].Block
+	}
+
+	return nil
+}
+
+func findLighttpdCommonDirAlias(hostBlock *lighttpdconf.Entry) (*lighttpdconf.Entry, *lighttpdconf.ArrayItem) {
+	for _, statement := range hostBlock.FindStatements(lighttpdAliasKey) {
+		for _, item := range lighttpdconf.ParseArray(statement.Value) {
+			if item.Key == lighttpdAcmeLocation {
+				return statement, &item
+			}
+		}
+	}
+
+	return nil, nil
+}
@@ -103,6 +103,14 @@ func (c *NginxCommonDirChangeCommand) EnableCommonDir(serverName string) error {
 		return err
 	}
 
+	if err := webserver.TestConfig(c.webServer); err != nil {
+		if rErr := c.reverter.Rollback(); rErr != nil {
+			c.logger.Error(fmt.Sprintf("failed to rollback webserver configuration on config test: %v", rErr))
+		}
+
+		return err
+	}
+
 	if err := processManager.Reload(); err != nil {
 		if rErr := c.reverter.Rollback(); rErr != nil {
 			c.logger.Error(fmt.Sprintf("failed to rollback webserver configuration on webserver reload: %v", rErr))
@@ -163,6 +171,14 @@ func (c *NginxCommonDirChangeCommand) DisableCommonDir(serverName string) error
 		return err
 	}
 
+	if err := webserver.TestConfig(c.webServer); err != nil {
+		if rErr := c.reverter.Rollback(); rErr != nil {
+			c.logger.Error(fmt.Sprintf("failed to rollback webserver configuration on config test: %v", rErr))
+		}
+
+		return err
+	}
+
 	if err := processManager.Reload(); err != nil {
 		if rErr := c.reverter.Rollback(); rErr != nil {
 			c.logger.Error(fmt.Sprintf("failed to rollback webserver configuration on webserver reload: %v", rErr))
@@ -12,6 +12,7 @@ import (
 	"github.com/r2dtools/sslbot/internal/dto"
 	"github.com/r2dtools/sslbot/internal/logger"
 	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/hostmng"
 	"github.com/r2dtools/sslbot/internal/webserver/reverter"
 	"github.com/samber/lo"
 	"github.com/unknwon/com"
@@ -87,6 +88,101 @@ func (d *ApacheCertificateDeployer) DeployCertificate(vhost *dto.VirtualHost, ce
 	return sslVHostBlock.FilePath, vHostBlock.FilePath, nil
 }
 
+// UndeployCertificate removes the ssl config file created on deploy. Ssl virtual hosts of other files are removed
+// if the host has a plain virtual host, otherwise the virtual host is kept without ssl directives and moved to 80 port.
+func (d *ApacheCertificateDeployer) UndeployCertificate(vhost *dto.VirtualHost) error {
+	wConfig := d.webServer.Config
+	var sslVHostBlocks []goapacheconf.VirtualHostBlock
+	var plainFilePaths []string
+
+	for _, vHostBlock := range wConfig.FindVirtualHostBlocksByServerName(vhost.ServerName) {
+		if vHostBlock.HasSSL() {
+			sslVHostBlocks = append(sslVHostBlocks, vHostBlock)
+		} else {
+			plainFilePaths = append(plainFilePaths, vHostBlock.FilePath)
+		}
+	}
+
+	if len(sslVHostBlocks) == 0 {
+		return fmt.Errorf("apache host %s has no ssl virtual host", vhost.ServerName)
+	}
+
+	var removedFilePaths []string
+	var changedFilePaths []string
+	// a virtual host moved to 80 port must not duplicate an existing plain host
+	hasPlainHost := len(plainFilePaths) > 0
+
+	for _, vHostBlock := range sslVHostBlocks {
+		if slices.Contains(removedFilePaths, vHostBlock.FilePath) {
+			continue
+		}
+
+		configFile := wConfig.GetConfigFile(filepath.Base(vHostBlock.FilePath))
+
+		if configFile == nil {
+			return fmt.Errorf("apache config file %s not found", vHostBlock.FilePath)
+		}
+
+		isCreated, err := isSslConfigCreated(vHostBlock.FilePath, plainFilePaths)
+
+		if err != nil {
+			return err
+		}
+
+		if isCreated && len(configFile.FindVirtualHostBlocks()) == 1 {
+			if err = removeSslConfig(d.webServer, d.reverter, vHostBlock.FilePath); err != nil {
+				return err
+			}
+
+			removedFilePaths = append(removedFilePaths, vHostBlock.FilePath)
+
+			continue
+		}
+
+		if err = d.reverter.BackupConfig(vHostBlock.FilePath); err != nil {
+			return err
+		}
+
+		if hasPlainHost {
+			configFile.DeleteVirtualHostBlock(vHostBlock)
+		} else {
+			d.stripSsl(vHostBlock)
+			hasPlainHost = true
+		}
+
+		if !slices.Contains(changedFilePaths, vHostBlock.FilePath) {
+			changedFilePaths = append(changedFilePaths, vHostBlock.FilePath)
+		}
+	}
+
+	for _, filePath := range changedFilePaths {
+		if _, err := wConfig.GetConfigFile(filepath.Base(filePath)).Dump(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stripSsl removes ssl directives from the virtual host and changes 443 port of its addresses to 80
+func (d *ApacheCertificateDeployer) stripSsl(vHostBlock goapacheconf.VirtualHostBlock) {
+	var addresses []goapacheconf.Address
+
+	for _, address := range vHostBlock.GetAddresses() {
+		if address.Port == "443" {
+			address = address.GetAddressWithNewPort("80")
+		}
+
+		addresses = append(addresses, address)
+	}
+
+	vHostBlock.SetAddresses(addresses)
+	vHostBlock.DeleteDirectiveByName(goapacheconf.SSLEngine)
+	vHostBlock.DeleteDirectiveByName(goapacheconf.SSLCertificateFile)
+	vHostBlock.DeleteDirectiveByName(goapacheconf.SSLCertificateKeyFile)
+	vHostBlock.DeleteDirectiveByName(goapacheconf.SSLCertificateChainFile)
+}
+
 func (d *ApacheCertificateDeployer) createSslHost(
 	vhost *dto.VirtualHost,
 	vHostBlock goapacheconf.VirtualHostBlock,
@@ -98,12 +194,18 @@ func (d *ApacheCertificateDeployer) createSslHost(
 		return nil, err
 	}
 
-	extension := filepath.Ext(filePath)
-	fileName := strings.TrimSuffix(filepath.Base(filePath), extension)
-	directory := filepath.Dir(filePath)
+	hostManager, err := hostmng.CreateHostManager(d.webServer)
 
-	sslFileName := fmt.Sprintf("%s-ssl%s", fileName, extension)
-	sslFilePath := filepath.Join(directory, sslFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	sslFileName := getSslConfigFileName(filePath)
+	sslFilePath, err := hostManager.GetConfigFilePath(filePath, sslFileName)
+
+	if err != nil {
+		return nil, err
+	}
 
 	if _, err := os.Stat(sslFilePath); errors.Is(err, os.ErrNotExist) {
 		file, err := os.Create(sslFilePath)
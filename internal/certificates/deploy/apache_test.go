@@ -3,6 +3,8 @@
 package deploy
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/r2dtools/sslbot/config"
@@ -119,6 +121,75 @@ func TestApacheGetIpFromListen(t *testing.T) {
 	}
 }
 
+const apacheSslHostConfig = `<VirtualHost *:443>
+    ServerName example.com
+    DocumentRoot /var/www/html
+    SSLEngine on
+    SSLCertificateFile /etc/ssl/example.com.crt
+    SSLCertificateKeyFile /etc/ssl/example.com.key
+    Header always set Strict-Transport-Security "max-age=31536000"
+</VirtualHost>
+`
+
+const apachePlainHostConfig = `<VirtualHost *:80>
+    ServerName example.com
+    DocumentRoot /var/www/html
+</VirtualHost>
+`
+
+func TestApacheUndeployCertificateRemovesSslHost(t *testing.T) {
+	configPath, undeployer := getApacheTempUndeployer(t, apachePlainHostConfig+"\n"+apacheSslHostConfig)
+
+	err := undeployer.UndeployCertificate(&dto.VirtualHost{ServerName: "example.com"})
+	require.Nil(t, err)
+
+	apacheWebServer, err := webserver.GetApacheWebServer(map[string]string{config.ApacheRootOpt: filepath.Dir(configPath)})
+	require.Nil(t, err)
+
+	vHostBlocks := apacheWebServer.Config.FindVirtualHostBlocksByServerName("example.com")
+	require.Len(t, vHostBlocks, 1)
+	assert.False(t, vHostBlocks[0].HasSSL())
+
+	content, err := os.ReadFile(configPath)
+	require.Nil(t, err)
+	assert.NotContains(t, string(content), "Strict-Transport-Security")
+	assert.NotContains(t, string(content), "SSLCertificateFile")
+}
+
+func TestApacheUndeployCertificateFromOnlySslHost(t *testing.T) {
+	configPath, undeployer := getApacheTempUndeployer(t, apacheSslHostConfig)
+
+	err := undeployer.UndeployCertificate(&dto.VirtualHost{ServerName: "example.com"})
+	require.Nil(t, err)
+
+	apacheWebServer, err := webserver.GetApacheWebServer(map[string]string{config.ApacheRootOpt: filepath.Dir(configPath)})
+	require.Nil(t, err)
+
+	vHostBlocks := apacheWebServer.Config.FindVirtualHostBlocksByServerName("example.com")
+	require.Len(t, vHostBlocks, 1)
+	assert.False(t, vHostBlocks[0].HasSSL())
+	assert.Empty(t, vHostBlocks[0].FindDirectives(webserver.ApacheCertDirective))
+	require.Len(t, vHostBlocks[0].GetAddresses(), 1)
+	assert.Equal(t, "80", vHostBlocks[0].GetAddresses()[0].Port)
+}
+
+func getApacheTempUndeployer(t *testing.T, content string) (string, CertificateUndeployer) {
+	configPath := filepath.Join(t.TempDir(), "apache2.conf")
+	require.Nil(t, os.WriteFile(configPath, []byte(content), 0644))
+
+	apacheWebServer, err := webserver.GetApacheWebServer(map[string]string{config.ApacheRootOpt: filepath.Dir(configPath)})
+	require.Nil(t, err)
+
+	log := &logger.TestLogger{T: t}
+	rv, err := reverter.CreateReverter(apacheWebServer, log)
+	require.Nil(t, err)
+
+	undeployer, err := GetCertificateUndeployer(apacheWebServer, rv, log)
+	require.Nil(t, err)
+
+	return configPath, undeployer
+}
+
 func getApacheDeployer(t *testing.T) (*ApacheCertificateDeployer, webserver.ApacheWebServer, reverter.Reverter) {
 	config, err := config.GetConfig()
 	assert.Nil(t, err)
@@ -2,10 +2,14 @@ package deploy
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/r2dtools/sslbot/internal/dto"
 	"github.com/r2dtools/sslbot/internal/logger"
 	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/hostmng"
 	"github.com/r2dtools/sslbot/internal/webserver/reverter"
 )
 
@@ -13,6 +17,17 @@ type CertificateDeployer interface {
 	DeployCertificate(vhost *dto.VirtualHost, certPath, certKeyPath string) (string, string, error)
 }
 
+// CertificateUndeployer removes ssl configuration of the host. The ssl config file created on deploy is removed,
+// otherwise ssl listen and certificate directives are stripped from the host.
+type CertificateUndeployer interface {
+	UndeployCertificate(vhost *dto.VirtualHost) error
+}
+
+// StreamCertificateDeployer deploys certificates to TCP/TLS stream servers
+type StreamCertificateDeployer interface {
+	DeployStreamCertificate(server *dto.StreamServer, certPath, certKeyPath string) (string, error)
+}
+
 func GetCertificateDeployer(webServer webserver.WebServer, reverter reverter.Reverter, logger logger.Logger) (CertificateDeployer, error) {
 	switch w := webServer.(type) {
 	case *webserver.NginxWebServer:
@@ -27,7 +42,121 @@ func GetCertificateDeployer(webServer webserver.WebServer, reverter reverter.Rev
 			webServer: w,
 			reverter:  reverter,
 		}, nil
+	case *webserver.HAProxyWebServer:
+		return &HAProxyCertificateDeployer{
+			logger:    logger,
+			webServer: w,
+			reverter:  reverter,
+		}, nil
+	case *webserver.LighttpdWebServer:
+		return &LighttpdCertificateDeployer{
+			logger:    logger,
+			webServer: w,
+			reverter:  reverter,
+		}, nil
+	case *webserver.TraefikWebServer:
+		return &TraefikCertificateDeployer{
+			logger:    logger,
+			webServer: w,
+			reverter:  reverter,
+		}, nil
 	default:
 		return nil, fmt.Errorf("could not create deployer: webserver '%s' is not supported", webServer.GetCode())
 	}
 }
+
+func GetCertificateUndeployer(webServer webserver.WebServer, reverter reverter.Reverter, logger logger.Logger) (CertificateUndeployer, error) {
+	switch w := webServer.(type) {
+	case *webserver.NginxWebServer:
+		return &NginxCertificateDeployer{
+			logger:    logger,
+			webServer: w,
+			reverter:  reverter,
+		}, nil
+	case *webserver.ApacheWebServer:
+		return &ApacheCertificateDeployer{
+			logger:    logger,
+			webServer: w,
+			reverter:  reverter,
+		}, nil
+	default:
+		return nil, fmt.Errorf("could not create undeployer: webserver '%s' is not supported", webServer.GetCode())
+	}
+}
+
+func GetStreamCertificateDeployer(webServer webserver.WebServer, reverter reverter.Reverter, logger logger.Logger) (StreamCertificateDeployer, error) {
+	switch w := webServer.(type) {
+	case *webserver.NginxWebServer:
+		return &NginxCertificateDeployer{
+			logger:    logger,
+			webServer: w,
+			reverter:  reverter,
+		}, nil
+	default:
+		return nil, fmt.Errorf("could not create deployer: webserver '%s' does not support stream servers", webServer.GetCode())
+	}
+}
+
+// getSslConfigFileName returns the name of the ssl config file created on deploy for the host config file
+func getSslConfigFileName(filePath string) string {
+	extension := filepath.Ext(filePath)
+	fileName := strings.TrimSuffix(filepath.Base(filePath), extension)
+
+	return fmt.Sprintf("%s-ssl%s", fileName, extension)
+}
+
+// isSslConfigCreated checks if the ssl config file was created on deploy for one of the host plain config files
+func isSslConfigCreated(sslFilePath string, plainFilePaths []string) (bool, error) {
+	sslFilePath, err := filepath.EvalSymlinks(sslFilePath)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, plainFilePath := range plainFilePaths {
+		plainFilePath, err := filepath.EvalSymlinks(plainFilePath)
+
+		if err != nil {
+			return false, err
+		}
+
+		if plainFilePath != sslFilePath && filepath.Base(sslFilePath) == getSslConfigFileName(plainFilePath) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// removeSslConfig disables the ssl config created on deploy and removes it. Both are reverted on rollback.
+func removeSslConfig(webServer webserver.WebServer, reverter reverter.Reverter, enabledFilePath string) error {
+	filePath, err := filepath.EvalSymlinks(enabledFilePath)
+
+	if err != nil {
+		return err
+	}
+
+	if err = reverter.BackupConfig(filePath); err != nil {
+		return err
+	}
+
+	if filePath != enabledFilePath {
+		hostManager, err := hostmng.CreateHostManager(webServer)
+
+		if err != nil {
+			return err
+		}
+
+		if err = hostManager.Disable(enabledFilePath); err != nil {
+			return err
+		}
+
+		reverter.AddConfigToEnable(filePath, filepath.Dir(enabledFilePath))
+	}
+
+	if err = os.Remove(filePath); err != nil {
+		return fmt.Errorf("could not remove ssl config file %s: %v", filePath, err)
+	}
+
+	return nil
+}
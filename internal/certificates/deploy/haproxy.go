@@ -0,0 +1,166 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/haproxyconf"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/unknwon/com"
+)
+
+type HAProxyCertificateDeployer struct {
+	logger    logger.Logger
+	webServer *webserver.HAProxyWebServer
+	reverter  reverter.Reverter
+}
+
+// DeployCertificate writes combined PEM (certificate + key) for the host.
+// The PEM is placed into the crt-list of the ssl bind, into its crt directory or added to the bind as a new crt.
+func (d *HAProxyCertificateDeployer) DeployCertificate(vhost *dto.VirtualHost, certPath, certKeyPath string) (string, string, error) {
+	wConfig := d.webServer.Config
+	sections := d.webServer.FindSections(vhost.ServerName)
+
+	if len(sections) == 0 {
+		return "", "", fmt.Errorf("haproxy host %s does not exist", vhost.ServerName)
+	}
+
+	var sslBind *haproxyconf.Bind
+
+	for _, section := range sections {
+		for _, bind := range section.GetBinds() {
+			if bind.Ssl {
+				sslBind = &bind
+
+				break
+			}
+		}
+
+		if sslBind != nil {
+			break
+		}
+	}
+
+	if sslBind == nil {
+		return "", "", fmt.Errorf("haproxy host %s has no ssl bind", vhost.ServerName)
+	}
+
+	content, err := getCombinedPem(certPath, certKeyPath)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(sslBind.CrtLists) > 0 {
+		crtList, err := haproxyconf.GetCrtList(wConfig.ResolvePath(sslBind.CrtLists[0]), wConfig.GetCrtBase())
+
+		if err != nil {
+			return "", "", err
+		}
+
+		pemPath := filepath.Join(filepath.Dir(crtList.FilePath), vhost.ServerName+".pem")
+
+		if err = d.writePem(pemPath, content); err != nil {
+			return "", "", err
+		}
+
+		if err = d.reverter.BackupConfig(crtList.FilePath); err != nil {
+			return "", "", err
+		}
+
+		crtList.SetCertificate(pemPath, append([]string{vhost.ServerName}, vhost.Aliases...))
+
+		if err = crtList.Save(); err != nil {
+			return "", "", err
+		}
+
+		return wConfig.FilePath, wConfig.FilePath, nil
+	}
+
+	for _, crt := range sslBind.Crts {
+		crtDir := wConfig.ResolvePath(crt)
+
+		if !com.IsDir(crtDir) {
+			continue
+		}
+
+		pemPath := filepath.Join(crtDir, vhost.ServerName+".pem")
+
+		// replace the certificate currently used by the host to avoid two certificates for the same name
+		if vhost.CertificatePath != "" && filepath.Dir(vhost.CertificatePath) == filepath.Clean(crtDir) {
+			pemPath = vhost.CertificatePath
+		}
+
+		if err = d.writePem(pemPath, content); err != nil {
+			return "", "", err
+		}
+
+		return wConfig.FilePath, wConfig.FilePath, nil
+	}
+
+	pemDir := filepath.Dir(wConfig.FilePath)
+
+	if len(sslBind.Crts) > 0 {
+		pemDir = filepath.Dir(wConfig.ResolvePath(sslBind.Crts[0]))
+	}
+
+	pemPath := filepath.Join(pemDir, vhost.ServerName+".pem")
+
+	if err = d.writePem(pemPath, content); err != nil {
+		return "", "", err
+	}
+
+	if err = d.reverter.BackupConfig(wConfig.FilePath); err != nil {
+		return "", "", err
+	}
+
+	sslBind.AddCrt(pemPath)
+
+	if err = wConfig.Save(); err != nil {
+		return "", "", err
+	}
+
+	return wConfig.FilePath, wConfig.FilePath, nil
+}
+
+func (d *HAProxyCertificateDeployer) writePem(pemPath string, content []byte) error {
+	if _, err := os.Stat(pemPath); errors.Is(err, os.ErrNotExist) {
+		d.reverter.AddConfigToDeletion(pemPath)
+	} else if err = d.reverter.BackupConfig(pemPath); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(pemPath, content, 0600); err != nil {
+		return fmt.Errorf("could not write haproxy certificate: %v", err)
+	}
+
+	return nil
+}
+
+func getCombinedPem(certPath, certKeyPath string) ([]byte, error) {
+	certContent, err := os.ReadFile(certPath)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read certificate: %v", err)
+	}
+
+	if certKeyPath == "" || certKeyPath == certPath {
+		return certContent, nil
+	}
+
+	keyContent, err := os.ReadFile(certKeyPath)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read certificate key: %v", err)
+	}
+
+	content := strings.TrimRight(string(certContent), "\n") + "\n" + strings.TrimRight(string(keyContent), "\n") + "\n"
+
+	return []byte(content), nil
+}
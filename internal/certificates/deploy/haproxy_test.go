@@ -0,0 +1,101 @@
+//go:build common
+
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHAProxyDeployCertificateToCrtDir(t *testing.T) {
+	dir := t.TempDir()
+	certDir := filepath.Join(dir, "certs")
+	require.Nil(t, os.Mkdir(certDir, 0755))
+
+	deployer, webServer, rv := getHAProxyDeployer(t, dir, fmt.Sprintf("bind :443 ssl crt %s", certDir))
+
+	host, err := webServer.GetVhostByName("example.com")
+	require.Nil(t, err)
+	require.NotNil(t, host)
+	require.Nil(t, host.Certificate)
+
+	configPath, _, err := deployer.DeployCertificate(host, "../../../test/certificate/example.com.crt", "../../../test/certificate/example.com.key")
+	require.Nil(t, err)
+	require.Equal(t, webServer.Config.FilePath, configPath)
+
+	webServer, err = webserver.GetHAProxyWebServer(map[string]string{config.HAProxyConfigOpt: configPath})
+	require.Nil(t, err)
+
+	host, err = webServer.GetVhostByName("example.com")
+	require.Nil(t, err)
+	require.NotNil(t, host.Certificate)
+	require.Equal(t, filepath.Join(certDir, "example.com.pem"), host.CertificatePath)
+	require.Equal(t, host.CertificatePath, host.CertificateKeyPath)
+
+	require.Nil(t, rv.Rollback())
+	require.NoFileExists(t, host.CertificatePath)
+}
+
+func TestHAProxyDeployCertificateToBind(t *testing.T) {
+	dir := t.TempDir()
+	deployer, webServer, rv := getHAProxyDeployer(t, dir, "bind :443 ssl crt default.pem")
+	configContent, err := os.ReadFile(webServer.Config.FilePath)
+	require.Nil(t, err)
+
+	host, err := webServer.GetVhostByName("example.com")
+	require.Nil(t, err)
+	require.NotNil(t, host)
+
+	_, _, err = deployer.DeployCertificate(host, "../../../test/certificate/example.com.pem", "")
+	require.Nil(t, err)
+
+	webServer, err = webserver.GetHAProxyWebServer(map[string]string{config.HAProxyConfigOpt: webServer.Config.FilePath})
+	require.Nil(t, err)
+
+	host, err = webServer.GetVhostByName("example.com")
+	require.Nil(t, err)
+	require.NotNil(t, host.Certificate)
+	require.Equal(t, filepath.Join(dir, "example.com.pem"), host.CertificatePath)
+
+	require.Nil(t, rv.Rollback())
+	content, err := os.ReadFile(webServer.Config.FilePath)
+	require.Nil(t, err)
+	require.Equal(t, string(configContent), string(content))
+}
+
+func TestHAProxyDeployCertificateToNonSslHost(t *testing.T) {
+	deployer, webServer, _ := getHAProxyDeployer(t, t.TempDir(), "bind :80")
+
+	host, err := webServer.GetVhostByName("example.com")
+	require.Nil(t, err)
+	require.NotNil(t, host)
+
+	_, _, err = deployer.DeployCertificate(host, "../../../test/certificate/example.com.pem", "")
+	require.NotNil(t, err)
+}
+
+func getHAProxyDeployer(t *testing.T, dir, bind string) (CertificateDeployer, *webserver.HAProxyWebServer, reverter.Reverter) {
+	configPath := filepath.Join(dir, "haproxy.cfg")
+	content := fmt.Sprintf("global\n    crt-base %s\n\nfrontend web\n    %s\n    acl example hdr(host) -i example.com\n    use_backend app if example\n", dir, bind)
+	require.Nil(t, os.WriteFile(configPath, []byte(content), 0644))
+
+	webServer, err := webserver.GetHAProxyWebServer(map[string]string{config.HAProxyConfigOpt: configPath})
+	require.Nil(t, err)
+
+	log := &logger.TestLogger{T: t}
+	rv, err := reverter.CreateReverter(webServer, log)
+	require.Nil(t, err)
+
+	deployer, err := GetCertificateDeployer(webServer, rv, log)
+	require.Nil(t, err)
+
+	return deployer, webServer, rv
+}
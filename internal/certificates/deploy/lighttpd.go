@@ -0,0 +1,119 @@
+package deploy
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/lighttpdconf"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+)
+
+const lighttpdSslPort = "443"
+
+type LighttpdCertificateDeployer struct {
+	logger    logger.Logger
+	webServer *webserver.LighttpdWebServer
+	reverter  reverter.Reverter
+}
+
+// DeployCertificate sets ssl.pemfile/ssl.privkey for the host inside $SERVER["socket"] == ":443" block.
+// The socket block is created if it does not exist.
+func (d *LighttpdCertificateDeployer) DeployCertificate(vhost *dto.VirtualHost, certPath, certKeyPath string) (string, string, error) {
+	wConfig := d.webServer.Config
+	hosts := d.webServer.FindHosts(vhost.ServerName)
+
+	if len(hosts) == 0 {
+		return "", "", fmt.Errorf("lighttpd host %s does not exist", vhost.ServerName)
+	}
+
+	certPath, err := filepath.Abs(certPath)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	certKeyPath, err = filepath.Abs(certKeyPath)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	sslSockets := d.webServer.FindSockets(lighttpdSslPort)
+	var sslBlock, socket *lighttpdconf.Entry
+
+	for _, host := range hosts {
+		if host.Socket != nil && slices.Contains(sslSockets, host.Socket) {
+			sslBlock = host.Block
+			socket = host.Socket
+
+			break
+		}
+	}
+
+	if sslBlock == nil && len(sslSockets) > 0 {
+		socket = sslSockets[0]
+
+		// ssl.pemfile in a top level host block is selected by SNI as well
+		for _, host := range hosts {
+			if host.Socket == nil && len(host.Block.FindStatements(webserver.LighttpdCertDirective)) > 0 {
+				sslBlock = host.Block
+
+				break
+			}
+		}
+
+		if sslBlock == nil {
+			sslBlock = socket.AddBlock(webserver.LighttpdHostVariable, "==", vhost.ServerName)
+		}
+	}
+
+	if sslBlock == nil {
+		file := hosts[0].Block.File
+		socket = file.Root.AddBlock(webserver.LighttpdSocketVariable, "==", ":"+lighttpdSslPort)
+		sslBlock = socket
+
+		if !d.isModuleEnabled("mod_openssl") {
+			file.Root.AddStatement("server.modules", "+=", lighttpdconf.FormatArray([]lighttpdconf.ArrayItem{{Value: "mod_openssl"}}))
+		}
+	}
+
+	if !d.webServer.IsSslEnabled(socket) {
+		socket.SetStatement(webserver.LighttpdSslEngineDirective, lighttpdconf.Quote("enable"))
+	}
+
+	sslBlock.SetStatement(webserver.LighttpdCertDirective, lighttpdconf.Quote(certPath))
+	sslBlock.SetStatement(webserver.LighttpdCertKeyDirective, lighttpdconf.Quote(certKeyPath))
+
+	for _, file := range wConfig.Files {
+		if !file.IsDirty() {
+			continue
+		}
+
+		if err := d.reverter.BackupConfig(file.Path); err != nil {
+			return "", "", err
+		}
+	}
+
+	if err := wConfig.Save(); err != nil {
+		return "", "", err
+	}
+
+	return sslBlock.File.Path, sslBlock.File.Path, nil
+}
+
+func (d *LighttpdCertificateDeployer) isModuleEnabled(module string) bool {
+	var enabled bool
+
+	d.webServer.Config.Walk(func(entry *lighttpdconf.Entry, parents []*lighttpdconf.Entry) {
+		if entry.Type == lighttpdconf.EntryStatement && entry.Key == "server.modules" && strings.Contains(entry.Value, module) {
+			enabled = true
+		}
+	})
+
+	return enabled
+}
@@ -0,0 +1,103 @@
+//go:build common
+
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/stretchr/testify/require"
+)
+
+const lighttpdTestConfig = `server.port = 80
+server.document-root = "/var/www/html"
+
+$HTTP["host"] == "example.com" {
+	server.document-root = "/var/www/example.com"
+}
+`
+
+func TestLighttpdDeployCertificateToNonSslHost(t *testing.T) {
+	deployer, webServer, rv := getLighttpdDeployer(t, lighttpdTestConfig)
+
+	host, err := webServer.GetVhostByName("example.com")
+	require.Nil(t, err)
+	require.NotNil(t, host)
+	require.False(t, host.Ssl)
+
+	certPath, err := filepath.Abs("../../../test/certificate/example.com.crt")
+	require.Nil(t, err)
+
+	configPath, _, err := deployer.DeployCertificate(host, certPath, "../../../test/certificate/example.com.key")
+	require.Nil(t, err)
+	require.Equal(t, webServer.Config.FilePath, configPath)
+
+	webServer, err = webserver.GetLighttpdWebServer(map[string]string{config.LighttpdConfigOpt: configPath})
+	require.Nil(t, err)
+
+	host, err = webServer.GetVhostByName("example.com")
+	require.Nil(t, err)
+	require.True(t, host.Ssl)
+	require.NotNil(t, host.Certificate)
+	require.Equal(t, certPath, host.CertificatePath)
+	require.Equal(t, "/var/www/example.com", host.DocRoot)
+
+	require.Nil(t, rv.Rollback())
+	content, err := os.ReadFile(configPath)
+	require.Nil(t, err)
+	require.Equal(t, lighttpdTestConfig, string(content))
+}
+
+func TestLighttpdDeployCertificateToSslSocket(t *testing.T) {
+	content := lighttpdTestConfig + `
+$SERVER["socket"] == ":443" {
+	ssl.engine = "enable"
+	ssl.pemfile = "/etc/lighttpd/default.pem"
+}
+`
+	deployer, webServer, _ := getLighttpdDeployer(t, content)
+
+	host, err := webServer.GetVhostByName("example.com")
+	require.Nil(t, err)
+	require.True(t, host.Ssl)
+	require.Nil(t, host.Certificate)
+
+	certPath, err := filepath.Abs("../../../test/certificate/example.com.crt")
+	require.Nil(t, err)
+
+	configPath, _, err := deployer.DeployCertificate(host, certPath, "../../../test/certificate/example.com.key")
+	require.Nil(t, err)
+
+	webServer, err = webserver.GetLighttpdWebServer(map[string]string{config.LighttpdConfigOpt: configPath})
+	require.Nil(t, err)
+
+	hosts := webServer.FindHosts("example.com")
+	require.Len(t, hosts, 2)
+	require.NotNil(t, hosts[1].Socket)
+
+	host, err = webServer.GetVhostByName("example.com")
+	require.Nil(t, err)
+	require.Equal(t, certPath, host.CertificatePath)
+}
+
+func getLighttpdDeployer(t *testing.T, content string) (CertificateDeployer, *webserver.LighttpdWebServer, reverter.Reverter) {
+	configPath := filepath.Join(t.TempDir(), "lighttpd.conf")
+	require.Nil(t, os.WriteFile(configPath, []byte(content), 0644))
+
+	webServer, err := webserver.GetLighttpdWebServer(map[string]string{config.LighttpdConfigOpt: configPath})
+	require.Nil(t, err)
+
+	log := &logger.TestLogger{T: t}
+	rv, err := reverter.CreateReverter(webServer, log)
+	require.Nil(t, err)
+
+	deployer, err := GetCertificateDeployer(webServer, rv, log)
+	require.Nil(t, err)
+
+	return deployer, webServer, rv
+}
@@ -5,12 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
+	"slices"
 
 	nginxConfig "github.com/r2dtools/gonginxconf/config"
 	"github.com/r2dtools/sslbot/internal/dto"
 	"github.com/r2dtools/sslbot/internal/logger"
 	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/hostmng"
 	"github.com/r2dtools/sslbot/internal/webserver/reverter"
 )
 
@@ -22,7 +23,7 @@ type NginxCertificateDeployer struct {
 
 func (d *NginxCertificateDeployer) DeployCertificate(vhost *dto.VirtualHost, certPath, certKeyPath string) (string, string, error) {
 	wConfig := d.webServer.Config
-	serverBlocks := wConfig.FindServerBlocksByServerName(vhost.ServerName)
+	serverBlocks := d.webServer.FindServerBlocksByServerName(vhost.ServerName)
 
 	if len(serverBlocks) == 0 {
 		return "", "", fmt.Errorf("nginx host %s does not exixst", vhost.ServerName)
@@ -75,6 +76,134 @@ func (d *NginxCertificateDeployer) DeployCertificate(vhost *dto.VirtualHost, cer
 	return sslServerBlock.FilePath, serverBlock.FilePath, nil
 }
 
+// UndeployCertificate removes the ssl config file created on deploy. Ssl server blocks of other files are removed
+// if the host has a plain server block, otherwise the server block is kept without ssl listens and certificate directives.
+func (d *NginxCertificateDeployer) UndeployCertificate(vhost *dto.VirtualHost) error {
+	var sslServerBlocks []nginxConfig.ServerBlock
+	var plainFilePaths []string
+
+	for _, serverBlock := range d.webServer.FindServerBlocksByServerName(vhost.ServerName) {
+		if serverBlock.HasSSL() {
+			sslServerBlocks = append(sslServerBlocks, serverBlock)
+		} else {
+			plainFilePaths = append(plainFilePaths, serverBlock.FilePath)
+		}
+	}
+
+	if len(sslServerBlocks) == 0 {
+		return fmt.Errorf("nginx host %s has no ssl server block", vhost.ServerName)
+	}
+
+	// a server block converted to plain one must not duplicate an existing plain host
+	hasPlainHost := len(plainFilePaths) > 0 || slices.ContainsFunc(sslServerBlocks, hasNginxPlainListen)
+
+	var removedFilePaths []string
+	var changedFilePaths []string
+
+	for _, serverBlock := range sslServerBlocks {
+		if slices.Contains(removedFilePaths, serverBlock.FilePath) {
+			continue
+		}
+
+		configFile := d.webServer.Config.GetConfigFile(filepath.Base(serverBlock.FilePath))
+
+		if configFile == nil {
+			return fmt.Errorf("nginx config file %s not found", serverBlock.FilePath)
+		}
+
+		isCreated, err := isSslConfigCreated(serverBlock.FilePath, plainFilePaths)
+
+		if err != nil {
+			return err
+		}
+
+		if isCreated && len(configFile.FindServerBlocks()) == 1 {
+			if err = removeSslConfig(d.webServer, d.reverter, serverBlock.FilePath); err != nil {
+				return err
+			}
+
+			removedFilePaths = append(removedFilePaths, serverBlock.FilePath)
+
+			continue
+		}
+
+		if err = d.reverter.BackupConfig(serverBlock.FilePath); err != nil {
+			return err
+		}
+
+		if hasPlainHost && !hasNginxPlainListen(serverBlock) {
+			deleteNginxServerBlock(configFile, serverBlock)
+		} else {
+			d.stripSsl(serverBlock)
+			hasPlainHost = true
+		}
+
+		if !slices.Contains(changedFilePaths, serverBlock.FilePath) {
+			changedFilePaths = append(changedFilePaths, serverBlock.FilePath)
+		}
+	}
+
+	for _, filePath := range changedFilePaths {
+		if err := d.webServer.Config.GetConfigFile(filepath.Base(filePath)).Dump(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeployStreamCertificate sets the certificate of the stream server. ssl parameter is added to listen directives if missing.
+func (d *NginxCertificateDeployer) DeployStreamCertificate(server *dto.StreamServer, certPath, certKeyPath string) (string, error) {
+	serverBlock := d.webServer.FindStreamServerBlock(server.Key)
+
+	if serverBlock == nil {
+		return "", fmt.Errorf("nginx stream server %s does not exist", server.Key)
+	}
+
+	certPath, err := filepath.Abs(certPath)
+
+	if err != nil {
+		return "", err
+	}
+
+	certKeyPath, err = filepath.Abs(certKeyPath)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err = d.reverter.BackupConfig(serverBlock.FilePath); err != nil {
+		return "", err
+	}
+
+	if !serverBlock.HasSSL() {
+		for _, listenDirective := range serverBlock.FindDirectives("listen") {
+			values := listenDirective.GetValues()
+
+			if len(values) == 0 {
+				continue
+			}
+
+			listenDirective.SetValues(append([]string{values[0], "ssl"}, values[1:]...))
+		}
+	}
+
+	d.createOrUpdateSingleDirective(serverBlock, webserver.NginxCertKeyDirective, certKeyPath)
+	d.createOrUpdateSingleDirective(serverBlock, webserver.NginxCertDirective, certPath)
+
+	configFile := d.webServer.Config.GetConfigFile(filepath.Base(serverBlock.FilePath))
+
+	if configFile == nil {
+		return "", fmt.Errorf("nginx config file %s not found", serverBlock.FilePath)
+	}
+
+	if err = configFile.Dump(); err != nil {
+		return "", err
+	}
+
+	return serverBlock.FilePath, nil
+}
+
 func (d *NginxCertificateDeployer) createSslHost(
 	vhost *dto.VirtualHost,
 	serverBlock nginxConfig.ServerBlock,
@@ -87,12 +216,18 @@ func (d *NginxCertificateDeployer) createSslHost(
 		return nil, err
 	}
 
-	extension := filepath.Ext(filePath)
-	fileName := strings.TrimSuffix(filepath.Base(filePath), extension)
-	directory := filepath.Dir(filePath)
+	hostManager, err := hostmng.CreateHostManager(d.webServer)
 
-	sslFileName := fmt.Sprintf("%s-ssl%s", fileName, extension)
-	sslFilePath := filepath.Join(directory, sslFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	sslFileName := getSslConfigFileName(filePath)
+	sslFilePath, err := hostManager.GetConfigFilePath(filePath, sslFileName)
+
+	if err != nil {
+		return nil, err
+	}
 
 	if _, err := os.Stat(sslFilePath); errors.Is(err, os.ErrNotExist) {
 		file, err := os.Create(sslFilePath)
@@ -141,6 +276,65 @@ func (d *NginxCertificateDeployer) createSslHost(
 	return nil, fmt.Errorf("config file already exists %s", filePath)
 }
 
+// stripSsl removes ssl listens and certificate directives from the server block.
+// The server block listens on 80 port if no plain listens are left.
+func (d *NginxCertificateDeployer) stripSsl(serverBlock nginxConfig.ServerBlock) {
+	isIpv4Enabled := serverBlock.IsIpv4Enabled()
+	isIpv6Enabled := serverBlock.IsIpv6Enabled()
+	serverSsl := slices.ContainsFunc(serverBlock.FindDirectives("ssl"), func(directive nginxConfig.Directive) bool {
+		return directive.GetFirstValue() == "on"
+	})
+	hasPlainListen := false
+
+	for _, listenDirective := range serverBlock.FindDirectives("listen") {
+		if serverSsl || slices.Contains(listenDirective.GetValues(), "ssl") {
+			serverBlock.DeleteDirective(listenDirective)
+		} else {
+			hasPlainListen = true
+		}
+	}
+
+	serverBlock.DeleteDirectiveByName("ssl")
+	serverBlock.DeleteDirectiveByName(webserver.NginxCertDirective)
+	serverBlock.DeleteDirectiveByName(webserver.NginxCertKeyDirective)
+
+	if hasPlainListen {
+		return
+	}
+
+	if isIpv6Enabled {
+		serverBlock.AddDirective(nginxConfig.NewDirective("listen", []string{"[::]:80"}), true, true)
+	}
+
+	if isIpv4Enabled {
+		serverBlock.AddDirective(nginxConfig.NewDirective("listen", []string{"80"}), true, false)
+	}
+}
+
+// hasNginxPlainListen checks if the server block listens on a port without ssl
+func hasNginxPlainListen(serverBlock nginxConfig.ServerBlock) bool {
+	serverSsl := slices.ContainsFunc(serverBlock.FindDirectives("ssl"), func(directive nginxConfig.Directive) bool {
+		return directive.GetFirstValue() == "on"
+	})
+
+	if serverSsl {
+		return false
+	}
+
+	return slices.ContainsFunc(serverBlock.FindDirectives("listen"), func(directive nginxConfig.Directive) bool {
+		return !slices.Contains(directive.GetValues(), "ssl")
+	})
+}
+
+// deleteNginxServerBlock removes the server block from the top level of the config file or from its http block
+func deleteNginxServerBlock(configFile *nginxConfig.ConfigFile, serverBlock nginxConfig.ServerBlock) {
+	configFile.DeleteServerBlock(serverBlock)
+
+	for _, httpBlock := range configFile.FindHttpBlocks() {
+		httpBlock.DeleteServerBlock(serverBlock)
+	}
+}
+
 func (d *NginxCertificateDeployer) createOrUpdateSingleDirective(block *nginxConfig.ServerBlock, name, value string) {
 	directives := block.FindDirectives(name)
 
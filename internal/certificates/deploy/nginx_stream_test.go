@@ -0,0 +1,160 @@
+//go:build common
+
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/stretchr/testify/require"
+)
+
+const nginxStreamConfig = `events {}
+
+http {
+    server {
+        listen 80;
+        server_name example.com;
+    }
+}
+
+stream {
+    include streams/*.conf;
+
+    server {
+        listen 8883;
+        server_name mqtt;
+        proxy_pass 127.0.0.1:1883;
+    }
+}
+`
+
+const nginxStreamIncludeConfig = `server {
+    listen 5432;
+    proxy_pass 127.0.0.1:5433;
+}
+`
+
+func TestNginxDeployStreamCertificate(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "streams"), 0755))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(nginxStreamConfig), 0644))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "streams", "db.conf"), []byte(nginxStreamIncludeConfig), 0644))
+
+	options := map[string]string{config.NginxRootOpt: dir}
+	webServer, err := webserver.GetNginxWebServer(options)
+	require.Nil(t, err)
+
+	vhosts, err := webServer.GetVhosts()
+	require.Nil(t, err)
+	require.Len(t, vhosts, 1)
+	require.Equal(t, "example.com", vhosts[0].ServerName)
+
+	servers, err := webServer.GetStreamServers()
+	require.Nil(t, err)
+	require.Len(t, servers, 2)
+
+	server, err := webServer.GetStreamServer("mqtt")
+	require.Nil(t, err)
+	require.NotNil(t, server)
+	require.Equal(t, "8883", server.Key)
+	require.False(t, server.Ssl)
+
+	server, err = webServer.GetStreamServer("5432")
+	require.Nil(t, err)
+	require.NotNil(t, server)
+	require.Equal(t, filepath.Join(dir, "streams", "db.conf"), server.FilePath)
+
+	log := &logger.TestLogger{T: t}
+	rv, err := reverter.CreateReverter(webServer, log)
+	require.Nil(t, err)
+
+	deployer, err := GetStreamCertificateDeployer(webServer, rv, log)
+	require.Nil(t, err)
+
+	filePath, err := deployer.DeployStreamCertificate(server, "../../../test/certificate/example.com.crt", "../../../test/certificate/example.com.key")
+	require.Nil(t, err)
+	require.Equal(t, server.FilePath, filePath)
+
+	webServer, err = webserver.GetNginxWebServer(options)
+	require.Nil(t, err)
+
+	server, err = webServer.GetStreamServer("5432")
+	require.Nil(t, err)
+	require.True(t, server.Ssl)
+	require.NotNil(t, server.Certificate)
+
+	require.Nil(t, rv.Rollback())
+
+	content, err := os.ReadFile(filePath)
+	require.Nil(t, err)
+	require.Equal(t, nginxStreamIncludeConfig, string(content))
+}
+
+const nginxStreamSamePortConfig = `events {}
+
+stream {
+    server {
+        listen 127.0.0.1:5432;
+        proxy_pass 127.0.0.1:5433;
+    }
+
+    server {
+        listen 127.0.0.2:5432;
+        proxy_pass 127.0.0.1:5434;
+    }
+
+    server {
+        listen 6432;
+        proxy_pass 127.0.0.1:6433;
+    }
+}
+`
+
+func TestNginxDeployStreamCertificateSamePort(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(nginxStreamSamePortConfig), 0644))
+
+	options := map[string]string{config.NginxRootOpt: dir}
+	webServer, err := webserver.GetNginxWebServer(options)
+	require.Nil(t, err)
+
+	servers, err := webServer.GetStreamServers()
+	require.Nil(t, err)
+	require.Len(t, servers, 3)
+	require.Equal(t, "127.0.0.1:5432", servers[0].Key)
+	require.Equal(t, "127.0.0.2:5432", servers[1].Key)
+	require.Equal(t, "6432", servers[2].Key)
+
+	server, err := webServer.GetStreamServer("127.0.0.2:5432")
+	require.Nil(t, err)
+	require.NotNil(t, server)
+
+	log := &logger.TestLogger{T: t}
+	rv, err := reverter.CreateReverter(webServer, log)
+	require.Nil(t, err)
+
+	deployer, err := GetStreamCertificateDeployer(webServer, rv, log)
+	require.Nil(t, err)
+
+	_, err = deployer.DeployStreamCertificate(server, "../../../test/certificate/example.com.crt", "../../../test/certificate/example.com.key")
+	require.Nil(t, err)
+
+	webServer, err = webserver.GetNginxWebServer(options)
+	require.Nil(t, err)
+
+	server, err = webServer.GetStreamServer("127.0.0.1:5432")
+	require.Nil(t, err)
+	require.False(t, server.Ssl)
+
+	server, err = webServer.GetStreamServer("127.0.0.2:5432")
+	require.Nil(t, err)
+	require.True(t, server.Ssl)
+
+	require.Nil(t, rv.Rollback())
+}
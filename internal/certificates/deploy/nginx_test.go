@@ -3,14 +3,18 @@
 package deploy
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/r2dtools/sslbot/config"
 	"github.com/r2dtools/sslbot/internal/dto"
 	"github.com/r2dtools/sslbot/internal/logger"
 	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/hostmng"
 	"github.com/r2dtools/sslbot/internal/webserver/reverter"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNginxDeployCertificateToNonSslHost(t *testing.T) {
@@ -58,6 +62,159 @@ func TestNginxDeployCertificateToSslHost(t *testing.T) {
 	assert.True(t, host.Ssl)
 }
 
+func TestNginxUndeployCertificateFromCreatedSslHost(t *testing.T) {
+	deployer, nginxWebServer, rv := getNginxDeployer(t)
+	defer rv.Rollback()
+
+	hosts, err := nginxWebServer.GetVhosts()
+	assert.Nilf(t, err, "get nginx hosts error: %v", err)
+
+	host := findHost("example3.com", hosts)
+	assert.NotNil(t, host)
+
+	configPath, originConfigPath, err := deployer.DeployCertificate(host, "test/certificate/example.com.crt", "test/certificate/example.com.key")
+	assert.Nilf(t, err, "deploy certificate error: %v", err)
+
+	hostManager, err := hostmng.CreateHostManager(&nginxWebServer)
+	assert.Nil(t, err)
+
+	enabledConfigPath, err := hostManager.Enable(configPath, filepath.Dir(originConfigPath))
+	assert.Nil(t, err)
+	rv.AddConfigToDisable(enabledConfigPath)
+
+	undeployer, _, urv := getNginxUndeployer(t)
+	err = undeployer.UndeployCertificate(host)
+	assert.Nilf(t, err, "undeploy certificate error: %v", err)
+	assert.NoFileExists(t, configPath)
+	assert.NoFileExists(t, enabledConfigPath)
+
+	_, uNginxWebServer, _ := getNginxUndeployer(t)
+	serverBlocks := uNginxWebServer.FindServerBlocksByServerName("example3.com")
+	assert.Len(t, serverBlocks, 1)
+	assert.False(t, serverBlocks[0].HasSSL())
+
+	err = urv.Rollback()
+	assert.Nil(t, err)
+	assert.FileExists(t, configPath)
+	assert.FileExists(t, enabledConfigPath)
+}
+
+func TestNginxUndeployCertificateFromSslHost(t *testing.T) {
+	undeployer, nginxWebServer, rv := getNginxUndeployer(t)
+	defer rv.Rollback()
+
+	hosts, err := nginxWebServer.GetVhosts()
+	assert.Nilf(t, err, "get nginx hosts error: %v", err)
+
+	host := findHost("example2.com", hosts)
+	assert.NotNil(t, host)
+	assert.True(t, host.Ssl)
+
+	err = undeployer.UndeployCertificate(host)
+	assert.Nilf(t, err, "undeploy certificate error: %v", err)
+	assert.FileExists(t, "/etc/nginx/sites-available/example2.com.conf")
+
+	serverBlocks := nginxWebServer.FindServerBlocksByServerName("example2.com")
+	assert.Len(t, serverBlocks, 1)
+	assert.False(t, serverBlocks[0].HasSSL())
+	assert.Empty(t, serverBlocks[0].FindDirectives(webserver.NginxCertDirective))
+	assert.Empty(t, serverBlocks[0].FindDirectives(webserver.NginxCertKeyDirective))
+
+	err = undeployer.UndeployCertificate(host)
+	assert.ErrorContains(t, err, "has no ssl server block")
+}
+
+const nginxPlainAndSslHostConfig = `events {}
+
+http {
+    server {
+        listen 80;
+        server_name example.com;
+    }
+
+    server {
+        listen 443 ssl;
+        server_name example.com;
+        ssl_certificate /etc/ssl/example.com.crt;
+        ssl_certificate_key /etc/ssl/example.com.key;
+        add_header Strict-Transport-Security "max-age=31536000" always;
+    }
+}
+`
+
+func TestNginxUndeployCertificateRemovesSslHost(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "nginx.conf")
+	require.Nil(t, os.WriteFile(configPath, []byte(nginxPlainAndSslHostConfig), 0644))
+
+	options := map[string]string{config.NginxRootOpt: dir}
+	nginxWebServer, err := webserver.GetNginxWebServer(options)
+	require.Nil(t, err)
+
+	log := &logger.TestLogger{T: t}
+	rv, err := reverter.CreateReverter(nginxWebServer, log)
+	require.Nil(t, err)
+
+	undeployer, err := GetCertificateUndeployer(nginxWebServer, rv, log)
+	require.Nil(t, err)
+	require.Nil(t, undeployer.UndeployCertificate(&dto.VirtualHost{ServerName: "example.com"}))
+
+	nginxWebServer, err = webserver.GetNginxWebServer(options)
+	require.Nil(t, err)
+
+	serverBlocks := nginxWebServer.FindServerBlocksByServerName("example.com")
+	require.Len(t, serverBlocks, 1)
+	assert.False(t, serverBlocks[0].HasSSL())
+
+	content, err := os.ReadFile(configPath)
+	require.Nil(t, err)
+	assert.NotContains(t, string(content), "Strict-Transport-Security")
+}
+
+func TestNginxDeployCertificateDryRun(t *testing.T) {
+	config, err := config.GetConfig()
+	assert.Nil(t, err)
+	nginxWebServer, err := webserver.GetNginxWebServer(config.ToMap())
+	assert.Nil(t, err)
+
+	sandbox, err := webserver.CreateSandbox(nginxWebServer)
+	assert.Nil(t, err)
+	defer sandbox.Remove()
+
+	sWebServer, err := sandbox.CreateWebServer()
+	assert.Nil(t, err)
+
+	log := &logger.TestLogger{T: t}
+	rv, err := reverter.CreateReverter(sWebServer, log)
+	assert.Nil(t, err)
+
+	deployer, err := GetCertificateDeployer(sWebServer, rv, log)
+	assert.Nil(t, err)
+
+	host, err := sWebServer.GetVhostByName("example3.com")
+	assert.Nil(t, err)
+	assert.NotNil(t, host)
+
+	configPath, originConfigPath, err := deployer.DeployCertificate(host, "test/certificate/example.com.crt", "test/certificate/example.com.key")
+	assert.Nilf(t, err, "deploy certificate error: %v", err)
+
+	hostManager, err := hostmng.CreateHostManager(sWebServer)
+	assert.Nil(t, err)
+	_, err = hostManager.Enable(configPath, filepath.Dir(originConfigPath))
+	assert.Nil(t, err)
+
+	diffs, err := sandbox.GetDiffs()
+	assert.Nil(t, err)
+	assert.Len(t, diffs, 2)
+	assert.Equal(t, "/etc/nginx/sites-available/example3.com-ssl.conf", diffs[0].FilePath)
+	assert.Contains(t, diffs[0].Diff, "--- /dev/null\n")
+	assert.Contains(t, diffs[0].Diff, "test/certificate/example.com.crt;")
+	assert.Equal(t, "/etc/nginx/sites-enabled/example3.com-ssl.conf", diffs[1].FilePath)
+	assert.True(t, diffs[1].Symlink)
+	assert.NoFileExists(t, "/etc/nginx/sites-available/example3.com-ssl.conf")
+	assert.NoFileExists(t, "/etc/nginx/sites-enabled/example3.com-ssl.conf")
+}
+
 func getNginxDeployer(t *testing.T) (CertificateDeployer, webserver.NginxWebServer, reverter.Reverter) {
 	config, err := config.GetConfig()
 	assert.Nil(t, err)
@@ -74,6 +231,22 @@ func getNginxDeployer(t *testing.T) (CertificateDeployer, webserver.NginxWebServ
 	return deployer, *nginxWebServer, rv
 }
 
+func getNginxUndeployer(t *testing.T) (CertificateUndeployer, *webserver.NginxWebServer, reverter.Reverter) {
+	config, err := config.GetConfig()
+	assert.Nil(t, err)
+	nginxWebServer, err := webserver.GetNginxWebServer(config.ToMap())
+	assert.Nil(t, err)
+
+	log := &logger.TestLogger{T: t}
+	rv, err := reverter.CreateReverter(nginxWebServer, log)
+	assert.Nil(t, err)
+
+	undeployer, err := GetCertificateUndeployer(nginxWebServer, rv, log)
+	assert.Nil(t, err)
+
+	return undeployer, nginxWebServer, rv
+}
+
 func findHost(servername string, hosts []dto.VirtualHost) *dto.VirtualHost {
 	for _, host := range hosts {
 		if host.ServerName == servername {
@@ -0,0 +1,90 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/utils"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/r2dtools/sslbot/internal/webserver/traefikconf"
+)
+
+type TraefikCertificateDeployer struct {
+	logger    logger.Logger
+	webServer *webserver.TraefikWebServer
+	reverter  reverter.Reverter
+}
+
+// DeployCertificate adds the certificate to tls.certificates of the dynamic config.
+// Certificates of the file whose names are all covered by the new certificate are replaced.
+func (d *TraefikCertificateDeployer) DeployCertificate(vhost *dto.VirtualHost, certPath, certKeyPath string) (string, string, error) {
+	cert, err := utils.GetCertificateFromFile(certPath)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	certPath, err = filepath.Abs(certPath)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	certKeyPath, err = filepath.Abs(certKeyPath)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	filePath := d.webServer.GetCertificatesFilePath()
+	var certificates []traefikconf.Certificate
+
+	for _, certificate := range d.webServer.Config.GetFileCertificates(filePath) {
+		if certificate.CertFile == certPath || isCertificateCovered(certificate.CertFile, cert) {
+			continue
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	certificates = append(certificates, traefikconf.Certificate{CertFile: certPath, KeyFile: certKeyPath})
+
+	if _, err := os.Stat(filePath); errors.Is(err, os.ErrNotExist) {
+		d.reverter.AddConfigToDeletion(filePath)
+	} else if err = d.reverter.BackupConfig(filePath); err != nil {
+		return "", "", err
+	}
+
+	if err = traefikconf.WriteCertificates(filePath, certificates); err != nil {
+		return "", "", fmt.Errorf("could not write traefik certificates: %v", err)
+	}
+
+	return filePath, filePath, nil
+}
+
+func isCertificateCovered(certPath string, cert *dto.Certificate) bool {
+	oldCert, err := utils.GetCertificateFromFile(certPath)
+
+	if err != nil {
+		return false
+	}
+
+	names := oldCert.DNSNames
+
+	if len(names) == 0 {
+		names = []string{oldCert.CN}
+	}
+
+	for _, name := range names {
+		if !utils.IsCertificateForDomain(cert, name) {
+			return false
+		}
+	}
+
+	return true
+}
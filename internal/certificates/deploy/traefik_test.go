@@ -0,0 +1,59 @@
+//go:build common
+
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraefikDeployCertificate(t *testing.T) {
+	dir := t.TempDir()
+	routers := "http:\n  routers:\n    app:\n      rule: Host(`example.com`)\n      tls: {}\n"
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "app.yml"), []byte(routers), 0644))
+
+	options := map[string]string{config.TraefikDynamicConfigOpt: dir}
+	webServer, err := webserver.GetTraefikWebServer(options)
+	require.Nil(t, err)
+
+	log := &logger.TestLogger{T: t}
+	rv, err := reverter.CreateReverter(webServer, log)
+	require.Nil(t, err)
+
+	deployer, err := GetCertificateDeployer(webServer, rv, log)
+	require.Nil(t, err)
+
+	host, err := webServer.GetVhostByName("example.com")
+	require.Nil(t, err)
+	require.NotNil(t, host)
+	require.Nil(t, host.Certificate)
+
+	filePath, _, err := deployer.DeployCertificate(host, "../../../test/certificate/example.com.crt", "../../../test/certificate/example.com.key")
+	require.Nil(t, err)
+	require.Equal(t, filepath.Join(dir, webserver.TraefikCertificatesFileName), filePath)
+
+	webServer, err = webserver.GetTraefikWebServer(options)
+	require.Nil(t, err)
+
+	host, err = webServer.GetVhostByName("example.com")
+	require.Nil(t, err)
+	require.NotNil(t, host.Certificate)
+
+	// the same certificate is not duplicated
+	_, _, err = deployer.DeployCertificate(host, "../../../test/certificate/example.com.crt", "../../../test/certificate/example.com.key")
+	require.Nil(t, err)
+
+	webServer, err = webserver.GetTraefikWebServer(options)
+	require.Nil(t, err)
+	require.Len(t, webServer.Config.GetCertificates(), 1)
+
+	require.Nil(t, rv.Rollback())
+	require.NoFileExists(t, filePath)
+}
@@ -0,0 +1,145 @@
+package hsts
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/goapacheconf"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+)
+
+const apacheHeader = "Header"
+
+type ApacheHstsQuery struct {
+	webServer *webserver.ApacheWebServer
+}
+
+func (q *ApacheHstsQuery) GetHstsStatus(serverName string) Hsts {
+	for _, vHostBlock := range q.webServer.FindSslVirtualHostBlocksByServerName(serverName) {
+		for _, directive := range findApacheHstsDirectives(vHostBlock) {
+			values := directive.GetValues()
+
+			return ParseHeaderValue(values[len(values)-1])
+		}
+	}
+
+	return Hsts{}
+}
+
+type ApacheHstsChangeCommand struct {
+	webServer *webserver.ApacheWebServer
+	reverter  reverter.Reverter
+	logger    logger.Logger
+	mx        *sync.Mutex
+}
+
+// EnableHsts sets "Header always set Strict-Transport-Security" in all ssl virtual hosts of the host
+func (c *ApacheHstsChangeCommand) EnableHsts(serverName string, policy Hsts) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	wConfig := c.webServer.Config
+
+	if !wConfig.IsModuleEnabled("headers") {
+		return errors.New("headers module is not enabled")
+	}
+
+	vHostBlocks := c.webServer.FindSslVirtualHostBlocksByServerName(serverName)
+
+	if len(vHostBlocks) == 0 {
+		return fmt.Errorf("apache ssl host %s does not exist", serverName)
+	}
+
+	values := []string{"always", "set", HeaderName, fmt.Sprintf(`"%s"`, FormatHeaderValue(policy))}
+
+	for _, vHostBlock := range vHostBlocks {
+		directives := findApacheHstsDirectives(vHostBlock)
+
+		if len(directives) == 0 {
+			directive := goapacheconf.NewDirective(apacheHeader, values)
+			directive.AppendNewLine()
+			vHostBlock.AppendDirective(directive)
+
+			continue
+		}
+
+		if !webserver.IsDirectiveInBlock(vHostBlock.Dump(), apacheHeader, HeaderName) {
+			return fmt.Errorf("%s header of apache host %s is set in an included file", HeaderName, serverName)
+		}
+
+		directives[0].SetValues(values)
+
+		for _, directive := range directives[1:] {
+			vHostBlock.DeleteDirective(directive)
+		}
+	}
+
+	return c.apply(vHostBlocks)
+}
+
+func (c *ApacheHstsChangeCommand) DisableHsts(serverName string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	var changedVHostBlocks []goapacheconf.VirtualHostBlock
+
+	for _, vHostBlock := range c.webServer.FindSslVirtualHostBlocksByServerName(serverName) {
+		directives := findApacheHstsDirectives(vHostBlock)
+
+		if len(directives) == 0 {
+			continue
+		}
+
+		if !webserver.IsDirectiveInBlock(vHostBlock.Dump(), apacheHeader, HeaderName) {
+			return fmt.Errorf("%s header of apache host %s is set in an included file", HeaderName, serverName)
+		}
+
+		for _, directive := range directives {
+			vHostBlock.DeleteDirective(directive)
+		}
+
+		changedVHostBlocks = append(changedVHostBlocks, vHostBlock)
+	}
+
+	if len(changedVHostBlocks) == 0 {
+		return nil
+	}
+
+	return c.apply(changedVHostBlocks)
+}
+
+func (c *ApacheHstsChangeCommand) apply(vHostBlocks []goapacheconf.VirtualHostBlock) error {
+	var filePaths []string
+
+	for _, vHostBlock := range vHostBlocks {
+		filePaths = append(filePaths, vHostBlock.FilePath)
+	}
+
+	return reverter.ApplyConfigFileChanges(c.webServer, c.reverter, c.logger, filePaths)
+}
+
+// findApacheHstsDirectives returns "Header [always] set|append Strict-Transport-Security value" directives
+func findApacheHstsDirectives(vHostBlock goapacheconf.VirtualHostBlock) []goapacheconf.Directive {
+	var directives []goapacheconf.Directive
+
+	for _, directive := range vHostBlock.FindDirectives(apacheHeader) {
+		if isApacheHstsDirective(directive) {
+			directives = append(directives, directive)
+		}
+	}
+
+	return directives
+}
+
+func isApacheHstsDirective(directive goapacheconf.Directive) bool {
+	values := directive.GetValues()
+
+	return len(values) >= 3 && slices.ContainsFunc(values[:len(values)-1], func(value string) bool {
+		return strings.EqualFold(value, HeaderName)
+	})
+}
@@ -0,0 +1,83 @@
+//go:build apache
+
+package hsts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const apacheHstsHostConfig = `LoadModule ssl_module modules/mod_ssl.so
+LoadModule headers_module modules/mod_headers.so
+
+<VirtualHost *:443>
+    ServerName example.com
+    DocumentRoot /var/www/html
+    SSLEngine on
+    SSLCertificateFile /etc/ssl/example.com.crt
+    SSLCertificateKeyFile /etc/ssl/example.com.key
+    # Header always set Strict-Transport-Security "max-age=100"
+</VirtualHost>
+`
+
+func TestApacheHsts(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "apache2.conf")
+	require.Nil(t, os.WriteFile(configPath, []byte(apacheHstsHostConfig), 0644))
+
+	options := map[string]string{
+		config.ApacheRootOpt:           dir,
+		config.ApachectlBinOpt:         "true",
+		config.ApacheReloadStrategyOpt: "command",
+		config.ApacheReloadCommandOpt:  "true",
+	}
+
+	command, query := getApacheHsts(t, options)
+	assert.False(t, query.GetHstsStatus("example.com").Enabled)
+	require.Nil(t, command.EnableHsts("example.com", Hsts{MaxAge: 300, IncludeSubDomains: true}))
+
+	command, query = getApacheHsts(t, options)
+	assert.Equal(t, Hsts{Enabled: true, MaxAge: 300, IncludeSubDomains: true}, query.GetHstsStatus("example.com"))
+	require.Nil(t, command.EnableHsts("example.com", Hsts{Preload: true}))
+
+	content, err := os.ReadFile(configPath)
+	require.Nil(t, err)
+	assert.Equal(t, 1, strings.Count(string(content), `Header always set Strict-Transport-Security "max-age=31536000; preload"`))
+
+	command, query = getApacheHsts(t, options)
+	require.Nil(t, command.DisableHsts("example.com"))
+	assert.False(t, query.GetHstsStatus("example.com").Enabled)
+
+	content, err = os.ReadFile(configPath)
+	require.Nil(t, err)
+	assert.NotContains(t, string(content), "max-age=31536000")
+	assert.Contains(t, string(content), `# Header always set Strict-Transport-Security "max-age=100"`)
+}
+
+func getApacheHsts(t *testing.T, options map[string]string) (HstsChangeCommand, HstsQuery) {
+	log := &logger.TestLogger{T: t}
+
+	apacheWebServer, err := webserver.GetApacheWebServer(options)
+	require.Nil(t, err)
+
+	rv, err := reverter.CreateReverter(apacheWebServer, log)
+	require.Nil(t, err)
+
+	command, err := CreateHstsChangeCommand(apacheWebServer, rv, log, &sync.Mutex{})
+	require.Nil(t, err)
+
+	query, err := CreateHstsStatusQuery(apacheWebServer)
+	require.Nil(t, err)
+
+	return command, query
+}
@@ -0,0 +1,112 @@
+package hsts
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+)
+
+const (
+	HeaderName    = "Strict-Transport-Security"
+	DefaultMaxAge = 31536000
+)
+
+// Hsts is the Strict-Transport-Security policy of the host
+type Hsts struct {
+	Enabled           bool
+	MaxAge            int
+	IncludeSubDomains bool
+	Preload           bool
+}
+
+type HstsQuery interface {
+	GetHstsStatus(serverName string) Hsts
+}
+
+type HstsChangeCommand interface {
+	EnableHsts(serverName string, policy Hsts) error
+	DisableHsts(serverName string) error
+}
+
+func CreateHstsStatusQuery(webServer webserver.WebServer) (HstsQuery, error) {
+	switch w := webServer.(type) {
+	case *webserver.NginxWebServer:
+		return &NginxHstsQuery{webServer: w}, nil
+	case *webserver.ApacheWebServer:
+		return &ApacheHstsQuery{webServer: w}, nil
+	default:
+		return nil, fmt.Errorf("webserver %s is not supported", webServer.GetCode())
+	}
+}
+
+func CreateHstsChangeCommand(
+	webServer webserver.WebServer,
+	reverter reverter.Reverter,
+	logger logger.Logger,
+	mx *sync.Mutex,
+) (HstsChangeCommand, error) {
+	switch w := webServer.(type) {
+	case *webserver.NginxWebServer:
+		return &NginxHstsChangeCommand{
+			logger:    logger,
+			webServer: w,
+			reverter:  reverter,
+			mx:        mx,
+		}, nil
+	case *webserver.ApacheWebServer:
+		return &ApacheHstsChangeCommand{
+			logger:    logger,
+			webServer: w,
+			reverter:  reverter,
+			mx:        mx,
+		}, nil
+	default:
+		return nil, fmt.Errorf("webserver %s is not supported", webServer.GetCode())
+	}
+}
+
+// FormatHeaderValue returns the header value of the policy, default max-age is used if it is not set
+func FormatHeaderValue(policy Hsts) string {
+	maxAge := policy.MaxAge
+
+	if maxAge <= 0 {
+		maxAge = DefaultMaxAge
+	}
+
+	parts := []string{fmt.Sprintf("max-age=%d", maxAge)}
+
+	if policy.IncludeSubDomains {
+		parts = append(parts, "includeSubDomains")
+	}
+
+	if policy.Preload {
+		parts = append(parts, "preload")
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+func ParseHeaderValue(value string) Hsts {
+	policy := Hsts{Enabled: true}
+
+	for _, part := range strings.Split(strings.Trim(value, `"'`), ";") {
+		part = strings.TrimSpace(part)
+		name, partValue, _ := strings.Cut(part, "=")
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			policy.MaxAge, _ = strconv.Atoi(strings.Trim(strings.TrimSpace(partValue), `"`))
+		case "includesubdomains":
+			policy.IncludeSubDomains = true
+		case "preload":
+			policy.Preload = true
+		}
+	}
+
+	return policy
+}
@@ -0,0 +1,21 @@
+//go:build common
+
+package hsts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatHeaderValue(t *testing.T) {
+	assert.Equal(t, "max-age=31536000", FormatHeaderValue(Hsts{}))
+	assert.Equal(t, "max-age=300; includeSubDomains", FormatHeaderValue(Hsts{MaxAge: 300, IncludeSubDomains: true}))
+	assert.Equal(t, "max-age=63072000; includeSubDomains; preload", FormatHeaderValue(Hsts{MaxAge: 63072000, IncludeSubDomains: true, Preload: true}))
+}
+
+func TestParseHeaderValue(t *testing.T) {
+	assert.Equal(t, Hsts{Enabled: true, MaxAge: 31536000, IncludeSubDomains: true}, ParseHeaderValue(`"max-age=31536000; includeSubDomains"`))
+	assert.Equal(t, Hsts{Enabled: true, MaxAge: 300, Preload: true}, ParseHeaderValue(`max-age="300";preload`))
+	assert.Equal(t, Hsts{Enabled: true, MaxAge: 100, IncludeSubDomains: true, Preload: true}, ParseHeaderValue(`'Max-Age=100; IncludeSubDomains; Preload'`))
+}
@@ -0,0 +1,181 @@
+package hsts
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/gonginxconf/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+)
+
+const (
+	nginxAddHeader = "add_header"
+	nginxLocation  = "location"
+)
+
+type NginxHstsQuery struct {
+	webServer *webserver.NginxWebServer
+}
+
+func (q *NginxHstsQuery) GetHstsStatus(serverName string) Hsts {
+	for _, serverBlock := range q.webServer.FindSslServerBlocksByServerName(serverName) {
+		for _, directive := range findNginxHstsDirectives(serverBlock.Block) {
+			values := directive.GetValues()
+
+			if len(values) < 2 {
+				continue
+			}
+
+			return ParseHeaderValue(values[1])
+		}
+	}
+
+	return Hsts{}
+}
+
+type NginxHstsChangeCommand struct {
+	webServer *webserver.NginxWebServer
+	reverter  reverter.Reverter
+	logger    logger.Logger
+	mx        *sync.Mutex
+}
+
+// EnableHsts sets "add_header Strict-Transport-Security" in all ssl server blocks of the host.
+// nginx does not inherit add_header directives into locations with own add_header directives, so the header is set there too.
+func (c *NginxHstsChangeCommand) EnableHsts(serverName string, policy Hsts) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	serverBlocks := c.webServer.FindSslServerBlocksByServerName(serverName)
+
+	if len(serverBlocks) == 0 {
+		return fmt.Errorf("nginx ssl host %s does not exist", serverName)
+	}
+
+	values := []string{HeaderName, fmt.Sprintf(`"%s"`, FormatHeaderValue(policy)), "always"}
+	var headerBlocks []config.Block
+
+	for _, serverBlock := range serverBlocks {
+		headerBlocks = append(headerBlocks, findNginxHeaderBlocks(serverBlock)...)
+	}
+
+	for _, block := range headerBlocks {
+		directives := findNginxHstsDirectives(block)
+
+		if len(directives) == 0 {
+			block.AddDirective(config.NewDirective(nginxAddHeader, values), false, true)
+
+			continue
+		}
+
+		if !webserver.IsDirectiveInBlock(block.Dump(), nginxAddHeader, HeaderName) {
+			return fmt.Errorf("%s header of nginx host %s is set in an included file", HeaderName, serverName)
+		}
+
+		directives[0].SetValues(values)
+
+		for _, directive := range directives[1:] {
+			block.DeleteDirective(directive)
+		}
+	}
+
+	return c.apply(headerBlocks)
+}
+
+func (c *NginxHstsChangeCommand) DisableHsts(serverName string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	var changedBlocks []config.Block
+
+	for _, serverBlock := range c.webServer.FindSslServerBlocksByServerName(serverName) {
+		for _, block := range findNginxHeaderBlocks(serverBlock) {
+			directives := findNginxHstsDirectives(block)
+
+			if len(directives) == 0 {
+				continue
+			}
+
+			if !webserver.IsDirectiveInBlock(block.Dump(), nginxAddHeader, HeaderName) {
+				return fmt.Errorf("%s header of nginx host %s is set in an included file", HeaderName, serverName)
+			}
+
+			for _, directive := range directives {
+				block.DeleteDirective(directive)
+			}
+
+			changedBlocks = append(changedBlocks, block)
+		}
+	}
+
+	if len(changedBlocks) == 0 {
+		return nil
+	}
+
+	return c.apply(changedBlocks)
+}
+
+func (c *NginxHstsChangeCommand) apply(blocks []config.Block) error {
+	var filePaths []string
+
+	for _, block := range blocks {
+		filePaths = append(filePaths, block.FilePath)
+	}
+
+	return reverter.ApplyConfigFileChanges(c.webServer, c.reverter, c.logger, filePaths)
+}
+
+// findNginxHeaderBlocks returns the server block and its locations with own add_header directives
+func findNginxHeaderBlocks(serverBlock config.ServerBlock) []config.Block {
+	blocks := []config.Block{serverBlock.Block}
+
+	for _, locationBlock := range findNginxLocationBlocks(serverBlock.Block) {
+		if len(findNginxOwnDirectives(locationBlock, nginxAddHeader)) > 0 {
+			blocks = append(blocks, locationBlock)
+		}
+	}
+
+	return blocks
+}
+
+// findNginxLocationBlocks returns locations of the block including nested ones
+func findNginxLocationBlocks(block config.Block) []config.Block {
+	var locationBlocks []config.Block
+
+	for _, locationBlock := range block.FindBlocks(nginxLocation) {
+		locationBlocks = append(locationBlocks, locationBlock)
+		locationBlocks = append(locationBlocks, findNginxLocationBlocks(locationBlock)...)
+	}
+
+	return locationBlocks
+}
+
+// findNginxOwnDirectives returns directives of the block including ones of included files, directives of nested locations are skipped
+func findNginxOwnDirectives(block config.Block, name string) []config.Directive {
+	var nestedDirectives []config.Directive
+
+	for _, locationBlock := range block.FindBlocks(nginxLocation) {
+		nestedDirectives = append(nestedDirectives, locationBlock.FindDirectives(name)...)
+	}
+
+	return slices.DeleteFunc(block.FindDirectives(name), func(directive config.Directive) bool {
+		return slices.Contains(nestedDirectives, directive)
+	})
+}
+
+// findNginxHstsDirectives returns add_header directives of the header including ones of included files
+func findNginxHstsDirectives(block config.Block) []config.Directive {
+	var directives []config.Directive
+
+	for _, directive := range findNginxOwnDirectives(block, nginxAddHeader) {
+		if strings.EqualFold(directive.GetFirstValue(), HeaderName) {
+			directives = append(directives, directive)
+		}
+	}
+
+	return directives
+}
@@ -0,0 +1,88 @@
+//go:build common
+
+package hsts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nginxLocationHeaderHostConfig = `events {}
+
+http {
+    server {
+        listen 443 ssl;
+        server_name example.com;
+        ssl_certificate /etc/ssl/example.com.crt;
+        ssl_certificate_key /etc/ssl/example.com.key;
+        # add_header Strict-Transport-Security "max-age=300";
+
+        location / {
+            try_files $uri $uri/ =404;
+        }
+
+        location /static/ {
+            add_header Cache-Control "public";
+        }
+    }
+}
+`
+
+func TestNginxHstsLocationWithOwnHeaders(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "nginx.conf")
+	require.Nil(t, os.WriteFile(configPath, []byte(nginxLocationHeaderHostConfig), 0644))
+
+	options := map[string]string{
+		config.NginxRootOpt:           dir,
+		config.NginxBinOpt:            "true",
+		config.NginxReloadStrategyOpt: "command",
+		config.NginxReloadCommandOpt:  "true",
+	}
+	command, query := getNginxTempHsts(t, options)
+	assert.False(t, query.GetHstsStatus("example.com").Enabled)
+
+	require.Nil(t, command.EnableHsts("example.com", Hsts{MaxAge: 300}))
+
+	content, err := os.ReadFile(configPath)
+	require.Nil(t, err)
+	// server block and the location with own add_header directives
+	assert.Equal(t, 2, strings.Count(string(content), `add_header Strict-Transport-Security "max-age=300" always;`))
+
+	command, query = getNginxTempHsts(t, options)
+	assert.Equal(t, Hsts{Enabled: true, MaxAge: 300}, query.GetHstsStatus("example.com"))
+	require.Nil(t, command.DisableHsts("example.com"))
+
+	content, err = os.ReadFile(configPath)
+	require.Nil(t, err)
+	assert.NotContains(t, string(content), `"max-age=300" always`)
+	assert.Contains(t, string(content), `add_header Cache-Control "public";`)
+}
+
+func getNginxTempHsts(t *testing.T, options map[string]string) (HstsChangeCommand, HstsQuery) {
+	log := &logger.TestLogger{T: t}
+
+	nginxWebServer, err := webserver.GetNginxWebServer(options)
+	require.Nil(t, err)
+
+	rv, err := reverter.CreateReverter(nginxWebServer, log)
+	require.Nil(t, err)
+
+	command, err := CreateHstsChangeCommand(nginxWebServer, rv, log, &sync.Mutex{})
+	require.Nil(t, err)
+
+	query, err := CreateHstsStatusQuery(nginxWebServer)
+	require.Nil(t, err)
+
+	return command, query
+}
@@ -0,0 +1,69 @@
+//go:build nginx
+
+package hsts
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNginxHsts(t *testing.T) {
+	host := "example4.com"
+
+	command, query, nginxWebServer, rv := getNginxHsts(t)
+	defer rv.Rollback()
+
+	assert.False(t, query.GetHstsStatus(host).Enabled)
+
+	err := command.EnableHsts(host, Hsts{MaxAge: 300, Preload: true})
+	require.Nil(t, err)
+	assert.Equal(t, Hsts{Enabled: true, MaxAge: 300, Preload: true}, query.GetHstsStatus(host))
+
+	err = command.EnableHsts(host, Hsts{IncludeSubDomains: true})
+	require.Nil(t, err)
+	assert.Equal(t, Hsts{Enabled: true, MaxAge: DefaultMaxAge, IncludeSubDomains: true}, query.GetHstsStatus(host))
+
+	for _, serverBlock := range nginxWebServer.FindSslServerBlocksByServerName(host) {
+		assert.Len(t, findNginxHstsDirectives(serverBlock.Block), 1)
+	}
+
+	err = command.DisableHsts(host)
+	require.Nil(t, err)
+	assert.False(t, query.GetHstsStatus(host).Enabled)
+}
+
+func TestNginxHstsInIncludedFile(t *testing.T) {
+	host := "example.com"
+
+	command, query, _, rv := getNginxHsts(t)
+	defer rv.Rollback()
+
+	assert.True(t, query.GetHstsStatus(host).Enabled)
+	assert.ErrorContains(t, command.DisableHsts(host), "included file")
+}
+
+func getNginxHsts(t *testing.T) (HstsChangeCommand, HstsQuery, *webserver.NginxWebServer, reverter.Reverter) {
+	config, err := config.GetConfig()
+	require.Nil(t, err)
+
+	nginxWebServer, err := webserver.GetNginxWebServer(config.ToMap())
+	require.Nil(t, err)
+
+	rv, err := reverter.CreateReverter(nginxWebServer, &logger.TestLogger{T: t})
+	require.Nil(t, err)
+
+	command, err := CreateHstsChangeCommand(nginxWebServer, rv, &logger.TestLogger{T: t}, &sync.Mutex{})
+	require.Nil(t, err)
+
+	query, err := CreateHstsStatusQuery(nginxWebServer)
+	require.Nil(t, err)
+
+	return command, query, nginxWebServer, rv
+}
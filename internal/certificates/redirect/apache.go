@@ -0,0 +1,184 @@
+package redirect
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/goapacheconf"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+)
+
+const (
+	apacheRedirectCond  = `%{REQUEST_URI} !^/\.well-known/acme-challenge/`
+	apacheRedirectUrl   = "https://%{HTTP_HOST}%{REQUEST_URI}"
+	apacheRedirectFlags = "[L,NE,R=301]"
+)
+
+type ApacheRedirectQuery struct {
+	webServer *webserver.ApacheWebServer
+}
+
+func (q *ApacheRedirectQuery) GetRedirectStatus(serverName string) Redirect {
+	var redirect Redirect
+	vHostBlock := findApachePlainVirtualHostBlock(q.webServer.Config, serverName)
+
+	if vHostBlock == nil {
+		return redirect
+	}
+
+	redirect.Enabled = findApacheRedirectRule(vHostBlock) != nil
+
+	return redirect
+}
+
+type ApacheRedirectChangeCommand struct {
+	webServer *webserver.ApacheWebServer
+	reverter  reverter.Reverter
+	logger    logger.Logger
+	mx        *sync.Mutex
+}
+
+// EnableRedirect adds RewriteRule redirecting to https to the port-80 virtual host right after RewriteEngine,
+// so existing rules with L flag do not stop it. ACME challenge path is excluded with RewriteCond.
+func (c *ApacheRedirectChangeCommand) EnableRedirect(serverName string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	wConfig := c.webServer.Config
+
+	if !wConfig.IsModuleEnabled("rewrite") {
+		return errors.New("rewrite module is not enabled")
+	}
+
+	vHostBlock := findApachePlainVirtualHostBlock(wConfig, serverName)
+
+	if vHostBlock == nil {
+		return fmt.Errorf("apache host %s on 80 port does not exist", serverName)
+	}
+
+	if !hasApacheSslVirtualHostBlock(wConfig, serverName) {
+		return fmt.Errorf("ssl is not enabled for apache host %s", serverName)
+	}
+
+	configFile := wConfig.GetConfigFile(filepath.Base(vHostBlock.FilePath))
+
+	if configFile == nil {
+		return fmt.Errorf("failed to find config file for host %s", serverName)
+	}
+
+	if findApacheRedirectRule(vHostBlock) != nil {
+		c.logger.Info("redirect is already enabled for %s host", serverName)
+
+		return nil
+	}
+
+	var rewriteEngine goapacheconf.Directive
+	rewriteEngines := vHostBlock.FindDirectives(goapacheconf.RewriteEngine)
+
+	if len(rewriteEngines) == 0 {
+		rewriteEngine = vHostBlock.AppendDirective(goapacheconf.NewDirective(goapacheconf.RewriteEngine, []string{"on"}))
+	} else {
+		rewriteEngine = rewriteEngines[len(rewriteEngines)-1]
+		rewriteEngine.SetValue("on")
+	}
+
+	cond := vHostBlock.AppendDirective(goapacheconf.NewDirective(goapacheconf.RewriteCond, strings.Fields(apacheRedirectCond)))
+	rule := goapacheconf.NewDirective(goapacheconf.RewriteRule, []string{"^", apacheRedirectUrl, apacheRedirectFlags})
+	rule.AppendNewLine()
+	rule = vHostBlock.AppendDirective(rule)
+
+	// RewriteEngine can be nested, e.g. in IfModule block, then the rule is kept at the end of the virtual host
+	if order := vHostBlock.GetDirectiveOrder(rewriteEngine); order != -1 {
+		vHostBlock.ChangeDirectiveOrder(cond, order+1)
+		vHostBlock.ChangeDirectiveOrder(rule, order+2)
+	}
+
+	return reverter.ApplyChanges(c.webServer, c.reverter, c.logger, []string{vHostBlock.FilePath}, func() error {
+		_, err := configFile.Dump()
+
+		return err
+	})
+}
+
+// DisableRedirect removes the redirect RewriteRule with its RewriteCond.
+// RewriteEngine is removed if no other rewrite rules are left.
+func (c *ApacheRedirectChangeCommand) DisableRedirect(serverName string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	wConfig := c.webServer.Config
+	vHostBlock := findApachePlainVirtualHostBlock(wConfig, serverName)
+
+	if vHostBlock == nil {
+		return fmt.Errorf("apache host %s on 80 port does not exist", serverName)
+	}
+
+	configFile := wConfig.GetConfigFile(filepath.Base(vHostBlock.FilePath))
+
+	if configFile == nil {
+		return fmt.Errorf("failed to find config file for host %s", serverName)
+	}
+
+	rule := findApacheRedirectRule(vHostBlock)
+
+	if rule == nil {
+		return nil
+	}
+
+	for _, cond := range rule.GetRelatedRewiteCondDirectives() {
+		vHostBlock.DeleteDirective(cond)
+	}
+
+	vHostBlock.DeleteDirective(rule.Directive)
+
+	if len(vHostBlock.FindRewriteRuleDirectives()) == 0 {
+		vHostBlock.DeleteDirectiveByName(goapacheconf.RewriteEngine)
+	}
+
+	return reverter.ApplyChanges(c.webServer, c.reverter, c.logger, []string{vHostBlock.FilePath}, func() error {
+		_, err := configFile.Dump()
+
+		return err
+	})
+}
+
+// findApachePlainVirtualHostBlock returns the virtual host of the host that listens on 80 port without ssl
+func findApachePlainVirtualHostBlock(apacheConfig *goapacheconf.Config, serverName string) *goapacheconf.VirtualHostBlock {
+	for _, vHostBlock := range apacheConfig.FindVirtualHostBlocksByServerName(serverName) {
+		if vHostBlock.HasSSL() {
+			continue
+		}
+
+		for _, address := range vHostBlock.GetAddresses() {
+			if address.Port == "80" {
+				return &vHostBlock
+			}
+		}
+	}
+
+	return nil
+}
+
+func hasApacheSslVirtualHostBlock(apacheConfig *goapacheconf.Config, serverName string) bool {
+	return slices.ContainsFunc(apacheConfig.FindVirtualHostBlocksByServerName(serverName), func(vHostBlock goapacheconf.VirtualHostBlock) bool {
+		return vHostBlock.HasSSL()
+	})
+}
+
+func findApacheRedirectRule(vHostBlock *goapacheconf.VirtualHostBlock) *goapacheconf.RewriteRuleDirective {
+	for _, rule := range vHostBlock.FindRewriteRuleDirectives() {
+		values := rule.GetValues()
+
+		if len(values) >= 2 && values[0] == "^" && values[1] == apacheRedirectUrl {
+			return &rule
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+//go:build apache
+
+package redirect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const apacheFrontControllerConfig = `LoadModule rewrite_module modules/mod_rewrite.so
+LoadModule ssl_module modules/mod_ssl.so
+
+<VirtualHost *:80>
+    ServerName example.com
+    DocumentRoot /var/www/html
+    RewriteEngine on
+    RewriteCond %{REQUEST_FILENAME} !-f
+    RewriteRule ^ index.php [L]
+</VirtualHost>
+
+<VirtualHost *:443>
+    ServerName example.com
+    DocumentRoot /var/www/html
+    SSLEngine on
+    SSLCertificateFile /etc/ssl/example.com.crt
+    SSLCertificateKeyFile /etc/ssl/example.com.key
+</VirtualHost>
+`
+
+func TestApacheRedirect(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "apache2.conf")
+	require.Nil(t, os.WriteFile(configPath, []byte(apacheFrontControllerConfig), 0644))
+
+	options := map[string]string{
+		config.ApacheRootOpt:           dir,
+		config.ApachectlBinOpt:         "true",
+		config.ApacheReloadStrategyOpt: "command",
+		config.ApacheReloadCommandOpt:  "true",
+	}
+	log := &logger.TestLogger{T: t}
+
+	command, query := getApacheRedirect(t, options, log)
+	require.False(t, query.GetRedirectStatus("example.com").Enabled)
+	require.Nil(t, command.EnableRedirect("example.com"))
+
+	// the redirect is placed before the front controller rule
+	content, err := os.ReadFile(configPath)
+	require.Nil(t, err)
+	redirectIndex := strings.Index(string(content), apacheRedirectUrl)
+	require.NotEqual(t, -1, redirectIndex)
+	assert.Less(t, strings.Index(string(content), "RewriteEngine on"), redirectIndex)
+	assert.Less(t, redirectIndex, strings.Index(string(content), "index.php [L]"))
+
+	command, query = getApacheRedirect(t, options, log)
+	require.True(t, query.GetRedirectStatus("example.com").Enabled)
+	require.Nil(t, command.DisableRedirect("example.com"))
+
+	content, err = os.ReadFile(configPath)
+	require.Nil(t, err)
+	assert.NotContains(t, string(content), apacheRedirectUrl)
+	assert.NotContains(t, string(content), "acme-challenge")
+	assert.Contains(t, string(content), "RewriteEngine on")
+	assert.Contains(t, string(content), "index.php [L]")
+
+	_, query = getApacheRedirect(t, options, log)
+	assert.False(t, query.GetRedirectStatus("example.com").Enabled)
+}
+
+func getApacheRedirect(t *testing.T, options map[string]string, log logger.Logger) (RedirectChangeCommand, RedirectQuery) {
+	apacheWebServer, err := webserver.GetApacheWebServer(options)
+	require.Nil(t, err)
+
+	rv, err := reverter.CreateReverter(apacheWebServer, log)
+	require.Nil(t, err)
+
+	command, err := CreateRedirectChangeCommand(apacheWebServer, rv, log, &sync.Mutex{})
+	require.Nil(t, err)
+
+	query, err := CreateRedirectStatusQuery(apacheWebServer)
+	require.Nil(t, err)
+
+	return command, query
+}
@@ -0,0 +1,250 @@
+package redirect
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/gonginxconf/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+)
+
+const (
+	nginxAcmeLocation  = "/.well-known/acme-challenge/"
+	nginxRootLocation  = "/"
+	nginxReturn        = "return"
+	nginxRedirectCode  = "301"
+	nginxRedirectUrl   = "https://$host$request_uri"
+	nginxAcmeTryFiles  = "try_files"
+	nginxAcmeTryValues = "$uri =404"
+)
+
+type NginxRedirectQuery struct {
+	webServer *webserver.NginxWebServer
+}
+
+func (q *NginxRedirectQuery) GetRedirectStatus(serverName string) Redirect {
+	var redirect Redirect
+	serverBlock := findNginxPlainServerBlock(q.webServer, serverName)
+
+	if serverBlock == nil {
+		return redirect
+	}
+
+	rootLocation := findNginxRootLocationBlock(serverBlock)
+	redirect.Enabled = rootLocation != nil && findNginxRedirectDirective(rootLocation) != nil
+
+	return redirect
+}
+
+type NginxRedirectChangeCommand struct {
+	webServer *webserver.NginxWebServer
+	reverter  reverter.Reverter
+	logger    logger.Logger
+	mx        *sync.Mutex
+}
+
+// EnableRedirect adds "return 301" to the root location of the host plain server block and to other locations,
+// since regex and longer prefix locations take precedence over the root one. ACME challenge location is exempt from the redirect.
+func (c *NginxRedirectChangeCommand) EnableRedirect(serverName string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	serverBlock := findNginxPlainServerBlock(c.webServer, serverName)
+
+	if serverBlock == nil {
+		return getNginxPlainServerBlockError(c.webServer, serverName)
+	}
+
+	if !hasNginxSslServerBlock(c.webServer, serverName) {
+		return fmt.Errorf("ssl is not enabled for nginx host %s", serverName)
+	}
+
+	configFile := c.webServer.Config.GetConfigFile(filepath.Base(serverBlock.FilePath))
+
+	if configFile == nil {
+		return fmt.Errorf("failed to find config file for host %s", serverName)
+	}
+
+	rootLocation := findNginxRootLocationBlock(serverBlock)
+	locations := findNginxRedirectLocationBlocks(serverBlock)
+
+	if rootLocation != nil && !slices.ContainsFunc(locations, func(location config.LocationBlock) bool {
+		return findNginxRedirectDirective(&location) == nil
+	}) {
+		c.logger.Info("redirect is already enabled for %s host", serverName)
+
+		return nil
+	}
+
+	if findNginxAcmeLocationBlock(serverBlock) == nil {
+		acmeLocation := serverBlock.AddLocationBlock("^~", nginxAcmeLocation, true)
+		acmeLocation.AddDirective(config.NewDirective(nginxAcmeTryFiles, strings.Fields(nginxAcmeTryValues)), true, false)
+	}
+
+	if rootLocation == nil {
+		serverBlock.AddLocationBlock("", nginxRootLocation, false)
+	}
+
+	for _, location := range findNginxRedirectLocationBlocks(serverBlock) {
+		if findNginxRedirectDirective(&location) == nil {
+			location.AddDirective(config.NewDirective(nginxReturn, []string{nginxRedirectCode, nginxRedirectUrl}), true, false)
+		}
+	}
+
+	return reverter.ApplyChanges(c.webServer, c.reverter, c.logger, []string{serverBlock.FilePath}, configFile.Dump)
+}
+
+// DisableRedirect removes "return 301" from locations. The root location is deleted if it becomes empty.
+func (c *NginxRedirectChangeCommand) DisableRedirect(serverName string) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	serverBlock := findNginxPlainServerBlock(c.webServer, serverName)
+
+	if serverBlock == nil {
+		return getNginxPlainServerBlockError(c.webServer, serverName)
+	}
+
+	configFile := c.webServer.Config.GetConfigFile(filepath.Base(serverBlock.FilePath))
+
+	if configFile == nil {
+		return fmt.Errorf("failed to find config file for host %s", serverName)
+	}
+
+	var changed bool
+
+	for _, location := range findNginxRedirectLocationBlocks(serverBlock) {
+		if redirectDirective := findNginxRedirectDirective(&location); redirectDirective != nil {
+			location.DeleteDirective(*redirectDirective)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	rootLocation := findNginxRootLocationBlock(serverBlock)
+
+	if rootLocation != nil && isNginxBlockEmpty(rootLocation.Block) {
+		serverBlock.DeleteLocationBlock(*rootLocation)
+	}
+
+	acmeLocation := findNginxAcmeLocationBlock(serverBlock)
+
+	if acmeLocation != nil && isNginxAcmeExemptLocation(acmeLocation) {
+		serverBlock.DeleteLocationBlock(*acmeLocation)
+	}
+
+	return reverter.ApplyChanges(c.webServer, c.reverter, c.logger, []string{serverBlock.FilePath}, configFile.Dump)
+}
+
+// findNginxPlainServerBlock returns the server block of the host that listens on 80 port without ssl
+func findNginxPlainServerBlock(webServer *webserver.NginxWebServer, serverName string) *config.ServerBlock {
+	for _, serverBlock := range webServer.FindServerBlocksByServerName(serverName) {
+		if serverBlock.HasSSL() {
+			continue
+		}
+
+		for _, address := range serverBlock.GetAddresses() {
+			if address.Port == "80" {
+				return &serverBlock
+			}
+		}
+	}
+
+	return nil
+}
+
+// getNginxPlainServerBlockError explains why the plain server block of the host is not found.
+// A server block listening on both 80 and 443 ports can not redirect to https by location return directives.
+func getNginxPlainServerBlockError(webServer *webserver.NginxWebServer, serverName string) error {
+	for _, serverBlock := range webServer.FindServerBlocksByServerName(serverName) {
+		for _, listen := range serverBlock.GetListens() {
+			if serverBlock.HasSSL() && !listen.Ssl && config.CreateServerAddressFromString(listen.HostPort).Port == "80" {
+				return fmt.Errorf(
+					"nginx host %s listens on 80 and ssl ports in the same server block, move 80 port to a separate server block to manage the redirect",
+					serverName,
+				)
+			}
+		}
+	}
+
+	return fmt.Errorf("nginx host %s on 80 port does not exist", serverName)
+}
+
+func hasNginxSslServerBlock(webServer *webserver.NginxWebServer, serverName string) bool {
+	return slices.ContainsFunc(webServer.FindServerBlocksByServerName(serverName), func(serverBlock config.ServerBlock) bool {
+		return serverBlock.HasSSL()
+	})
+}
+
+func findNginxRootLocationBlock(serverBlock *config.ServerBlock) *config.LocationBlock {
+	for _, locationBlock := range serverBlock.FindLocationBlocks() {
+		if locationBlock.GetModifier() == "" && locationBlock.GetLocationMatch() == nginxRootLocation {
+			return &locationBlock
+		}
+	}
+
+	return nil
+}
+
+// findNginxRedirectLocationBlocks returns locations requests can be matched to except the ACME challenge one.
+// Named locations are skipped: they are used only for internal redirects.
+func findNginxRedirectLocationBlocks(serverBlock *config.ServerBlock) []config.LocationBlock {
+	var locationBlocks []config.LocationBlock
+
+	for _, locationBlock := range serverBlock.FindLocationBlocks() {
+		match := locationBlock.GetLocationMatch()
+
+		if match == nginxAcmeLocation || strings.HasPrefix(match, "@") {
+			continue
+		}
+
+		locationBlocks = append(locationBlocks, locationBlock)
+	}
+
+	return locationBlocks
+}
+
+func findNginxAcmeLocationBlock(serverBlock *config.ServerBlock) *config.LocationBlock {
+	for _, locationBlock := range serverBlock.FindLocationBlocks() {
+		if locationBlock.GetLocationMatch() == nginxAcmeLocation {
+			return &locationBlock
+		}
+	}
+
+	return nil
+}
+
+func findNginxRedirectDirective(locationBlock *config.LocationBlock) *config.Directive {
+	for _, directive := range locationBlock.FindDirectives(nginxReturn) {
+		values := directive.GetValues()
+
+		if len(values) == 2 && values[0] == nginxRedirectCode && values[1] == nginxRedirectUrl {
+			return &directive
+		}
+	}
+
+	return nil
+}
+
+// isNginxAcmeExemptLocation checks if the ACME location contains only try_files added on redirect enabling
+func isNginxAcmeExemptLocation(locationBlock *config.LocationBlock) bool {
+	directives := locationBlock.FindDirectives(nginxAcmeTryFiles)
+
+	if len(directives) != 1 || strings.Join(directives[0].GetValues(), " ") != nginxAcmeTryValues {
+		return false
+	}
+
+	return strings.Count(locationBlock.Dump(), ";") == 1
+}
+
+func isNginxBlockEmpty(block config.Block) bool {
+	return !strings.Contains(block.Dump(), ";")
+}
@@ -0,0 +1,128 @@
+//go:build common
+
+package redirect
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nginxPhpHostConfig = `events {}
+
+http {
+    server {
+        listen 80;
+        server_name example.com;
+        root /var/www/html;
+
+        location / {
+            try_files $uri $uri/ /index.php?$query_string;
+        }
+
+        location ~ \.php$ {
+            fastcgi_pass unix:/var/run/php/php-fpm.sock;
+        }
+
+        location @fallback {
+            proxy_pass http://127.0.0.1:8080;
+        }
+    }
+
+    server {
+        listen 443 ssl;
+        server_name example.com;
+        root /var/www/html;
+    }
+}
+`
+
+func TestNginxRedirectRegexLocation(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "nginx.conf")
+	require.Nil(t, os.WriteFile(configPath, []byte(nginxPhpHostConfig), 0644))
+
+	options := map[string]string{
+		config.NginxRootOpt:           dir,
+		config.NginxBinOpt:            "true",
+		config.NginxReloadStrategyOpt: "command",
+		config.NginxReloadCommandOpt:  "true",
+	}
+	log := &logger.TestLogger{T: t}
+	command, nginxWebServer := getNginxLocationRedirect(t, options, log)
+
+	require.Nil(t, command.EnableRedirect("example.com"))
+
+	nginxWebServer, err := webserver.GetNginxWebServer(options)
+	require.Nil(t, err)
+
+	serverBlock := findNginxPlainServerBlock(nginxWebServer, "example.com")
+	require.NotNil(t, serverBlock)
+
+	for _, location := range serverBlock.FindLocationBlocks() {
+		switch location.GetLocationMatch() {
+		case nginxAcmeLocation, "@fallback":
+			assert.Nil(t, findNginxRedirectDirective(&location), location.GetLocationMatch())
+		default:
+			assert.NotNil(t, findNginxRedirectDirective(&location), location.GetLocationMatch())
+		}
+	}
+
+	command, _ = getNginxLocationRedirect(t, options, log)
+	require.Nil(t, command.DisableRedirect("example.com"))
+
+	content, err := os.ReadFile(configPath)
+	require.Nil(t, err)
+	assert.NotContains(t, string(content), nginxRedirectUrl)
+	assert.NotContains(t, string(content), nginxAcmeLocation)
+}
+
+const nginxCombinedHostConfig = `events {}
+
+http {
+    server {
+        listen 80;
+        listen 443 ssl;
+        server_name example.com;
+        ssl_certificate /etc/ssl/example.com.crt;
+        ssl_certificate_key /etc/ssl/example.com.key;
+    }
+}
+`
+
+func TestNginxRedirectCombinedServerBlock(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(nginxCombinedHostConfig), 0644))
+
+	options := map[string]string{config.NginxRootOpt: dir}
+	command, _ := getNginxLocationRedirect(t, options, &logger.TestLogger{T: t})
+
+	err := command.EnableRedirect("example.com")
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "same server block")
+
+	err = command.DisableRedirect("example.com")
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "same server block")
+}
+
+func getNginxLocationRedirect(t *testing.T, options map[string]string, log logger.Logger) (RedirectChangeCommand, *webserver.NginxWebServer) {
+	nginxWebServer, err := webserver.GetNginxWebServer(options)
+	require.Nil(t, err)
+
+	rv, err := reverter.CreateReverter(nginxWebServer, log)
+	require.Nil(t, err)
+
+	command, err := CreateRedirectChangeCommand(nginxWebServer, rv, log, &sync.Mutex{})
+	require.Nil(t, err)
+
+	return command, nginxWebServer
+}
@@ -0,0 +1,86 @@
+//go:build nginx
+
+package redirect
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNginxRedirect(t *testing.T) {
+	host := "example4.com"
+
+	command, query, nginxWebServer, rv := getNginxRedirect(t)
+	defer rv.Rollback()
+
+	require.True(t, query.GetRedirectStatus(host).Enabled)
+
+	err := command.DisableRedirect(host)
+	require.Nil(t, err)
+	assert.False(t, query.GetRedirectStatus(host).Enabled)
+
+	serverBlock := findNginxPlainServerBlock(nginxWebServer, host)
+	require.NotNil(t, serverBlock)
+	assert.Nil(t, findNginxRootLocationBlock(serverBlock))
+
+	err = command.EnableRedirect(host)
+	require.Nil(t, err)
+	assert.True(t, query.GetRedirectStatus(host).Enabled)
+
+	serverBlock = findNginxPlainServerBlock(nginxWebServer, host)
+	require.NotNil(t, serverBlock)
+	acmeLocation := findNginxAcmeLocationBlock(serverBlock)
+	require.NotNil(t, acmeLocation)
+	assert.Equal(t, "^~", acmeLocation.GetModifier())
+	assert.True(t, isNginxAcmeExemptLocation(acmeLocation))
+
+	// regex locations of the host take precedence over the root one
+	for _, location := range findNginxRedirectLocationBlocks(serverBlock) {
+		assert.NotNil(t, findNginxRedirectDirective(&location), location.GetLocationMatch())
+	}
+
+	err = command.DisableRedirect(host)
+	require.Nil(t, err)
+
+	serverBlock = findNginxPlainServerBlock(nginxWebServer, host)
+	require.NotNil(t, serverBlock)
+	assert.Nil(t, findNginxAcmeLocationBlock(serverBlock))
+
+	for _, location := range findNginxRedirectLocationBlocks(serverBlock) {
+		assert.Nil(t, findNginxRedirectDirective(&location), location.GetLocationMatch())
+	}
+}
+
+func TestNginxRedirectWithoutPlainHost(t *testing.T) {
+	command, query, _, rv := getNginxRedirect(t)
+	defer rv.Rollback()
+
+	assert.False(t, query.GetRedirectStatus("example2.com").Enabled)
+	assert.NotNil(t, command.EnableRedirect("example2.com"))
+}
+
+func getNginxRedirect(t *testing.T) (RedirectChangeCommand, RedirectQuery, *webserver.NginxWebServer, reverter.Reverter) {
+	config, err := config.GetConfig()
+	require.Nil(t, err)
+
+	nginxWebServer, err := webserver.GetNginxWebServer(config.ToMap())
+	require.Nil(t, err)
+
+	rv, err := reverter.CreateReverter(nginxWebServer, &logger.TestLogger{T: t})
+	require.Nil(t, err)
+
+	command, err := CreateRedirectChangeCommand(nginxWebServer, rv, &logger.TestLogger{T: t}, &sync.Mutex{})
+	require.Nil(t, err)
+
+	query, err := CreateRedirectStatusQuery(nginxWebServer)
+	require.Nil(t, err)
+
+	return command, query, nginxWebServer, rv
+}
@@ -0,0 +1,60 @@
+package redirect
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+)
+
+type Redirect struct {
+	Enabled bool
+}
+
+type RedirectQuery interface {
+	GetRedirectStatus(serverName string) Redirect
+}
+
+type RedirectChangeCommand interface {
+	EnableRedirect(serverName string) error
+	DisableRedirect(serverName string) error
+}
+
+func CreateRedirectStatusQuery(webServer webserver.WebServer) (RedirectQuery, error) {
+	switch w := webServer.(type) {
+	case *webserver.NginxWebServer:
+		return &NginxRedirectQuery{webServer: w}, nil
+	case *webserver.ApacheWebServer:
+		return &ApacheRedirectQuery{webServer: w}, nil
+	default:
+		return nil, fmt.Errorf("webserver %s is not supported", webServer.GetCode())
+	}
+}
+
+func CreateRedirectChangeCommand(
+	webServer webserver.WebServer,
+	reverter reverter.Reverter,
+	logger logger.Logger,
+	mx *sync.Mutex,
+) (RedirectChangeCommand, error) {
+	switch w := webServer.(type) {
+	case *webserver.NginxWebServer:
+		return &NginxRedirectChangeCommand{
+			logger:    logger,
+			webServer: w,
+			reverter:  reverter,
+			mx:        mx,
+		}, nil
+	case *webserver.ApacheWebServer:
+		return &ApacheRedirectChangeCommand{
+			logger:    logger,
+			webServer: w,
+			reverter:  reverter,
+			mx:        mx,
+		}, nil
+	default:
+		return nil, fmt.Errorf("webserver %s is not supported", webServer.GetCode())
+	}
+}
@@ -8,6 +8,10 @@ type IssueRequest struct {
 	Subjects      []string
 	Assign        bool
 	PreventReload bool
+	// Redirect enables HTTP to HTTPS redirect for the host after the certificate is deployed
+	Redirect bool
+	// TlsProfile is applied to the host after the certificate is deployed if set
+	TlsProfile string
 }
 
 type UploadRequest struct {
@@ -22,4 +26,41 @@ type AssignRequest struct {
 	WebServer   string
 	CertName    string
 	StorageType string
+	// Redirect enables HTTP to HTTPS redirect for the host after the certificate is deployed
+	Redirect bool
+	// TlsProfile is applied to the host after the certificate is deployed if set
+	TlsProfile string
+}
+
+// UnassignRequest removes ssl from the host
+type UnassignRequest struct {
+	ServerName string
+	WebServer  string
+}
+
+type AdoptRequest struct {
+	CertPath string
+	CertName string
+	// Link creates a symlink in the storage instead of copying the certificate
+	Link bool
+	// RewriteVhosts points the hosts that use the certificate to the storage path
+	RewriteVhosts bool
+}
+
+// StreamAssignRequest assigns the storage certificate to the TCP/TLS stream server.
+// ServerKey is the listen address or the name of the server.
+type StreamAssignRequest struct {
+	ServerKey   string
+	WebServer   string
+	CertName    string
+	StorageType string
+}
+
+// ReissueRequest issues a new certificate for the host with the host names the current certificate does not cover
+type ReissueRequest struct {
+	ServerName string
+	WebServer  string
+	Email      string
+	// ChallengeType is http if not set, wildcard names need dns challenge
+	ChallengeType string
 }
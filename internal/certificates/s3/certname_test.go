@@ -0,0 +1,26 @@
+//go:build common
+
+package s3
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCertName(t *testing.T) {
+	assert.Nil(t, validateCertName("example.com"))
+
+	for _, certName := range []string{"", ".", "..", "a/b", "../example.com", `a\b`} {
+		assert.NotNil(t, validateCertName(certName), certName)
+	}
+
+	// names are validated before the bucket is requested
+	storage := &S3Storage{RWMutex: &sync.RWMutex{}}
+	_, err := storage.AddPemCertificate("a/b", "")
+	assert.NotNil(t, err)
+	_, _, err = storage.GetCertificatePath("a/b")
+	assert.NotNil(t, err)
+	assert.NotNil(t, storage.RemoveCertificate("../b"))
+}
@@ -0,0 +1,311 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/utils"
+	"github.com/unknwon/com"
+)
+
+const requestTimeout = 30 * time.Second
+
+// S3Storage keeps certificates in a S3 compatible bucket as <prefix>/<cert name>.pem objects.
+// Objects are cached in the var directory since webservers need local files.
+type S3Storage struct {
+	*sync.RWMutex
+	client    *minio.Client
+	bucket    string
+	prefix    string
+	cachePath string
+	logger    logger.Logger
+}
+
+func (s *S3Storage) AddPemCertificate(certName, pemData string) (certPath string, err error) {
+	if err := validateCertName(certName); err != nil {
+		return "", err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	info, err := s.client.PutObject(
+		ctx,
+		s.bucket,
+		s.getObjectName(certName),
+		strings.NewReader(pemData),
+		int64(len(pemData)),
+		minio.PutObjectOptions{ContentType: "application/x-pem-file"},
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("could not upload certificate to the bucket: %v", err)
+	}
+
+	certPath = s.getCertificatePath(certName)
+
+	if err := s.writeCache(certName, []byte(pemData), info.ETag); err != nil {
+		return "", err
+	}
+
+	return certPath, nil
+}
+
+func (s *S3Storage) RemoveCertificate(certName string) error {
+	if err := validateCertName(certName); err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	if err := s.client.RemoveObject(ctx, s.bucket, s.getObjectName(certName), minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("could not remove certificate %s: %v", certName, err)
+	}
+
+	for _, path := range []string{s.getCertificatePath(certName), s.getETagPath(certName)} {
+		if com.IsFile(path) {
+			os.Remove(path)
+		}
+	}
+
+	return nil
+}
+
+func (s *S3Storage) GetCertificate(certName string) (*dto.Certificate, error) {
+	certPath, _, err := s.GetCertificatePath(certName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	return utils.GetCertificateFromFile(certPath)
+}
+
+func (s *S3Storage) GetCertificateAsString(certName string) (certPath string, certContent string, err error) {
+	certPath, _, err = s.GetCertificatePath(certName)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	certContentBytes, err := os.ReadFile(certPath)
+
+	if err != nil {
+		return "", "", fmt.Errorf("could not read certificate content: %v", err)
+	}
+
+	certContent = string(certContentBytes)
+
+	return
+}
+
+func (s *S3Storage) GetCertificates() (map[string]*dto.Certificate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	certsMap := map[string]*dto.Certificate{}
+	objects := s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: s.getObjectPrefix()})
+
+	for object := range objects {
+		if object.Err != nil {
+			return nil, fmt.Errorf("could not get certificate list in the bucket: %v", object.Err)
+		}
+
+		name := path.Base(object.Key)
+
+		if path.Ext(name) != ".pem" {
+			continue
+		}
+
+		certName := strings.TrimSuffix(name, ".pem")
+
+		if err := validateCertName(certName); err != nil {
+			s.logger.Error("failed to fetch certificate %s: %v", certName, err)
+
+			continue
+		}
+
+		certPath, err := s.syncCache(ctx, certName, object.ETag)
+
+		if err != nil {
+			s.logger.Error("failed to fetch certificate %s: %v", certName, err)
+
+			continue
+		}
+
+		cert, err := utils.GetCertificateFromFile(certPath)
+
+		if err != nil {
+			s.logger.Error("failed to parse certificate %s: %v", certName, err)
+
+			continue
+		}
+
+		certsMap[certName] = cert
+	}
+
+	return certsMap, nil
+}
+
+// GetCertificatePath returns path of the local copy of the certificate. The copy is refreshed if the object was changed.
+func (s *S3Storage) GetCertificatePath(certName string) (certPath string, keyPath string, err error) {
+	if err := validateCertName(certName); err != nil {
+		return "", "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	info, err := s.client.StatObject(ctx, s.bucket, s.getObjectName(certName), minio.StatObjectOptions{})
+
+	if err != nil {
+		return "", "", fmt.Errorf("could not get certificate %s: %v", certName, err)
+	}
+
+	certPath, err = s.syncCache(ctx, certName, info.ETag)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	keyPath = certPath
+
+	return
+}
+
+func (s *S3Storage) syncCache(ctx context.Context, certName, eTag string) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	certPath := s.getCertificatePath(certName)
+	cachedETag, err := os.ReadFile(s.getETagPath(certName))
+
+	if err == nil && string(cachedETag) == eTag && com.IsFile(certPath) {
+		return certPath, nil
+	}
+
+	object, err := s.client.GetObject(ctx, s.bucket, s.getObjectName(certName), minio.GetObjectOptions{})
+
+	if err != nil {
+		return "", fmt.Errorf("could not download certificate %s: %v", certName, err)
+	}
+
+	defer object.Close()
+
+	content, err := io.ReadAll(object)
+
+	if err != nil {
+		return "", fmt.Errorf("could not download certificate %s: %v", certName, err)
+	}
+
+	if err := s.writeCache(certName, content, eTag); err != nil {
+		return "", err
+	}
+
+	return certPath, nil
+}
+
+func (s *S3Storage) writeCache(certName string, content []byte, eTag string) error {
+	if err := os.WriteFile(s.getCertificatePath(certName), content, 0600); err != nil {
+		return fmt.Errorf("could not cache certificate %s: %v", certName, err)
+	}
+
+	if err := os.WriteFile(s.getETagPath(certName), []byte(eTag), 0600); err != nil {
+		return fmt.Errorf("could not cache certificate %s: %v", certName, err)
+	}
+
+	return nil
+}
+
+func (s *S3Storage) getObjectPrefix() string {
+	prefix := strings.Trim(s.prefix, "/")
+
+	if prefix == "" {
+		return ""
+	}
+
+	return prefix + "/"
+}
+
+func (s *S3Storage) getObjectName(certName string) string {
+	return s.getObjectPrefix() + certName + ".pem"
+}
+
+func (s *S3Storage) getCertificatePath(certName string) string {
+	return filepath.Join(s.cachePath, certName+".pem")
+}
+
+func (s *S3Storage) getETagPath(certName string) string {
+	return filepath.Join(s.cachePath, "."+certName+".etag")
+}
+
+// validateCertName accepts only names of a single path element: the name is used as the object name under the prefix
+// and as the cache file name, so nested names could escape the prefix and collide in the cache
+func validateCertName(certName string) error {
+	if certName == "" || certName == "." || certName == ".." || strings.ContainsAny(certName, `/\`) {
+		return fmt.Errorf("invalid certificate name '%s'", certName)
+	}
+
+	return nil
+}
+
+func CreateCertStorage(config *config.Config, logger logger.Logger) (*S3Storage, error) {
+	if !config.IsS3StorageEnabled() {
+		return nil, errors.New("s3 storage is not configured")
+	}
+
+	client, err := minio.New(config.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.S3AccessKey, config.S3SecretKey, ""),
+		Secure: config.S3UseSsl,
+		Region: config.S3Region,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("could not create s3 client: %v", err)
+	}
+
+	cachePath := config.GetPathInsideVarDir("s3", "certificates")
+
+	if !com.IsExist(cachePath) {
+		err := os.MkdirAll(cachePath, 0700)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &S3Storage{
+		RWMutex:   &sync.RWMutex{},
+		client:    client,
+		bucket:    config.S3Bucket,
+		prefix:    config.S3Prefix,
+		cachePath: cachePath,
+		logger:    logger,
+	}, nil
+}
@@ -0,0 +1,64 @@
+//go:build s3
+
+package s3
+
+import (
+	"os"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// Tests require a running S3 compatible server (e.g. MinIO) with an existing bucket:
+// SSLBOT_TEST_S3_ENDPOINT=localhost:9000 SSLBOT_TEST_S3_BUCKET=sslbot SSLBOT_TEST_S3_ACCESS_KEY=minioadmin SSLBOT_TEST_S3_SECRET_KEY=minioadmin
+func TestS3Storage(t *testing.T) {
+	storage := getStorage(t)
+
+	data, err := os.ReadFile("../../../test/certificate/example.com.pem")
+	assert.Nil(t, err)
+
+	certPath, err := storage.AddPemCertificate("example.com", string(data))
+	assert.Nil(t, err)
+
+	certs, err := storage.GetCertificates()
+	assert.Nil(t, err)
+
+	cert, ok := certs["example.com"]
+	assert.True(t, ok)
+	assert.Equal(t, "example.com", cert.CN)
+
+	// local copy is restored from the bucket
+	assert.Nil(t, os.Remove(certPath))
+
+	path, keyPath, err := storage.GetCertificatePath("example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, certPath, path)
+	assert.Equal(t, certPath, keyPath)
+
+	_, content, err := storage.GetCertificateAsString("example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), content)
+
+	err = storage.RemoveCertificate("example.com")
+	assert.Nil(t, err)
+
+	_, err = storage.GetCertificate("example.com")
+	assert.NotNil(t, err)
+}
+
+func getStorage(t *testing.T) *S3Storage {
+	conf := &config.Config{
+		VarDir:      t.TempDir(),
+		S3Endpoint:  os.Getenv("SSLBOT_TEST_S3_ENDPOINT"),
+		S3Bucket:    os.Getenv("SSLBOT_TEST_S3_BUCKET"),
+		S3AccessKey: os.Getenv("SSLBOT_TEST_S3_ACCESS_KEY"),
+		S3SecretKey: os.Getenv("SSLBOT_TEST_S3_SECRET_KEY"),
+		S3Prefix:    "test",
+	}
+	storage, err := CreateCertStorage(conf, &logger.TestLogger{T: t})
+	assert.Nil(t, err)
+
+	return storage
+}
@@ -0,0 +1,204 @@
+package tlsprofile
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/goapacheconf"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+)
+
+const (
+	apacheProtocol         = "SSLProtocol"
+	apacheCipherSuite      = "SSLCipherSuite"
+	apacheHonorCipherOrder = "SSLHonorCipherOrder"
+	apacheSessionTickets   = "SSLSessionTickets"
+)
+
+// apacheProtocols are protocols enabled by "all" keyword of SSLProtocol directive
+var apacheProtocols = []string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"}
+
+var apacheSettingNames = []string{
+	apacheProtocol,
+	apacheCipherSuite,
+	apacheHonorCipherOrder,
+	apacheSessionTickets,
+}
+
+type ApacheTlsProfileQuery struct {
+	webServer *webserver.ApacheWebServer
+}
+
+func (q *ApacheTlsProfileQuery) GetTlsProfile(serverName string) string {
+	vHostBlocks := q.webServer.FindSslVirtualHostBlocksByServerName(serverName)
+
+	if len(vHostBlocks) == 0 {
+		return ""
+	}
+
+	vHostBlock := vHostBlocks[0]
+	protocols := vHostBlock.FindDirectives(apacheProtocol)
+	cipherSuites := vHostBlock.FindDirectives(apacheCipherSuite)
+	honorCipherOrders := vHostBlock.FindDirectives(apacheHonorCipherOrder)
+
+	if len(protocols) == 0 && len(cipherSuites) == 0 && len(honorCipherOrders) == 0 {
+		return ""
+	}
+
+	var protocolValues []string
+	var cipherValue string
+	var honorCipherOrderValue bool
+
+	if len(protocols) > 0 {
+		protocolValues = parseApacheProtocols(protocols[len(protocols)-1].GetValues())
+	}
+
+	if len(cipherSuites) > 0 {
+		cipherValue = strings.Trim(cipherSuites[len(cipherSuites)-1].GetFirstValue(), `"'`)
+	}
+
+	if len(honorCipherOrders) > 0 {
+		honorCipherOrderValue = strings.EqualFold(honorCipherOrders[len(honorCipherOrders)-1].GetFirstValue(), "on")
+	}
+
+	return detectProfile(protocolValues, cipherValue, honorCipherOrderValue)
+}
+
+type ApacheTlsProfileChangeCommand struct {
+	webServer *webserver.ApacheWebServer
+	reverter  reverter.Reverter
+	logger    logger.Logger
+	mx        *sync.Mutex
+}
+
+// ApplyTlsProfile sets the profile directives in all ssl virtual hosts of the host.
+// Session cache can be set only globally and DH parameters are built in apache, so they are left as is.
+func (c *ApacheTlsProfileChangeCommand) ApplyTlsProfile(serverName string, profileName string) error {
+	profile, err := GetProfile(profileName)
+
+	if err != nil {
+		return err
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	vHostBlocks := c.webServer.FindSslVirtualHostBlocksByServerName(serverName)
+
+	if len(vHostBlocks) == 0 {
+		return fmt.Errorf("apache ssl host %s does not exist", serverName)
+	}
+
+	settings := getApacheSettings(profile)
+
+	for _, vHostBlock := range vHostBlocks {
+		blockContent := vHostBlock.Dump()
+
+		for _, name := range apacheSettingNames {
+			directives := vHostBlock.FindDirectives(name)
+
+			if len(directives) > 0 && !webserver.IsDirectiveInBlock(blockContent, name) {
+				return fmt.Errorf("%s directive of apache host %s is set in an included file", name, serverName)
+			}
+
+			values, ok := settings[name]
+
+			if !ok {
+				for _, directive := range directives {
+					vHostBlock.DeleteDirective(directive)
+				}
+
+				continue
+			}
+
+			if len(directives) == 0 {
+				directive := goapacheconf.NewDirective(name, values)
+				directive.AppendNewLine()
+				vHostBlock.AppendDirective(directive)
+
+				continue
+			}
+
+			directives[0].SetValues(values)
+
+			for _, directive := range directives[1:] {
+				vHostBlock.DeleteDirective(directive)
+			}
+		}
+	}
+
+	var filePaths []string
+
+	for _, vHostBlock := range vHostBlocks {
+		filePaths = append(filePaths, vHostBlock.FilePath)
+	}
+
+	return reverter.ApplyConfigFileChanges(c.webServer, c.reverter, c.logger, filePaths)
+}
+
+// getApacheSettings returns directive values of the profile, directives missing in the map are not used by the profile
+func getApacheSettings(profile Profile) map[string][]string {
+	// protocols are disabled explicitly instead of enabling them for compatibility with apache built without TLSv1.3
+	protocols := []string{"all", "-SSLv3"}
+
+	for _, protocol := range apacheProtocols {
+		if !slices.Contains(profile.Protocols, protocol) {
+			protocols = append(protocols, "-"+protocol)
+		}
+	}
+
+	honorCipherOrder := "off"
+
+	if profile.PreferServerCiphers {
+		honorCipherOrder = "on"
+	}
+
+	settings := map[string][]string{
+		apacheProtocol:         protocols,
+		apacheHonorCipherOrder: {honorCipherOrder},
+		apacheSessionTickets:   {"off"},
+	}
+
+	if profile.Ciphers != "" {
+		settings[apacheCipherSuite] = []string{profile.Ciphers}
+	}
+
+	return settings
+}
+
+// parseApacheProtocols returns protocols enabled by SSLProtocol values, e.g. "all -TLSv1 -TLSv1.1"
+func parseApacheProtocols(values []string) []string {
+	var protocols []string
+
+	for _, value := range values {
+		var names []string
+		name := strings.TrimLeft(value, "+-")
+
+		if strings.EqualFold(name, "all") {
+			names = apacheProtocols
+		} else if slices.Contains(apacheProtocols, name) {
+			names = []string{name}
+		}
+
+		switch {
+		case strings.HasPrefix(value, "+"):
+			for _, name := range names {
+				if !slices.Contains(protocols, name) {
+					protocols = append(protocols, name)
+				}
+			}
+		case strings.HasPrefix(value, "-"):
+			protocols = slices.DeleteFunc(protocols, func(protocol string) bool {
+				return slices.Contains(names, protocol)
+			})
+		default:
+			protocols = slices.Clone(names)
+		}
+	}
+
+	return protocols
+}
@@ -0,0 +1,75 @@
+//go:build apache
+
+package tlsprofile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const apacheTlsHostConfig = `LoadModule ssl_module modules/mod_ssl.so
+
+<VirtualHost *:443>
+    ServerName example.com
+    DocumentRoot /var/www/html
+    SSLEngine on
+    SSLCertificateFile /etc/ssl/example.com.crt
+    SSLCertificateKeyFile /etc/ssl/example.com.key
+    SSLProtocol all
+    SSLProtocol -SSLv3
+</VirtualHost>
+`
+
+func TestApacheApplyTlsProfile(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "apache2.conf")
+	require.Nil(t, os.WriteFile(configPath, []byte(apacheTlsHostConfig), 0644))
+
+	options := map[string]string{
+		config.ApacheRootOpt:           dir,
+		config.ApachectlBinOpt:         "true",
+		config.ApacheReloadStrategyOpt: "command",
+		config.ApacheReloadCommandOpt:  "true",
+	}
+
+	for _, profile := range []string{ProfileOld, ProfileModern, ProfileIntermediate} {
+		command, _ := getApacheTlsProfile(t, options)
+		require.Nil(t, command.ApplyTlsProfile("example.com", profile))
+
+		_, query := getApacheTlsProfile(t, options)
+		assert.Equal(t, profile, query.GetTlsProfile("example.com"))
+	}
+
+	content, err := os.ReadFile(configPath)
+	require.Nil(t, err)
+	assert.Equal(t, 1, strings.Count(string(content), apacheProtocol))
+	assert.Equal(t, 1, strings.Count(string(content), apacheSessionTickets))
+}
+
+func getApacheTlsProfile(t *testing.T, options map[string]string) (TlsProfileChangeCommand, TlsProfileQuery) {
+	log := &logger.TestLogger{T: t}
+
+	apacheWebServer, err := webserver.GetApacheWebServer(options)
+	require.Nil(t, err)
+
+	rv, err := reverter.CreateReverter(apacheWebServer, log)
+	require.Nil(t, err)
+
+	command, err := CreateTlsProfileChangeCommand(apacheWebServer, rv, log, &sync.Mutex{})
+	require.Nil(t, err)
+
+	query, err := CreateTlsProfileQuery(apacheWebServer)
+	require.Nil(t, err)
+
+	return command, query
+}
@@ -0,0 +1,194 @@
+package tlsprofile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/gonginxconf/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+)
+
+const (
+	nginxProtocols           = "ssl_protocols"
+	nginxCiphers             = "ssl_ciphers"
+	nginxPreferServerCiphers = "ssl_prefer_server_ciphers"
+	nginxSessionTimeout      = "ssl_session_timeout"
+	nginxSessionCache        = "ssl_session_cache"
+	nginxSessionTickets      = "ssl_session_tickets"
+	nginxDhParam             = "ssl_dhparam"
+	nginxDhParamsFileName    = "ffdhe2048.pem"
+)
+
+type NginxTlsProfileQuery struct {
+	webServer *webserver.NginxWebServer
+}
+
+func (q *NginxTlsProfileQuery) GetTlsProfile(serverName string) string {
+	serverBlocks := q.webServer.FindSslServerBlocksByServerName(serverName)
+
+	if len(serverBlocks) == 0 {
+		return ""
+	}
+
+	serverBlock := serverBlocks[0]
+	protocols := serverBlock.FindDirectives(nginxProtocols)
+	ciphers := serverBlock.FindDirectives(nginxCiphers)
+	preferServerCiphers := serverBlock.FindDirectives(nginxPreferServerCiphers)
+
+	if len(protocols) == 0 && len(ciphers) == 0 && len(preferServerCiphers) == 0 {
+		return ""
+	}
+
+	var protocolValues []string
+	var cipherValue string
+	var preferServerCiphersValue bool
+
+	if len(protocols) > 0 {
+		protocolValues = protocols[len(protocols)-1].GetValues()
+	}
+
+	if len(ciphers) > 0 {
+		cipherValue = strings.Trim(ciphers[len(ciphers)-1].GetFirstValue(), `"'`)
+	}
+
+	if len(preferServerCiphers) > 0 {
+		preferServerCiphersValue = preferServerCiphers[len(preferServerCiphers)-1].GetFirstValue() == "on"
+	}
+
+	return detectProfile(protocolValues, cipherValue, preferServerCiphersValue)
+}
+
+type NginxTlsProfileChangeCommand struct {
+	webServer *webserver.NginxWebServer
+	reverter  reverter.Reverter
+	logger    logger.Logger
+	mx        *sync.Mutex
+}
+
+// ApplyTlsProfile sets the profile directives in all ssl server blocks of the host.
+// Directives that are not used by the profile are removed.
+func (c *NginxTlsProfileChangeCommand) ApplyTlsProfile(serverName string, profileName string) error {
+	profile, err := GetProfile(profileName)
+
+	if err != nil {
+		return err
+	}
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	serverBlocks := c.webServer.FindSslServerBlocksByServerName(serverName)
+
+	if len(serverBlocks) == 0 {
+		return fmt.Errorf("nginx ssl host %s does not exist", serverName)
+	}
+
+	dhParamsPath := filepath.Join(filepath.Dir(c.webServer.GetConfigFilePath()), nginxDhParamsFileName)
+
+	if profile.DhParams {
+		if err := writeDhParams(dhParamsPath, c.reverter); err != nil {
+			return err
+		}
+	}
+
+	settings := getNginxSettings(profile, dhParamsPath)
+
+	for _, serverBlock := range serverBlocks {
+		blockContent := serverBlock.Dump()
+
+		for _, name := range nginxSettingNames {
+			directives := serverBlock.FindDirectives(name)
+
+			if len(directives) > 0 && !webserver.IsDirectiveInBlock(blockContent, name) {
+				return fmt.Errorf("%s directive of nginx host %s is set in an included file", name, serverName)
+			}
+
+			values, ok := settings[name]
+
+			if !ok {
+				for _, directive := range directives {
+					serverBlock.DeleteDirective(directive)
+				}
+
+				continue
+			}
+
+			if len(directives) == 0 {
+				serverBlock.AddDirective(config.NewDirective(name, values), false, true)
+
+				continue
+			}
+
+			directives[0].SetValues(values)
+
+			for _, directive := range directives[1:] {
+				serverBlock.DeleteDirective(directive)
+			}
+		}
+	}
+
+	var filePaths []string
+
+	for _, serverBlock := range serverBlocks {
+		filePaths = append(filePaths, serverBlock.FilePath)
+	}
+
+	return reverter.ApplyConfigFileChanges(c.webServer, c.reverter, c.logger, filePaths)
+}
+
+var nginxSettingNames = []string{
+	nginxProtocols,
+	nginxCiphers,
+	nginxPreferServerCiphers,
+	nginxSessionTimeout,
+	nginxSessionCache,
+	nginxSessionTickets,
+	nginxDhParam,
+}
+
+// getNginxSettings returns directive values of the profile, directives missing in the map are not used by the profile
+func getNginxSettings(profile Profile, dhParamsPath string) map[string][]string {
+	preferServerCiphers := "off"
+
+	if profile.PreferServerCiphers {
+		preferServerCiphers = "on"
+	}
+
+	settings := map[string][]string{
+		nginxProtocols:           profile.Protocols,
+		nginxPreferServerCiphers: {preferServerCiphers},
+		nginxSessionTimeout:      {"1d"},
+		nginxSessionCache:        {"shared:MozSSL:10m"},
+		nginxSessionTickets:      {"off"},
+	}
+
+	if profile.Ciphers != "" {
+		settings[nginxCiphers] = []string{profile.Ciphers}
+	}
+
+	if profile.DhParams {
+		settings[nginxDhParam] = []string{dhParamsPath}
+	}
+
+	return settings
+}
+
+// writeDhParams writes the dh parameters file if it does not exist, the new file is removed on rollback
+func writeDhParams(path string, reverter reverter.Reverter) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.WriteFile(path, []byte(dhParams), 0644); err != nil {
+		return fmt.Errorf("could not write dh parameters file: %v", err)
+	}
+
+	reverter.AddConfigToDeletion(path)
+
+	return nil
+}
@@ -0,0 +1,65 @@
+//go:build common
+
+package tlsprofile
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nginxSslHostConfig = `events {}
+
+http {
+    server {
+        listen 443 ssl;
+        server_name example.com;
+        ssl_certificate /etc/ssl/example.com.crt;
+        ssl_certificate_key /etc/ssl/example.com.key;
+    }
+}
+`
+
+func TestNginxApplyTlsProfileRemovesDhParamsOnRollback(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(nginxSslHostConfig), 0644))
+	dhParamsPath := filepath.Join(dir, nginxDhParamsFileName)
+
+	options := map[string]string{
+		config.NginxRootOpt:           dir,
+		config.NginxBinOpt:            "true",
+		config.NginxReloadStrategyOpt: "command",
+		config.NginxReloadCommandOpt:  "false",
+	}
+	err := getNginxTempTlsProfileCommand(t, options).ApplyTlsProfile("example.com", ProfileIntermediate)
+	require.NotNil(t, err)
+	assert.NoFileExists(t, dhParamsPath)
+
+	options[config.NginxReloadCommandOpt] = "true"
+	err = getNginxTempTlsProfileCommand(t, options).ApplyTlsProfile("example.com", ProfileIntermediate)
+	require.Nil(t, err)
+	assert.FileExists(t, dhParamsPath)
+}
+
+func getNginxTempTlsProfileCommand(t *testing.T, options map[string]string) TlsProfileChangeCommand {
+	log := &logger.TestLogger{T: t}
+
+	nginxWebServer, err := webserver.GetNginxWebServer(options)
+	require.Nil(t, err)
+
+	rv, err := reverter.CreateReverter(nginxWebServer, log)
+	require.Nil(t, err)
+
+	command, err := CreateTlsProfileChangeCommand(nginxWebServer, rv, log, &sync.Mutex{})
+	require.Nil(t, err)
+
+	return command
+}
@@ -0,0 +1,45 @@
+//go:build nginx
+
+package tlsprofile
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNginxApplyTlsProfile(t *testing.T) {
+	host := "example4.com"
+	config, err := config.GetConfig()
+	require.Nil(t, err)
+
+	nginxWebServer, err := webserver.GetNginxWebServer(config.ToMap())
+	require.Nil(t, err)
+
+	rv, err := reverter.CreateReverter(nginxWebServer, &logger.TestLogger{T: t})
+	require.Nil(t, err)
+	defer rv.Rollback()
+
+	query, err := CreateTlsProfileQuery(nginxWebServer)
+	require.Nil(t, err)
+	assert.Equal(t, "", query.GetTlsProfile(host))
+
+	command, err := CreateTlsProfileChangeCommand(nginxWebServer, rv, &logger.TestLogger{T: t}, &sync.Mutex{})
+	require.Nil(t, err)
+
+	for _, profile := range []string{ProfileOld, ProfileModern, ProfileIntermediate} {
+		err = command.ApplyTlsProfile(host, profile)
+		require.Nil(t, err)
+		assert.Equal(t, profile, query.GetTlsProfile(host))
+	}
+
+	serverBlock := nginxWebServer.FindSslServerBlocksByServerName(host)[0]
+	assert.Len(t, serverBlock.FindDirectives(nginxProtocols), 1)
+	assert.Len(t, serverBlock.FindDirectives(nginxDhParam), 1)
+}
@@ -0,0 +1,178 @@
+package tlsprofile
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/r2dtools/sslbot/internal/webserver/reverter"
+)
+
+const (
+	ProfileModern       = "modern"
+	ProfileIntermediate = "intermediate"
+	ProfileOld          = "old"
+	// ProfileCustom is reported for hosts with TLS settings that do not match any profile
+	ProfileCustom = "custom"
+)
+
+const (
+	intermediateCiphers = "ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:" +
+		"ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:" +
+		"DHE-RSA-AES128-GCM-SHA256:DHE-RSA-AES256-GCM-SHA384:DHE-RSA-CHACHA20-POLY1305"
+	oldCiphers = intermediateCiphers + ":ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256:ECDHE-ECDSA-AES128-SHA:" +
+		"ECDHE-RSA-AES128-SHA:ECDHE-ECDSA-AES256-SHA384:ECDHE-RSA-AES256-SHA384:ECDHE-ECDSA-AES256-SHA:" +
+		"ECDHE-RSA-AES256-SHA:DHE-RSA-AES128-SHA256:DHE-RSA-AES256-SHA256:AES128-GCM-SHA256:AES256-GCM-SHA384:" +
+		"AES128-SHA256:AES256-SHA256:AES128-SHA:AES256-SHA:DES-CBC3-SHA"
+)
+
+// ffdhe2048 group from RFC 7919 recommended for DHE ciphers
+const dhParams = `-----BEGIN DH PARAMETERS-----
+MIIBCAKCAQEA//////////+t+FRYortKmq/cViAnPTzx2LnFg84tNpWp4TZBFGQz
++8yTnc4kmz75fS/jY2MMddj2gbICrsRhetPfHtXV/WVhJDP1H18GbtCFY2VVPe0a
+87VXE15/V8k1mE8McODmi3fipona8+/och3xWKE2rec1MKzKT0g6eXq8CrGCsyT7
+YdEIqUuyyOP7uWrat2DX9GgdT0Kj3jlN9K5W7edjcrsZCwenyO4KbXCeAvzhzffi
+7MA0BM0oNC9hkXL+nOmFg/+OTxIy7vKBg8P+OxtMb61zO7X8vC7CIAXFjvGDfRaD
+ssbzSibBsu/6iGtCOGEoXJf//////////wIBAg==
+-----END DH PARAMETERS-----
+`
+
+// Profile is a set of TLS settings based on Mozilla SSL configuration guidelines
+type Profile struct {
+	Name      string
+	Protocols []string
+	// Ciphers are TLSv1.2 and older ciphers, TLSv1.3 ciphers are not configurable
+	Ciphers             string
+	PreferServerCiphers bool
+	// DhParams is set for profiles with DHE ciphers
+	DhParams bool
+}
+
+var profiles = []Profile{
+	{
+		Name:      ProfileModern,
+		Protocols: []string{"TLSv1.3"},
+	},
+	{
+		Name:      ProfileIntermediate,
+		Protocols: []string{"TLSv1.2", "TLSv1.3"},
+		Ciphers:   intermediateCiphers,
+		DhParams:  true,
+	},
+	{
+		Name:                ProfileOld,
+		Protocols:           []string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"},
+		Ciphers:             oldCiphers,
+		PreferServerCiphers: true,
+		DhParams:            true,
+	},
+}
+
+type TlsProfileQuery interface {
+	// GetTlsProfile returns the profile name of the host settings, custom if they do not match any profile
+	// and an empty string if the host uses webserver defaults
+	GetTlsProfile(serverName string) string
+}
+
+type TlsProfileChangeCommand interface {
+	ApplyTlsProfile(serverName string, profileName string) error
+}
+
+func GetProfile(name string) (Profile, error) {
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+
+	return Profile{}, fmt.Errorf("invalid tls profile %s, supported profiles: %s", name, strings.Join(GetProfileNames(), ", "))
+}
+
+func GetProfileNames() []string {
+	var names []string
+
+	for _, profile := range profiles {
+		names = append(names, profile.Name)
+	}
+
+	return names
+}
+
+func CreateTlsProfileQuery(webServer webserver.WebServer) (TlsProfileQuery, error) {
+	switch w := webServer.(type) {
+	case *webserver.NginxWebServer:
+		return &NginxTlsProfileQuery{webServer: w}, nil
+	case *webserver.ApacheWebServer:
+		return &ApacheTlsProfileQuery{webServer: w}, nil
+	default:
+		return nil, fmt.Errorf("webserver %s is not supported", webServer.GetCode())
+	}
+}
+
+func CreateTlsProfileChangeCommand(
+	webServer webserver.WebServer,
+	reverter reverter.Reverter,
+	logger logger.Logger,
+	mx *sync.Mutex,
+) (TlsProfileChangeCommand, error) {
+	switch w := webServer.(type) {
+	case *webserver.NginxWebServer:
+		return &NginxTlsProfileChangeCommand{
+			logger:    logger,
+			webServer: w,
+			reverter:  reverter,
+			mx:        mx,
+		}, nil
+	case *webserver.ApacheWebServer:
+		return &ApacheTlsProfileChangeCommand{
+			logger:    logger,
+			webServer: w,
+			reverter:  reverter,
+			mx:        mx,
+		}, nil
+	default:
+		return nil, fmt.Errorf("webserver %s is not supported", webServer.GetCode())
+	}
+}
+
+// ResolveVhostTlsProfiles sets the TLS profile of the webserver ssl hosts. Hosts are left as is if the webserver is not supported.
+func ResolveVhostTlsProfiles(webServer webserver.WebServer, vhosts []dto.VirtualHost) []dto.VirtualHost {
+	query, err := CreateTlsProfileQuery(webServer)
+
+	if err != nil {
+		return vhosts
+	}
+
+	for i := range vhosts {
+		if vhosts[i].Ssl {
+			vhosts[i].TlsProfile = query.GetTlsProfile(vhosts[i].ServerName)
+		}
+	}
+
+	return vhosts
+}
+
+// detectProfile returns the profile matching the settings. Protocol order does not matter.
+func detectProfile(protocols []string, ciphers string, preferServerCiphers bool) string {
+	for _, profile := range profiles {
+		if profile.Ciphers != ciphers || profile.PreferServerCiphers != preferServerCiphers {
+			continue
+		}
+
+		if len(profile.Protocols) != len(protocols) {
+			continue
+		}
+
+		if !slices.ContainsFunc(protocols, func(protocol string) bool {
+			return !slices.Contains(profile.Protocols, protocol)
+		}) {
+			return profile.Name
+		}
+	}
+
+	return ProfileCustom
+}
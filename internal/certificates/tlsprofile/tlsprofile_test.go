@@ -0,0 +1,45 @@
+//go:build common
+
+package tlsprofile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectProfile(t *testing.T) {
+	assert.Equal(t, ProfileModern, detectProfile([]string{"TLSv1.3"}, "", false))
+	assert.Equal(t, ProfileIntermediate, detectProfile([]string{"TLSv1.3", "TLSv1.2"}, intermediateCiphers, false))
+	assert.Equal(t, ProfileOld, detectProfile([]string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"}, oldCiphers, true))
+	assert.Equal(t, ProfileCustom, detectProfile([]string{"TLSv1.2", "TLSv1.3"}, intermediateCiphers, true))
+	assert.Equal(t, ProfileCustom, detectProfile([]string{"TLSv1.2"}, "HIGH:!aNULL:!MD5", false))
+}
+
+func TestParseApacheProtocols(t *testing.T) {
+	assert.Equal(t, []string{"TLSv1.2", "TLSv1.3"}, parseApacheProtocols([]string{"all", "-SSLv3", "-TLSv1", "-TLSv1.1"}))
+	assert.Equal(t, []string{"TLSv1.3"}, parseApacheProtocols([]string{"-all", "+TLSv1.3"}))
+	assert.Equal(t, []string{"TLSv1.2"}, parseApacheProtocols([]string{"TLSv1.2"}))
+}
+
+func TestApacheSettingsMatchProfile(t *testing.T) {
+	for _, name := range GetProfileNames() {
+		profile, err := GetProfile(name)
+		assert.Nil(t, err)
+
+		settings := getApacheSettings(profile)
+		ciphers := ""
+
+		if cipherSuite, ok := settings[apacheCipherSuite]; ok {
+			ciphers = cipherSuite[0]
+		}
+
+		protocols := parseApacheProtocols(settings[apacheProtocol])
+		assert.Equal(t, name, detectProfile(protocols, ciphers, settings[apacheHonorCipherOrder][0] == "on"))
+	}
+}
+
+func TestGetProfile(t *testing.T) {
+	_, err := GetProfile("strict")
+	assert.ErrorContains(t, err, "invalid tls profile strict")
+}
@@ -18,3 +18,10 @@ type Issuer struct {
 	CN           string
 	Organization []string
 }
+
+type CertificateVersion struct {
+	ID          string
+	CreatedAt   string
+	Fingerprint string
+	Certificate *Certificate
+}
@@ -0,0 +1,9 @@
+package dto
+
+// ConfigDiff is a planned change of the webserver config file in unified diff format
+type ConfigDiff struct {
+	FilePath string
+	// Symlink is set if the file is a symlink, the diff shows the change of the symlink target
+	Symlink bool
+	Diff    string
+}
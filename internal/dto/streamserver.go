@@ -0,0 +1,19 @@
+package dto
+
+// StreamServer is a server that proxies TCP/TLS connections, e.g. a server of nginx stream block.
+// Such servers have no host names and are identified by the listen address: the port if the server listens
+// on all addresses, otherwise host and port, e.g. "127.0.0.1:5432".
+type StreamServer struct {
+	Key string
+	// Name is an optional friendly name of the server
+	Name               string
+	FilePath           string
+	WebServer          string
+	Ssl                bool
+	Addresses          []VirtualHostAddress
+	Certificate        *Certificate
+	CertificatePath    string
+	CertificateKeyPath string
+	CertificateStorage string
+	CertificateName    string
+}
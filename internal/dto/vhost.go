@@ -1,18 +1,63 @@
 package dto
 
+// Server name match types. Names of wildcard prefix type are "*.example.com" and ".example.com",
+// names of wildcard suffix type are "www.example.*", regex names start with "~".
+const (
+	MatchTypeExact          = "exact"
+	MatchTypeWildcardPrefix = "wildcard_prefix"
+	MatchTypeWildcardSuffix = "wildcard_suffix"
+	MatchTypeRegex          = "regex"
+)
+
 type VirtualHost struct {
 	FilePath    string
 	ServerName  string
+	MatchType   string
 	DocRoot     string
 	WebServer   string
 	Aliases     []string
 	Ssl         bool
 	Addresses   []VirtualHostAddress
 	Certificate *Certificate
+	// CertificatePath is the path of the certificate file referenced by the host
+	CertificatePath    string
+	CertificateKeyPath string
+	// CertificateStorage and CertificateName identify the storage certificate used by the host.
+	// CertificateStorage is "unmanaged" if the certificate is outside every storage.
+	CertificateStorage string
+	CertificateName    string
+	// TlsProfile is the TLS hardening profile of the ssl host, "custom" if settings do not match any profile
+	TlsProfile string
+	// CertificateCoverage is set for hosts with a certificate
+	CertificateCoverage *CertificateCoverage
+}
+
+// CertificateCoverage shows which host names are covered by the host certificate.
+// Regex and wildcard suffix names can not be covered by a certificate and are skipped.
+type CertificateCoverage struct {
+	CoveredNames   []string
+	UncoveredNames []string
+	// WildcardMatches maps covered host names to the wildcard certificate names covering them
+	WildcardMatches map[string]string
+}
+
+// IsDefaultServer checks if the host is the default server of any of its addresses
+func (v *VirtualHost) IsDefaultServer() bool {
+	for _, address := range v.Addresses {
+		if address.DefaultServer {
+			return true
+		}
+	}
+
+	return false
 }
 
 type VirtualHostAddress struct {
 	IsIpv6 bool
 	Host   string
 	Port   string
+	// DefaultServer is set if the host serves requests to the address with unknown host names
+	DefaultServer bool
+	// Ssl is set if connections to the address use TLS
+	Ssl bool
 }
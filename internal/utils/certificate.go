@@ -1,13 +1,16 @@
 package utils
 
 import (
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"net"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/r2dtools/sslbot/internal/dto"
@@ -15,7 +18,13 @@ import (
 )
 
 func GetX509CertificateFromRequest(domain string) ([]*x509.Certificate, error) {
-	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: time.Minute}, "tcp", domain+":443", &tls.Config{InsecureSkipVerify: true})
+	return GetX509CertificateFromAddress(domain+":443", domain, time.Minute)
+}
+
+// GetX509CertificateFromAddress returns certificates served on the address for the SNI server name
+func GetX509CertificateFromAddress(address, serverName string, timeout time.Duration) ([]*x509.Certificate, error) {
+	tlsConfig := &tls.Config{ServerName: serverName, InsecureSkipVerify: true}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", address, tlsConfig)
 
 	if err != nil {
 		return nil, err
@@ -77,6 +86,32 @@ func GetCertificateForDomainFromRequest(domain string) (*dto.Certificate, error)
 	return ConvertX509CertificateToIntCert(certs[0], roots), nil
 }
 
+// GetCertificateFingerprint returns SHA-256 fingerprint of the first certificate in the pem data
+func GetCertificateFingerprint(pemData []byte) (string, error) {
+	for {
+		block, rest := pem.Decode(pemData)
+
+		if block == nil {
+			break
+		}
+
+		if block.Type == "CERTIFICATE" {
+			return GetX509CertificateFingerprint(block.Bytes), nil
+		}
+
+		pemData = rest
+	}
+
+	return "", errors.New("could not parse certificate")
+}
+
+// GetX509CertificateFingerprint returns hex encoded SHA-256 hash of the DER encoded certificate
+func GetX509CertificateFingerprint(der []byte) string {
+	hash := sha256.Sum256(der)
+
+	return hex.EncodeToString(hash[:])
+}
+
 func GetCertificateFromFile(path string) (*dto.Certificate, error) {
 	if !com.IsFile(path) {
 		return nil, fmt.Errorf("certificate file '%s' does not exist", path)
@@ -118,3 +153,36 @@ func GetCertificateFromFile(path string) (*dto.Certificate, error) {
 
 	return cert, nil
 }
+
+// IsCertificateForDomain checks if the certificate names cover the domain. Wildcard names cover one label.
+func IsCertificateForDomain(cert *dto.Certificate, domain string) bool {
+	names := cert.DNSNames
+
+	if len(names) == 0 && cert.CN != "" {
+		names = []string{cert.CN}
+	}
+
+	domain = strings.ToLower(domain)
+
+	for _, name := range names {
+		if MatchDomainName(strings.ToLower(name), domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func MatchDomainName(pattern, domain string) bool {
+	if pattern == domain {
+		return true
+	}
+
+	if !strings.HasPrefix(pattern, "*.") {
+		return false
+	}
+
+	index := strings.Index(domain, ".")
+
+	return index > 0 && domain[index:] == pattern[1:]
+}
@@ -13,3 +13,11 @@ func TestGetCertificateFromFile(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, []string{"example.com", "www.example.com"}, cert.DNSNames)
 }
+
+func TestMatchDomainName(t *testing.T) {
+	assert.True(t, MatchDomainName("example.com", "example.com"))
+	assert.True(t, MatchDomainName("*.example.com", "www.example.com"))
+	assert.False(t, MatchDomainName("*.example.com", "example.com"))
+	assert.False(t, MatchDomainName("*.example.com", "a.www.example.com"))
+	assert.False(t, MatchDomainName("www.example.com", "example.com"))
+}
@@ -1,6 +1,10 @@
 package utils
 
-import "os"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+)
 
 func IsSymlink(path string) (bool, error) {
 	var isSymlink bool
@@ -14,3 +18,10 @@ func IsSymlink(path string) (bool, error) {
 
 	return isSymlink, nil
 }
+
+// GetContentChecksum returns hex encoded SHA-256 hash of the file content
+func GetContentChecksum(content []byte) string {
+	hash := sha256.Sum256(content)
+
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// ConvertPkcs12ToPem converts PKCS#12 (PFX) data to the pem format: leaf certificate, CA certificates and private key.
+func ConvertPkcs12ToPem(data []byte, password string) (string, error) {
+	privateKey, cert, caCerts, err := pkcs12.DecodeChain(data, password)
+
+	if err != nil {
+		return "", fmt.Errorf("could not decode PKCS#12 data: %v", err)
+	}
+
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
+
+	if err != nil {
+		return "", fmt.Errorf("could not encode private key: %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	for _, c := range append([]*x509.Certificate{cert}, caCerts...) {
+		if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}); err != nil {
+			return "", err
+		}
+	}
+
+	if err := pem.Encode(&buf, &pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// ConvertPemFilesToPkcs12 builds PKCS#12 (PFX) data from the certificate and key files. Both paths can point to the same file.
+func ConvertPemFilesToPkcs12(certPath, keyPath, password string) ([]byte, error) {
+	certContent, err := os.ReadFile(certPath)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read certificate content: %v", err)
+	}
+
+	keyContent := certContent
+
+	if keyPath != certPath {
+		keyContent, err = os.ReadFile(keyPath)
+
+		if err != nil {
+			return nil, fmt.Errorf("could not read private key content: %v", err)
+		}
+	}
+
+	var certs []*x509.Certificate
+
+	for {
+		block, rest := pem.Decode(certContent)
+
+		if block == nil {
+			break
+		}
+
+		if block.Type == "CERTIFICATE" {
+			cert, err := x509.ParseCertificate(block.Bytes)
+
+			if err != nil {
+				return nil, errors.New("could not parse certificate")
+			}
+
+			certs = append(certs, cert)
+		}
+
+		certContent = rest
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("could not parse certificate")
+	}
+
+	privateKey, err := parsePrivateKey(keyContent)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := pkcs12.Modern.Encode(privateKey, certs[0], certs[1:], password)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not encode PKCS#12 data: %v", err)
+	}
+
+	return data, nil
+}
+
+func parsePrivateKey(content []byte) (any, error) {
+	for {
+		block, rest := pem.Decode(content)
+
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "PRIVATE KEY":
+			return x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			return x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			return x509.ParseECPrivateKey(block.Bytes)
+		}
+
+		content = rest
+	}
+
+	return nil, errors.New("could not find private key")
+}
@@ -0,0 +1,34 @@
+//go:build common
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPkcs12RoundTrip(t *testing.T) {
+	certPath := "../../test/certificate/example.com.pem"
+	data, err := ConvertPemFilesToPkcs12(certPath, certPath, "secret")
+	assert.Nil(t, err)
+
+	_, err = ConvertPkcs12ToPem(data, "invalid")
+	assert.NotNil(t, err)
+
+	pemData, err := ConvertPkcs12ToPem(data, "secret")
+	assert.Nil(t, err)
+
+	pemPath := filepath.Join(t.TempDir(), "example.com.pem")
+	err = os.WriteFile(pemPath, []byte(pemData), 0644)
+	assert.Nil(t, err)
+
+	cert, err := GetCertificateFromFile(pemPath)
+	assert.Nil(t, err)
+	assert.Equal(t, "example.com", cert.CN)
+
+	_, err = ConvertPemFilesToPkcs12(pemPath, pemPath, "")
+	assert.Nil(t, err)
+}
@@ -2,6 +2,9 @@ package webserver
 
 import (
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/r2dtools/goapacheconf"
@@ -9,6 +12,7 @@ import (
 	"github.com/r2dtools/sslbot/internal/dto"
 	"github.com/r2dtools/sslbot/internal/utils"
 	"github.com/r2dtools/sslbot/internal/webserver/processmng"
+	"github.com/unknwon/com"
 )
 
 const (
@@ -20,6 +24,12 @@ type ApacheWebServer struct {
 	Config  *goapacheconf.Config
 	root    string
 	options map[string]string
+	// vhostIndex is set for webservers kept in the cache
+	vhostIndex *vhostIndex
+	// sandbox is set for webservers parsed from a sandbox config copy
+	sandbox bool
+	// reloadPrevented is set if the caller reloads the webserver itself
+	reloadPrevented bool
 }
 
 func (a *ApacheWebServer) GetCode() string {
@@ -27,6 +37,10 @@ func (a *ApacheWebServer) GetCode() string {
 }
 
 func (a *ApacheWebServer) GetVhosts() ([]dto.VirtualHost, error) {
+	if a.vhostIndex != nil {
+		return a.vhostIndex.getVhosts(), nil
+	}
+
 	var vhosts []dto.VirtualHost
 
 	aVhosts := a.Config.FindVirtualHostBlocks()
@@ -39,6 +53,7 @@ func (a *ApacheWebServer) GetVhosts() ([]dto.VirtualHost, error) {
 				IsIpv6: address.IsIpv6,
 				Host:   address.Host,
 				Port:   address.Port,
+				Ssl:    aVhost.HasSSL(),
 			})
 		}
 
@@ -48,55 +63,285 @@ func (a *ApacheWebServer) GetVhosts() ([]dto.VirtualHost, error) {
 			continue
 		}
 
+		certificate, certificatePath := getApacheCertificate(aVhost)
 		vhost := dto.VirtualHost{
-			FilePath:    strings.Trim(aVhost.FilePath, "\""),
-			ServerName:  strings.Trim(serverNames[0], "\""),
-			DocRoot:     strings.Trim(aVhost.GetDocumentRoot(), "\""),
-			Aliases:     aVhost.GetServerAliases(),
-			Ssl:         aVhost.HasSSL(),
-			WebServer:   WebServerApacheCode,
-			Addresses:   addresses,
-			Certificate: getApacheCertificate(aVhost),
+			FilePath:           strings.Trim(aVhost.FilePath, "\""),
+			ServerName:         strings.Trim(serverNames[0], "\""),
+			DocRoot:            strings.Trim(aVhost.GetDocumentRoot(), "\""),
+			Aliases:            aVhost.GetServerAliases(),
+			Ssl:                aVhost.HasSSL(),
+			WebServer:          getWebServerName(a.options, WebServerApacheCode),
+			Addresses:          addresses,
+			Certificate:        certificate,
+			CertificatePath:    certificatePath,
+			CertificateKeyPath: getApacheCertificateKeyPath(aVhost),
 		}
 		vhosts = append(vhosts, vhost)
 	}
 
+	markDefaultServers(vhosts)
 	vhosts = filterVhosts(vhosts)
 	vhosts = mergeVhosts(vhosts)
+	resolveCertificateCoverage(vhosts)
 
 	return vhosts, nil
 }
 
+// GetCertificateVhosts returns virtual hosts with a certificate including hosts without server name and on any port
+func (a *ApacheWebServer) GetCertificateVhosts() ([]dto.VirtualHost, error) {
+	var vhosts []dto.VirtualHost
+
+	for _, aVhost := range a.Config.FindVirtualHostBlocks() {
+		certificatePath := getApacheCertificatePath(aVhost)
+
+		if certificatePath == "" {
+			continue
+		}
+
+		var serverName string
+		var addresses []dto.VirtualHostAddress
+
+		if serverNames := aVhost.GetServerNames(); len(serverNames) > 0 {
+			serverName = strings.Trim(serverNames[0], "\"")
+		}
+
+		for _, address := range aVhost.GetAddresses() {
+			addresses = append(addresses, dto.VirtualHostAddress{
+				IsIpv6: address.IsIpv6,
+				Host:   address.Host,
+				Port:   address.Port,
+				Ssl:    aVhost.HasSSL(),
+			})
+		}
+
+		vhosts = append(vhosts, dto.VirtualHost{
+			FilePath:           strings.Trim(aVhost.FilePath, "\""),
+			ServerName:         serverName,
+			Ssl:                aVhost.HasSSL(),
+			WebServer:          getWebServerName(a.options, WebServerApacheCode),
+			Addresses:          addresses,
+			CertificatePath:    certificatePath,
+			CertificateKeyPath: getApacheCertificateKeyPath(aVhost),
+		})
+	}
+
+	return vhosts, nil
+}
+
+// GetVhostByName returns the host with the server name or the exact alias, ResolveVhost matches wildcard aliases
 func (a *ApacheWebServer) GetVhostByName(serverName string) (*dto.VirtualHost, error) {
+	if a.vhostIndex != nil {
+		return a.vhostIndex.getVhostByName(serverName), nil
+	}
+
+	vhosts, err := a.GetVhosts()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return createVhostIndex(vhosts).getVhostByName(serverName), nil
+}
+
+// ResolveVhost returns the host apache selects for the host name on the port, the port is optional
+func (a *ApacheWebServer) ResolveVhost(hostName, port string) (*dto.VirtualHost, error) {
 	vhosts, err := a.GetVhosts()
 
 	if err != nil {
 		return nil, err
 	}
 
-	return getVhostByName(vhosts, serverName), nil
+	return resolveApacheVhost(vhosts, hostName, port, true), nil
+}
+
+func (a *ApacheWebServer) indexVhosts() error {
+	vhosts, err := a.GetVhosts()
+
+	if err != nil {
+		return err
+	}
+
+	a.vhostIndex = createVhostIndex(vhosts)
+
+	return nil
+}
+
+func (a *ApacheWebServer) FindSslVirtualHostBlocksByServerName(serverName string) []goapacheconf.VirtualHostBlock {
+	var vHostBlocks []goapacheconf.VirtualHostBlock
+
+	for _, vHostBlock := range a.Config.FindVirtualHostBlocksByServerName(serverName) {
+		if vHostBlock.HasSSL() {
+			vHostBlocks = append(vHostBlocks, vHostBlock)
+		}
+	}
+
+	return vHostBlocks
+}
+
+// SaveConfigFiles writes changed config files to the disk
+func (a *ApacheWebServer) SaveConfigFiles(filePaths []string) error {
+	for _, filePath := range filePaths {
+		configFile := a.Config.GetConfigFile(filepath.Base(filePath))
+
+		if configFile == nil {
+			return fmt.Errorf("failed to find config file %s", filePath)
+		}
+
+		if _, err := configFile.Dump(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *ApacheWebServer) GetLayout() Layout {
+	return detectLayout(a.root)
+}
+
+func (a *ApacheWebServer) GetIncludePaths() []string {
+	var includes []string
+
+	for _, name := range []string{goapacheconf.Include, goapacheconf.IncludeOptional} {
+		for _, directive := range a.Config.FindDirectives(name) {
+			includes = append(includes, strings.Trim(directive.GetFirstValue(), "\"'"))
+		}
+	}
+
+	return getAbsIncludePaths(a.root, includes)
 }
 
 func (a *ApacheWebServer) GetProcessManager() (ProcessManager, error) {
-	return processmng.GetApacheProcessManager()
+	if a.sandbox || a.reloadPrevented {
+		return &nilProcessManager{}, nil
+	}
+
+	if processManager, ok := getInstanceProcessManager(a.options); ok {
+		return processManager, nil
+	}
+
+	strategy := a.options[config.ApacheReloadStrategyOpt]
+	processManager, ok, err := getStrategyProcessManager(strategy, a.getSystemdUnit(), a.options[config.ApacheReloadCommandOpt])
+
+	if ok {
+		return processManager, err
+	}
+
+	switch strategy {
+	case "", processmng.ReloadStrategySignal:
+		return processmng.GetApacheProcessManager(a.getPidFile())
+	case processmng.ReloadStrategyApachectl:
+		return processmng.GetExecProcessManager(a.getApachectlBin(), "graceful"), nil
+	default:
+		return nil, fmt.Errorf("apache reload strategy '%s' is not supported", strategy)
+	}
 }
 
-func getApacheCertificate(virtualHostBlock goapacheconf.VirtualHostBlock) *dto.Certificate {
+func (a *ApacheWebServer) TestConfig() error {
+	if a.sandbox {
+		return nil
+	}
+
+	return processmng.RunCommand(a.getApachectlBin(), "-t", "-f", a.GetConfigFilePath())
+}
+
+func (a *ApacheWebServer) GetConfigFilePath() string {
+	return getApacheConfigFilePath(a.root)
+}
+
+func (a *ApacheWebServer) preventReload() {
+	a.reloadPrevented = true
+}
+
+func (a *ApacheWebServer) getRoot() string {
+	return a.root
+}
+
+func (a *ApacheWebServer) createSandboxWebServer(root string) (WebServer, error) {
+	options := map[string]string{}
+	maps.Copy(options, a.options)
+	options[config.ApacheRootOpt] = root
+	webServer, err := GetApacheWebServer(options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	webServer.sandbox = true
+
+	return webServer, nil
+}
+
+// getPidFile returns the pid file of the webserver instance or the file from PidFile directive.
+// The master process is searched by name if the pid file is not found.
+func (a *ApacheWebServer) getPidFile() string {
+	if pidFile := a.options[config.WebServerInstancePidFileOpt]; pidFile != "" {
+		return pidFile
+	}
+
+	directives := a.Config.FindDirectives("PidFile")
+
+	if len(directives) == 0 {
+		return ""
+	}
+
+	envVars := getApacheEnvVars(a.root)
+	pidFile := os.Expand(strings.Trim(directives[len(directives)-1].GetFirstValue(), "\"'"), func(key string) string {
+		if value, ok := envVars[key]; ok {
+			return value
+		}
+
+		return os.Getenv(key)
+	})
+
+	return resolvePidFile(a.root, pidFile)
+}
+
+func (a *ApacheWebServer) getSystemdUnit() string {
+	if unit := a.options[config.ApacheSystemdUnitOpt]; unit != "" {
+		return unit
+	}
+
+	if filepath.Base(a.root) == "httpd" {
+		return "httpd"
+	}
+
+	return "apache2"
+}
+
+func (a *ApacheWebServer) getApachectlBin() string {
+	if bin := a.options[config.ApachectlBinOpt]; bin != "" {
+		return bin
+	}
+
+	return "apachectl"
+}
+
+func getApacheCertificate(virtualHostBlock goapacheconf.VirtualHostBlock) (*dto.Certificate, string) {
+	certPath := getApacheCertificatePath(virtualHostBlock)
+
+	if certPath == "" {
+		return nil, ""
+	}
+
+	cert, _ := utils.GetCertificateFromFile(certPath)
+
+	return cert, certPath
+}
+
+func getApacheCertificatePath(virtualHostBlock goapacheconf.VirtualHostBlock) string {
 	certDirectives := virtualHostBlock.FindDirectives(ApacheCertDirective)
 
 	if len(certDirectives) == 0 {
-		return nil
+		return ""
 	}
 
-	certDirective := certDirectives[len(certDirectives)-1]
-	cert, _ := utils.GetCertificateFromFile(certDirective.GetFirstValue())
-
-	return cert
+	return strings.Trim(certDirectives[len(certDirectives)-1].GetFirstValue(), "\"")
 }
 
 func GetApacheWebServer(options map[string]string) (*ApacheWebServer, error) {
 	root := options[config.ApacheRootOpt]
-	config, err := goapacheconf.GetConfig(root, "")
+	config, err := goapacheconf.GetConfig(root, getApacheConfigFilePath(root))
 
 	if err != nil {
 		return nil, fmt.Errorf("could not parse apache config: %v", err)
@@ -108,3 +353,59 @@ func GetApacheWebServer(options map[string]string) (*ApacheWebServer, error) {
 		options: options,
 	}, nil
 }
+
+// getApacheConfigFilePath returns the main config file: apache2.conf/httpd.conf in the root or conf/httpd.conf for RHEL layout
+func getApacheConfigFilePath(root string) string {
+	for _, fileName := range []string{"apache2.conf", "httpd.conf", filepath.Join("conf", "httpd.conf")} {
+		if configFilePath := filepath.Join(root, fileName); com.IsFile(configFilePath) {
+			return configFilePath
+		}
+	}
+
+	return ""
+}
+
+// getApacheEnvVars parses variables exported by envvars file of Debian layout, e.g. APACHE_PID_FILE
+func getApacheEnvVars(root string) map[string]string {
+	vars := map[string]string{}
+	content, err := os.ReadFile(filepath.Join(root, "envvars"))
+
+	if err != nil {
+		return vars
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+
+		if !strings.HasPrefix(line, "export ") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+
+		if !ok {
+			continue
+		}
+
+		value = strings.Trim(value, "\"'")
+		vars[strings.TrimSpace(name)] = os.Expand(value, func(key string) string {
+			if value, ok := vars[key]; ok {
+				return value
+			}
+
+			return os.Getenv(key)
+		})
+	}
+
+	return vars
+}
+
+func getApacheCertificateKeyPath(virtualHostBlock goapacheconf.VirtualHostBlock) string {
+	keyDirectives := virtualHostBlock.FindDirectives(ApacheCertKeyDirective)
+
+	if len(keyDirectives) == 0 {
+		return ""
+	}
+
+	return strings.Trim(keyDirectives[len(keyDirectives)-1].GetFirstValue(), "\"")
+}
@@ -3,6 +3,8 @@
 package webserver
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/r2dtools/sslbot/config"
@@ -30,6 +32,32 @@ func TestApacheGetVHost(t *testing.T) {
 	assert.Equal(t, "example2.com", host.ServerName)
 }
 
+const apacheWildcardAliasConfig = `<VirtualHost *:80>
+    ServerName example.com
+    ServerAlias www.example.com *.example.com mail.example.com
+    DocumentRoot /var/www/html
+</VirtualHost>
+`
+
+func TestApacheGetVhostByNameAliasAfterWildcard(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "apache2.conf"), []byte(apacheWildcardAliasConfig), 0644))
+
+	apacheWebServer, err := GetApacheWebServer(map[string]string{config.ApacheRootOpt: dir})
+	require.Nil(t, err)
+
+	for _, name := range []string{"example.com", "www.example.com", "mail.example.com"} {
+		host, err := apacheWebServer.GetVhostByName(name)
+		require.Nil(t, err)
+		require.NotNil(t, host, name)
+		assert.Equal(t, "example.com", host.ServerName)
+	}
+
+	host, err := apacheWebServer.GetVhostByName("imap.example.com")
+	require.Nil(t, err)
+	assert.Nil(t, host)
+}
+
 func getApacheWebServer(t *testing.T) *ApacheWebServer {
 	config, err := config.GetConfig()
 	assert.Nil(t, err)
@@ -0,0 +1,255 @@
+package webserver
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/r2dtools/sslbot/internal/dto"
+)
+
+// WebServerCache keeps parsed webservers between requests of the daemon. A webserver is parsed again after its
+// config files are changed or the cache is invalidated after the agent's own changes.
+// Cached webservers are shared, so they must not be used to change configs.
+type WebServerCache struct {
+	watcher *fsnotify.Watcher
+	entries map[string]*webServerCacheEntry
+	// generation is increased on every invalidation to skip webservers parsed before it
+	generation int
+	mx         sync.Mutex
+}
+
+type webServerCacheEntry struct {
+	webServer WebServer
+	options   map[string]string
+	dirs      []string
+}
+
+// cacheableWebServer is implemented by webservers which config tree is expensive to parse
+type cacheableWebServer interface {
+	LayoutWebServer
+	GetConfigFilePath() string
+	indexVhosts() error
+}
+
+// GetWebServer returns the cached webserver by its code or by the name of a webserver instance.
+// Only nginx and apache webservers are cached, other webservers are created on every call.
+func (c *WebServerCache) GetWebServer(webServerCode string, options map[string]string) (WebServer, error) {
+	c.mx.Lock()
+	entry, ok := c.entries[webServerCode]
+	generation := c.generation
+	c.mx.Unlock()
+
+	if ok && maps.Equal(entry.options, options) {
+		return entry.webServer, nil
+	}
+
+	webServer, err := CreateWebServer(webServerCode, options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	cacheable, ok := webServer.(cacheableWebServer)
+
+	if !ok {
+		return webServer, nil
+	}
+
+	if err := cacheable.indexVhosts(); err != nil {
+		return nil, err
+	}
+
+	dirs := getConfigDirs(cacheable)
+
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	// configs could be changed while the webserver was parsed
+	if generation != c.generation {
+		return webServer, nil
+	}
+
+	for _, dir := range dirs {
+		// the directory can be missing, e.g. include pattern without matched files
+		_ = c.watcher.Add(dir)
+	}
+
+	c.entries[webServerCode] = &webServerCacheEntry{
+		webServer: webServer,
+		options:   maps.Clone(options),
+		dirs:      dirs,
+	}
+
+	return webServer, nil
+}
+
+// Invalidate removes all webservers from the cache
+func (c *WebServerCache) Invalidate() {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.entries = make(map[string]*webServerCacheEntry)
+	c.generation++
+}
+
+func (c *WebServerCache) Close() error {
+	return c.watcher.Close()
+}
+
+func (c *WebServerCache) invalidateDir(dir string) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	for code, entry := range c.entries {
+		if slices.Contains(entry.dirs, dir) {
+			delete(c.entries, code)
+		}
+	}
+
+	c.generation++
+}
+
+func (c *WebServerCache) watch() {
+	for {
+		select {
+		case event, ok := <-c.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			c.invalidateDir(filepath.Dir(event.Name))
+		case _, ok := <-c.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			// events could be lost
+			c.Invalidate()
+		}
+	}
+}
+
+func CreateWebServerCache() (*WebServerCache, error) {
+	watcher, err := fsnotify.NewWatcher()
+
+	if err != nil {
+		return nil, fmt.Errorf("could not create config watcher: %v", err)
+	}
+
+	cache := &WebServerCache{
+		watcher: watcher,
+		entries: make(map[string]*webServerCacheEntry),
+	}
+
+	go cache.watch()
+
+	return cache, nil
+}
+
+// getConfigDirs returns directories of the webserver config files and host certificates, so renewed certificates
+// are read again. Directories of symlink targets are included, since changes of a symlink target are not reported
+// for the directory of the symlink.
+func getConfigDirs(webServer cacheableWebServer) []string {
+	paths := []string{webServer.GetConfigFilePath()}
+
+	for _, includePath := range webServer.GetIncludePaths() {
+		matches, _ := filepath.Glob(includePath)
+		paths = append(paths, includePath)
+		paths = append(paths, matches...)
+	}
+
+	// hosts are taken from the index, so the config is not parsed again
+	vhosts, _ := webServer.GetVhosts()
+
+	for _, vhost := range vhosts {
+		if vhost.CertificatePath != "" {
+			paths = append(paths, vhost.CertificatePath)
+		}
+	}
+
+	var dirs []string
+
+	for _, path := range paths {
+		pathDirs := []string{filepath.Dir(path)}
+
+		if realPath, err := filepath.EvalSymlinks(path); err == nil {
+			pathDirs = append(pathDirs, filepath.Dir(realPath))
+		}
+
+		// apache can include a whole directory
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			pathDirs = append(pathDirs, path)
+		}
+
+		for _, dir := range pathDirs {
+			if !slices.Contains(dirs, dir) {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	return dirs
+}
+
+// vhostIndex keeps hosts of a cached webserver and indexes them by server names and aliases
+type vhostIndex struct {
+	vhosts []dto.VirtualHost
+	names  map[string]int
+}
+
+// getVhosts returns a copy of the hosts, since callers can change them
+func (i *vhostIndex) getVhosts() []dto.VirtualHost {
+	return slices.Clone(i.vhosts)
+}
+
+// getVhostByName returns the host with the server name or the exact alias
+func (i *vhostIndex) getVhostByName(serverName string) *dto.VirtualHost {
+	index, ok := i.names[strings.ToLower(serverName)]
+
+	if !ok {
+		return nil
+	}
+
+	vhost := i.vhosts[index]
+
+	return &vhost
+}
+
+// createVhostIndex indexes hosts by server names first and by exact aliases then, the first host wins
+func createVhostIndex(vhosts []dto.VirtualHost) *vhostIndex {
+	names := make(map[string]int)
+
+	for i, vhost := range vhosts {
+		name := strings.ToLower(vhost.ServerName)
+
+		if _, ok := names[name]; !ok {
+			names[name] = i
+		}
+	}
+
+	for i, vhost := range vhosts {
+		for _, alias := range vhost.Aliases {
+			if getServerNameMatchType(alias) != dto.MatchTypeExact {
+				continue
+			}
+
+			name := strings.ToLower(alias)
+
+			if _, ok := names[name]; !ok {
+				names[name] = i
+			}
+		}
+	}
+
+	return &vhostIndex{vhosts: vhosts, names: names}
+}
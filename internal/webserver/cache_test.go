@@ -0,0 +1,167 @@
+//go:build common
+
+package webserver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/stretchr/testify/require"
+)
+
+const cacheNginxConfig = `events {}
+
+http {
+    include sites-enabled/*;
+}
+`
+
+const cacheNginxHostConfig = `server {
+    listen 80;
+    server_name example.com www.example.com;
+}
+`
+
+const cacheNginxChangedHostConfig = `server {
+    listen 80;
+    server_name example.org;
+}
+`
+
+const cacheNginxSslHostConfig = `server {
+    listen 443 ssl;
+    server_name example.com;
+    ssl_certificate %s;
+    ssl_certificate_key %s;
+}
+`
+
+func TestWebServerCache(t *testing.T) {
+	dir := createCacheNginxConfig(t)
+	cache, err := CreateWebServerCache()
+	require.Nil(t, err)
+	defer cache.Close()
+
+	options := map[string]string{config.NginxRootOpt: dir}
+	webServer, err := cache.GetWebServer(WebServerNginxCode, options)
+	require.Nil(t, err)
+
+	cachedWebServer, err := cache.GetWebServer(WebServerNginxCode, options)
+	require.Nil(t, err)
+	require.Same(t, webServer, cachedWebServer)
+
+	vhost, err := webServer.GetVhostByName("WWW.example.com")
+	require.Nil(t, err)
+	require.NotNil(t, vhost)
+	require.Equal(t, "example.com", vhost.ServerName)
+
+	// hosts are copied, so callers can not change the cache
+	vhosts, err := webServer.GetVhosts()
+	require.Nil(t, err)
+	require.Len(t, vhosts, 1)
+	vhosts[0].ServerName = "changed.com"
+	vhost, err = webServer.GetVhostByName("example.com")
+	require.Nil(t, err)
+	require.Equal(t, "example.com", vhost.ServerName)
+
+	cache.Invalidate()
+	webServer, err = cache.GetWebServer(WebServerNginxCode, options)
+	require.Nil(t, err)
+	require.NotSame(t, cachedWebServer, webServer)
+
+	options = map[string]string{config.NginxRootOpt: dir, config.NginxReloadCommandOpt: "true"}
+	cachedWebServer, err = cache.GetWebServer(WebServerNginxCode, options)
+	require.Nil(t, err)
+	require.NotSame(t, cachedWebServer, webServer)
+}
+
+func TestWebServerCacheWatchConfig(t *testing.T) {
+	dir := createCacheNginxConfig(t)
+	cache, err := CreateWebServerCache()
+	require.Nil(t, err)
+	defer cache.Close()
+
+	options := map[string]string{config.NginxRootOpt: dir}
+	webServer, err := cache.GetWebServer(WebServerNginxCode, options)
+	require.Nil(t, err)
+
+	// the host is changed via the symlink target
+	hostPath := filepath.Join(dir, "sites-available", "example.com.conf")
+	require.Nil(t, os.WriteFile(hostPath, []byte(cacheNginxChangedHostConfig), 0644))
+
+	require.Eventually(t, func() bool {
+		cachedWebServer, err := cache.GetWebServer(WebServerNginxCode, options)
+
+		return err == nil && cachedWebServer != webServer
+	}, 5*time.Second, 50*time.Millisecond)
+
+	webServer, err = cache.GetWebServer(WebServerNginxCode, options)
+	require.Nil(t, err)
+	vhost, err := webServer.GetVhostByName("example.org")
+	require.Nil(t, err)
+	require.NotNil(t, vhost)
+}
+
+func TestWebServerCacheWatchCertificate(t *testing.T) {
+	dir := createCacheNginxConfig(t)
+	certDir := filepath.Join(t.TempDir(), "live", "example.com")
+	require.Nil(t, os.MkdirAll(certDir, 0755))
+
+	hostConfig := fmt.Sprintf(cacheNginxSslHostConfig, filepath.Join(certDir, "fullchain.pem"), filepath.Join(certDir, "privkey.pem"))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "sites-available", "example.com.conf"), []byte(hostConfig), 0644))
+
+	cache, err := CreateWebServerCache()
+	require.Nil(t, err)
+	defer cache.Close()
+
+	options := map[string]string{config.NginxRootOpt: dir}
+	webServer, err := cache.GetWebServer(WebServerNginxCode, options)
+	require.Nil(t, err)
+
+	// the certificate is renewed
+	require.Nil(t, os.WriteFile(filepath.Join(certDir, "fullchain.pem"), []byte("certificate"), 0644))
+
+	require.Eventually(t, func() bool {
+		cachedWebServer, err := cache.GetWebServer(WebServerNginxCode, options)
+
+		return err == nil && cachedWebServer != webServer
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func createCacheNginxConfig(t *testing.T) string {
+	dir := t.TempDir()
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "sites-available"), 0755))
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "sites-enabled"), 0755))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(cacheNginxConfig), 0644))
+
+	hostPath := filepath.Join(dir, "sites-available", "example.com.conf")
+	require.Nil(t, os.WriteFile(hostPath, []byte(cacheNginxHostConfig), 0644))
+	require.Nil(t, os.Symlink(hostPath, filepath.Join(dir, "sites-enabled", "example.com.conf")))
+
+	return dir
+}
+
+func TestCreateVhostIndexWildcardAlias(t *testing.T) {
+	vhosts := []dto.VirtualHost{
+		{ServerName: "example.com", Aliases: []string{"www.example.com", "*.example.com", "mail.example.com"}},
+		{ServerName: "example.org", Aliases: []string{"www.example.org"}},
+	}
+	index := createVhostIndex(vhosts)
+
+	require.Equal(t, "example.com", index.getVhostByName("www.example.com").ServerName)
+	require.Nil(t, index.getVhostByName("imap.example.com"))
+
+	// exact aliases following the wildcard alias are indexed
+	vhost := index.getVhostByName("mail.example.com")
+	require.NotNil(t, vhost)
+	require.Equal(t, "example.com", vhost.ServerName)
+
+	vhost = index.getVhostByName("www.example.org")
+	require.NotNil(t, vhost)
+	require.Equal(t, "example.org", vhost.ServerName)
+}
@@ -0,0 +1,107 @@
+package webserver
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/r2dtools/sslbot/internal/dto"
+)
+
+func resolveCertificateCoverage(vhosts []dto.VirtualHost) {
+	for i := range vhosts {
+		vhosts[i].CertificateCoverage = getCertificateCoverage(vhosts[i])
+	}
+}
+
+// getCertificateCoverage checks the server name and aliases of the host against the certificate names
+func getCertificateCoverage(vhost dto.VirtualHost) *dto.CertificateCoverage {
+	if vhost.Certificate == nil {
+		return nil
+	}
+
+	coverage := &dto.CertificateCoverage{
+		CoveredNames:    []string{},
+		UncoveredNames:  []string{},
+		WildcardMatches: map[string]string{},
+	}
+
+	for _, name := range getCertificateNames(append([]string{vhost.ServerName}, vhost.Aliases...)) {
+		certName, ok := matchCertificateName(vhost.Certificate.DNSNames, name)
+
+		if !ok {
+			coverage.UncoveredNames = append(coverage.UncoveredNames, name)
+
+			continue
+		}
+
+		coverage.CoveredNames = append(coverage.CoveredNames, name)
+
+		if certName != name {
+			coverage.WildcardMatches[name] = certName
+		}
+	}
+
+	return coverage
+}
+
+// getCertificateNames returns names a certificate must contain to cover the server names.
+// ".example.com" server name needs both "example.com" and "*.example.com" names.
+func getCertificateNames(serverNames []string) []string {
+	var names []string
+
+	for _, serverName := range serverNames {
+		serverName = strings.ToLower(serverName)
+		var certNames []string
+
+		switch getServerNameMatchType(serverName) {
+		case dto.MatchTypeExact:
+			certNames = []string{serverName}
+		case dto.MatchTypeWildcardPrefix:
+			domain := strings.TrimPrefix(strings.TrimPrefix(serverName, "*"), ".")
+
+			if strings.HasPrefix(serverName, ".") {
+				certNames = []string{domain, "*." + domain}
+			} else {
+				certNames = []string{"*." + domain}
+			}
+		}
+
+		for _, certName := range certNames {
+			if !slices.Contains(names, certName) {
+				names = append(names, certName)
+			}
+		}
+	}
+
+	return names
+}
+
+// matchCertificateName returns the certificate name covering the name. Exact certificate names have precedence
+// over wildcard ones, a wildcard certificate name covers only one level of subdomains.
+func matchCertificateName(certNames []string, name string) (string, bool) {
+	for _, certName := range certNames {
+		if strings.EqualFold(certName, name) {
+			return name, true
+		}
+	}
+
+	if strings.HasPrefix(name, "*.") {
+		return "", false
+	}
+
+	for _, certName := range certNames {
+		certName = strings.ToLower(certName)
+
+		if !strings.HasPrefix(certName, "*.") {
+			continue
+		}
+
+		label, found := strings.CutSuffix(name, certName[1:])
+
+		if found && label != "" && !strings.Contains(label, ".") {
+			return certName, true
+		}
+	}
+
+	return "", false
+}
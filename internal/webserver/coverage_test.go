@@ -0,0 +1,28 @@
+//go:build common
+
+package webserver
+
+import (
+	"testing"
+
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCertificateCoverage(t *testing.T) {
+	vhost := dto.VirtualHost{
+		ServerName: "example.com",
+		Aliases:    []string{"WWW.example.com", "mail.example.com", "a.b.example.com", "*.example.org", ".example.net", `~^.+\.example\.info$`},
+		Certificate: &dto.Certificate{
+			DNSNames: []string{"example.com", "*.example.com", "www.example.com", "*.example.net"},
+		},
+	}
+	coverage := getCertificateCoverage(vhost)
+
+	assert.Equal(t, []string{"example.com", "www.example.com", "mail.example.com", "*.example.net"}, coverage.CoveredNames)
+	assert.Equal(t, []string{"a.b.example.com", "*.example.org", "example.net"}, coverage.UncoveredNames)
+	assert.Equal(t, map[string]string{"mail.example.com": "*.example.com"}, coverage.WildcardMatches)
+
+	vhost.Certificate = nil
+	assert.Nil(t, getCertificateCoverage(vhost))
+}
@@ -0,0 +1,250 @@
+package webserver
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/utils"
+	"github.com/r2dtools/sslbot/internal/webserver/haproxyconf"
+	"github.com/r2dtools/sslbot/internal/webserver/processmng"
+	"github.com/unknwon/com"
+)
+
+type HAProxyWebServer struct {
+	Config  *haproxyconf.Config
+	options map[string]string
+}
+
+type haproxyCertificate struct {
+	path       string
+	sniFilters []string
+}
+
+func (h *HAProxyWebServer) GetCode() string {
+	return WebServerHAProxyCode
+}
+
+func (h *HAProxyWebServer) GetVhostByName(serverName string) (*dto.VirtualHost, error) {
+	vhosts, err := h.GetVhosts()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return getVhostByName(vhosts, serverName), nil
+}
+
+func (h *HAProxyWebServer) GetVhosts() ([]dto.VirtualHost, error) {
+	var vhosts []dto.VirtualHost
+
+	for _, section := range h.Config.FindSections(haproxyconf.SectionFrontend, haproxyconf.SectionListen) {
+		binds := section.GetBinds()
+
+		if len(binds) == 0 {
+			continue
+		}
+
+		var addresses []dto.VirtualHostAddress
+		var ssl bool
+
+		for _, bind := range binds {
+			ssl = ssl || bind.Ssl
+
+			for _, address := range bind.Addresses {
+				addresses = append(addresses, dto.VirtualHostAddress{
+					IsIpv6: address.IsIpv6,
+					Host:   address.Host,
+					Port:   address.Port,
+					Ssl:    bind.Ssl,
+				})
+			}
+		}
+
+		certificates := h.getCertificates(binds)
+
+		for _, names := range section.GetHostMatches() {
+			for i := range names {
+				// hdr_dom and hdr_end values can start with a dot
+				names[i] = strings.TrimPrefix(names[i], ".")
+			}
+
+			certificate, certificatePath := findHAProxyCertificate(certificates, names[0])
+			vhost := dto.VirtualHost{
+				FilePath:           h.Config.FilePath,
+				ServerName:         names[0],
+				Aliases:            names[1:],
+				Ssl:                ssl,
+				WebServer:          getWebServerName(h.options, WebServerHAProxyCode),
+				Addresses:          addresses,
+				Certificate:        certificate,
+				CertificatePath:    certificatePath,
+				CertificateKeyPath: getHAProxyCertificateKeyPath(certificatePath),
+			}
+			vhosts = append(vhosts, vhost)
+		}
+	}
+
+	vhosts = filterVhosts(vhosts)
+	vhosts = mergeVhosts(vhosts)
+	resolveCertificateCoverage(vhosts)
+
+	return vhosts, nil
+}
+
+// FindSections returns frontend and listen sections serving the host
+func (h *HAProxyWebServer) FindSections(serverName string) []*haproxyconf.Section {
+	var sections []*haproxyconf.Section
+
+	for _, section := range h.Config.FindSections(haproxyconf.SectionFrontend, haproxyconf.SectionListen) {
+		for _, names := range section.GetHostMatches() {
+			if slices.ContainsFunc(names, func(name string) bool { return strings.TrimPrefix(name, ".") == serverName }) {
+				sections = append(sections, section)
+
+				break
+			}
+		}
+	}
+
+	return sections
+}
+
+func (h *HAProxyWebServer) GetProcessManager() (ProcessManager, error) {
+	if processManager, ok := getInstanceProcessManager(h.options); ok {
+		return processManager, nil
+	}
+
+	return processmng.GetHAProxyProcessManager(h.options[config.HAProxyMasterSocketOpt], h.options[config.WebServerInstancePidFileOpt])
+}
+
+func (h *HAProxyWebServer) TestConfig() error {
+	return processmng.RunCommand(h.getBin(), "-c", "-f", h.Config.FilePath)
+}
+
+func (h *HAProxyWebServer) getBin() string {
+	if bin := h.options[config.HAProxyBinOpt]; bin != "" {
+		return bin
+	}
+
+	return "haproxy"
+}
+
+// getCertificates returns certificate files of the binds: crt files, files from crt directories and crt-list entries
+func (h *HAProxyWebServer) getCertificates(binds []haproxyconf.Bind) []haproxyCertificate {
+	var certificates []haproxyCertificate
+	crtBase := h.Config.GetCrtBase()
+
+	for _, bind := range binds {
+		for _, crt := range bind.Crts {
+			crt = h.Config.ResolvePath(crt)
+
+			if com.IsFile(crt) {
+				certificates = append(certificates, haproxyCertificate{path: crt})
+
+				continue
+			}
+
+			entries, err := os.ReadDir(crt)
+
+			if err != nil {
+				continue
+			}
+
+			for _, entry := range entries {
+				if entry.IsDir() || slices.Contains([]string{".key", ".ocsp", ".issuer", ".sctl"}, filepath.Ext(entry.Name())) {
+					continue
+				}
+
+				certificates = append(certificates, haproxyCertificate{path: filepath.Join(crt, entry.Name())})
+			}
+		}
+
+		for _, crtListPath := range bind.CrtLists {
+			crtList, err := haproxyconf.GetCrtList(h.Config.ResolvePath(crtListPath), crtBase)
+
+			if err != nil {
+				continue
+			}
+
+			for _, entry := range crtList.Entries {
+				certificates = append(certificates, haproxyCertificate{path: entry.CertPath, sniFilters: entry.SniFilters})
+			}
+		}
+	}
+
+	return certificates
+}
+
+func GetHAProxyWebServer(options map[string]string) (*HAProxyWebServer, error) {
+	haproxyConfig, err := haproxyconf.GetConfig(options[config.HAProxyConfigOpt])
+
+	if err != nil {
+		return nil, fmt.Errorf("could not parse haproxy config: %v", err)
+	}
+
+	return &HAProxyWebServer{
+		Config:  haproxyConfig,
+		options: options,
+	}, nil
+}
+
+// findHAProxyCertificate finds the certificate selected for the server name: crt-list sni filters are checked first, then certificate names
+func findHAProxyCertificate(certificates []haproxyCertificate, serverName string) (*dto.Certificate, string) {
+	for _, certificate := range certificates {
+		for _, filter := range certificate.sniFilters {
+			if !strings.HasPrefix(filter, "!") && utils.MatchDomainName(strings.ToLower(filter), serverName) {
+				cert, err := utils.GetCertificateFromFile(certificate.path)
+
+				if err != nil {
+					return nil, ""
+				}
+
+				return cert, certificate.path
+			}
+		}
+	}
+
+	for _, certificate := range certificates {
+		if len(certificate.sniFilters) > 0 {
+			continue
+		}
+
+		cert, err := utils.GetCertificateFromFile(certificate.path)
+
+		if err != nil {
+			continue
+		}
+
+		if utils.IsCertificateForDomain(cert, serverName) {
+			return cert, certificate.path
+		}
+	}
+
+	return nil, ""
+}
+
+// getHAProxyCertificateKeyPath returns the certificate path if the key is bundled into the certificate file, otherwise <path>.key
+func getHAProxyCertificateKeyPath(certificatePath string) string {
+	if certificatePath == "" {
+		return ""
+	}
+
+	content, err := os.ReadFile(certificatePath)
+
+	if err == nil && bytes.Contains(content, []byte("PRIVATE KEY-----")) {
+		return certificatePath
+	}
+
+	keyPath := certificatePath + ".key"
+
+	if com.IsFile(keyPath) {
+		return keyPath
+	}
+
+	return ""
+}
@@ -0,0 +1,389 @@
+package haproxyconf
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type Address struct {
+	IsIpv6 bool
+	Host   string
+	Port   string
+}
+
+type Bind struct {
+	Line      *Line
+	Addresses []Address
+	Ssl       bool
+	Crts      []string
+	CrtLists  []string
+}
+
+// AddCrt adds "crt <path>" option to the bind line
+func (b *Bind) AddCrt(path string) {
+	args := append(slices.Clone(b.Line.Args), "crt", path)
+
+	if !b.Ssl {
+		args = append(args, "ssl")
+		b.Ssl = true
+	}
+
+	b.Line.SetArgs(args)
+	b.Crts = append(b.Crts, path)
+}
+
+// GetCrtBase returns the directory relative crt and crt-list paths are resolved against
+func (c *Config) GetCrtBase() string {
+	for _, section := range c.FindSections(SectionGlobal) {
+		for _, line := range section.FindLines("crt-base") {
+			if len(line.Args) > 0 {
+				return line.Args[0]
+			}
+		}
+	}
+
+	return ""
+}
+
+// ResolvePath resolves path relative to crt-base directory
+func (c *Config) ResolvePath(path string) string {
+	crtBase := c.GetCrtBase()
+
+	if filepath.IsAbs(path) || crtBase == "" {
+		return path
+	}
+
+	return filepath.Join(crtBase, path)
+}
+
+func (s *Section) GetBinds() []Bind {
+	var binds []Bind
+
+	for _, line := range s.FindLines("bind") {
+		if len(line.Args) == 0 {
+			continue
+		}
+
+		bind := Bind{Line: line}
+
+		for _, address := range strings.Split(line.Args[0], ",") {
+			bind.Addresses = append(bind.Addresses, parseAddress(address)...)
+		}
+
+		for i := 1; i < len(line.Args); i++ {
+			switch line.Args[i] {
+			case "ssl":
+				bind.Ssl = true
+			case "crt":
+				if i+1 < len(line.Args) {
+					bind.Crts = append(bind.Crts, line.Args[i+1])
+					i++
+				}
+			case "crt-list":
+				if i+1 < len(line.Args) {
+					bind.CrtLists = append(bind.CrtLists, line.Args[i+1])
+					i++
+				}
+			}
+		}
+
+		binds = append(binds, bind)
+	}
+
+	return binds
+}
+
+// parseAddress parses bind addresses like *:443, :::443, [::]:443, 127.0.0.1:80-81, ipv6@:443
+func parseAddress(address string) []Address {
+	if index := strings.Index(address, "@"); index != -1 {
+		prefix := address[:index]
+
+		if prefix != "ipv4" && prefix != "ipv6" {
+			return nil
+		}
+
+		address = address[index+1:]
+	}
+
+	index := strings.LastIndex(address, ":")
+
+	if index == -1 {
+		return nil
+	}
+
+	host := strings.Trim(address[:index], "[]")
+	ports := address[index+1:]
+
+	if host == "*" {
+		host = ""
+	}
+
+	isIpv6 := host == "::" || (host != "" && net.ParseIP(host) != nil && strings.Contains(host, ":"))
+	var addresses []Address
+
+	for _, port := range expandPortRange(ports) {
+		addresses = append(addresses, Address{IsIpv6: isIpv6, Host: host, Port: port})
+	}
+
+	return addresses
+}
+
+func expandPortRange(ports string) []string {
+	parts := strings.SplitN(ports, "-", 2)
+
+	if len(parts) == 1 {
+		return []string{ports}
+	}
+
+	var start, end int
+
+	if _, err := fmt.Sscanf(parts[0]+" "+parts[1], "%d %d", &start, &end); err != nil || end < start || end-start > 100 {
+		return []string{parts[0]}
+	}
+
+	var expanded []string
+
+	for port := start; port <= end; port++ {
+		expanded = append(expanded, fmt.Sprint(port))
+	}
+
+	return expanded
+}
+
+// GetHostMatches returns host names matched by ACLs of the section: named acls and anonymous acls in conditions.
+// Each element contains the names matched by one acl.
+func (s *Section) GetHostMatches() [][]string {
+	var matches [][]string
+
+	for _, line := range s.Lines {
+		if line.Keyword == "acl" {
+			if len(line.Args) < 2 {
+				continue
+			}
+
+			if names := getHostMatchValues(line.Args[1:]); len(names) > 0 {
+				matches = append(matches, names)
+			}
+
+			continue
+		}
+
+		// anonymous acls: use_backend app if { hdr(host) -i example.com }
+		args := line.Args
+
+		for {
+			start := slices.Index(args, "{")
+
+			if start == -1 {
+				break
+			}
+
+			end := slices.Index(args[start:], "}")
+
+			if end == -1 {
+				break
+			}
+
+			if names := getHostMatchValues(args[start+1 : start+end]); len(names) > 0 {
+				matches = append(matches, names)
+			}
+
+			args = args[start+end+1:]
+		}
+	}
+
+	return matches
+}
+
+var hostCriteria = []string{
+	"hdr(host)",
+	"hdr_dom(host)",
+	"hdr_end(host)",
+	"req.hdr(host)",
+	"req.hdr(host,1)",
+	"hdr(host,1)",
+	"req_ssl_sni",
+	"req.ssl_sni",
+	"ssl_fc_sni",
+}
+
+func getHostMatchValues(args []string) []string {
+	if len(args) == 0 {
+		return nil
+	}
+
+	criterion := strings.ToLower(args[0])
+
+	if !slices.Contains(hostCriteria, criterion) && !strings.HasPrefix(criterion, "hdr(host)") {
+		return nil
+	}
+
+	var values []string
+
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+
+		switch arg {
+		case "-i", "--":
+			continue
+		case "-m":
+			// only exact and domain matching define host names
+			if i+1 < len(args) && args[i+1] != "str" && args[i+1] != "dom" {
+				return nil
+			}
+
+			i++
+
+			continue
+		case "-f", "-M":
+			i++
+
+			continue
+		}
+
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		// host header can contain a port
+		if host, _, err := net.SplitHostPort(arg); err == nil {
+			arg = host
+		}
+
+		values = append(values, strings.ToLower(arg))
+	}
+
+	return values
+}
+
+type CrtListEntry struct {
+	CertPath   string
+	Options    []string
+	SniFilters []string
+	raw        string
+}
+
+func (e *CrtListEntry) String() string {
+	if e.raw != "" {
+		return e.raw
+	}
+
+	parts := []string{quote(e.CertPath)}
+
+	if len(e.Options) > 0 {
+		parts = append(parts, "["+strings.Join(e.Options, " ")+"]")
+	}
+
+	parts = append(parts, e.SniFilters...)
+
+	return strings.Join(parts, " ")
+}
+
+type CrtList struct {
+	FilePath string
+	Entries  []*CrtListEntry
+	lines    []any
+}
+
+// SetCertificate replaces the certificate of the entry with the sni filter or adds a new entry
+func (l *CrtList) SetCertificate(certPath string, sniFilters []string) {
+	for _, entry := range l.Entries {
+		for _, filter := range entry.SniFilters {
+			if slices.Contains(sniFilters, filter) {
+				entry.CertPath = certPath
+				entry.raw = ""
+
+				return
+			}
+		}
+	}
+
+	entry := &CrtListEntry{CertPath: certPath, SniFilters: sniFilters}
+	l.Entries = append(l.Entries, entry)
+	l.lines = append(l.lines, entry)
+}
+
+func (l *CrtList) Dump() string {
+	var lines []string
+
+	for _, line := range l.lines {
+		switch v := line.(type) {
+		case string:
+			lines = append(lines, v)
+		case *CrtListEntry:
+			lines = append(lines, v.String())
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (l *CrtList) Save() error {
+	return os.WriteFile(l.FilePath, []byte(l.Dump()), 0644)
+}
+
+// GetCrtList parses crt-list file. Relative certificate paths are resolved against base directory (crt-base).
+func GetCrtList(filePath, baseDir string) (*CrtList, error) {
+	file, err := os.Open(filePath)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read crt-list: %v", err)
+	}
+
+	defer file.Close()
+
+	crtList := &CrtList{FilePath: filePath}
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		raw := scanner.Text()
+		trimmed := strings.TrimSpace(raw)
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			crtList.lines = append(crtList.lines, raw)
+
+			continue
+		}
+
+		entry := &CrtListEntry{raw: raw}
+		tokens := Tokenize(trimmed)
+		entry.CertPath = tokens[0]
+
+		if !filepath.IsAbs(entry.CertPath) && baseDir != "" {
+			entry.CertPath = filepath.Join(baseDir, entry.CertPath)
+		}
+
+		tokens = tokens[1:]
+
+		if len(tokens) > 0 && strings.HasPrefix(tokens[0], "[") {
+			var options []string
+
+			for len(tokens) > 0 {
+				token := tokens[0]
+				tokens = tokens[1:]
+				options = append(options, strings.Trim(token, "[]"))
+
+				if strings.HasSuffix(token, "]") {
+					break
+				}
+			}
+
+			entry.Options = slices.DeleteFunc(options, func(option string) bool { return option == "" })
+		}
+
+		entry.SniFilters = tokens
+		crtList.Entries = append(crtList.Entries, entry)
+		crtList.lines = append(crtList.lines, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read crt-list: %v", err)
+	}
+
+	return crtList, nil
+}
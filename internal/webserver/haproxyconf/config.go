@@ -0,0 +1,242 @@
+package haproxyconf
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	SectionGlobal   = "global"
+	SectionDefaults = "defaults"
+	SectionFrontend = "frontend"
+	SectionBackend  = "backend"
+	SectionListen   = "listen"
+)
+
+var sectionKeywords = []string{
+	SectionGlobal,
+	SectionDefaults,
+	SectionFrontend,
+	SectionBackend,
+	SectionListen,
+	"peers",
+	"resolvers",
+	"userlist",
+	"cache",
+	"program",
+	"http-errors",
+	"ring",
+	"mailers",
+}
+
+type Line struct {
+	Keyword string
+	Args    []string
+	raw     string
+	indent  string
+	changed bool
+}
+
+// SetArgs replaces line arguments. The line is rendered again on dump.
+func (l *Line) SetArgs(args []string) {
+	l.Args = args
+	l.changed = true
+}
+
+func (l *Line) String() string {
+	if !l.changed {
+		return l.raw
+	}
+
+	parts := []string{l.Keyword}
+
+	for _, arg := range l.Args {
+		parts = append(parts, quote(arg))
+	}
+
+	return l.indent + strings.Join(parts, " ")
+}
+
+type Section struct {
+	Type  string
+	Name  string
+	Lines []*Line
+	// header is the line that opens the section
+	header *Line
+}
+
+func (s *Section) FindLines(keyword string) []*Line {
+	var lines []*Line
+
+	for _, line := range s.Lines {
+		if line.Keyword == keyword {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// AddLine adds the line after the last section line
+func (s *Section) AddLine(config *Config, keyword string, args []string) *Line {
+	indent := "    "
+	after := s.header
+
+	if len(s.Lines) > 0 {
+		after = s.Lines[len(s.Lines)-1]
+		indent = after.indent
+	}
+
+	line := &Line{Keyword: keyword, Args: args, indent: indent, changed: true}
+	s.Lines = append(s.Lines, line)
+	index := slices.Index(config.lines, after)
+	config.lines = slices.Insert(config.lines, index+1, line)
+
+	return line
+}
+
+type Config struct {
+	FilePath string
+	Sections []*Section
+	lines    []*Line
+}
+
+func (c *Config) FindSections(sectionTypes ...string) []*Section {
+	var sections []*Section
+
+	for _, section := range c.Sections {
+		if slices.Contains(sectionTypes, section.Type) {
+			sections = append(sections, section)
+		}
+	}
+
+	return sections
+}
+
+func (c *Config) Dump() string {
+	var lines []string
+
+	for _, line := range c.lines {
+		lines = append(lines, line.String())
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (c *Config) Save() error {
+	return os.WriteFile(c.FilePath, []byte(c.Dump()), 0644)
+}
+
+func GetConfig(filePath string) (*Config, error) {
+	content, err := os.ReadFile(filePath)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read haproxy config: %v", err)
+	}
+
+	config := Parse(string(content))
+	config.FilePath = filePath
+
+	return config, nil
+}
+
+func Parse(content string) *Config {
+	config := &Config{}
+	var section *Section
+
+	for _, raw := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		line := &Line{raw: raw}
+		config.lines = append(config.lines, line)
+		tokens := Tokenize(raw)
+
+		if len(tokens) == 0 {
+			continue
+		}
+
+		line.Keyword = tokens[0]
+		line.Args = tokens[1:]
+		line.indent = raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))]
+
+		if slices.Contains(sectionKeywords, line.Keyword) {
+			section = &Section{Type: line.Keyword, header: line}
+
+			if len(line.Args) > 0 {
+				section.Name = line.Args[0]
+			}
+
+			config.Sections = append(config.Sections, section)
+
+			continue
+		}
+
+		if section != nil {
+			section.Lines = append(section.Lines, line)
+		}
+	}
+
+	return config
+}
+
+// Tokenize splits config line into words respecting quotes, escapes and comments
+func Tokenize(line string) []string {
+	var tokens []string
+	var token strings.Builder
+	var quote rune
+	inToken := false
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			token.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+			inToken = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				token.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inToken = true
+		case r == '#':
+			if inToken {
+				tokens = append(tokens, token.String())
+			}
+
+			return tokens
+		case r == ' ' || r == '\t':
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		default:
+			token.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+
+	return tokens
+}
+
+func quote(arg string) string {
+	if arg == "" {
+		return `""`
+	}
+
+	if strings.ContainsAny(arg, " \t#\"'\\") {
+		return `"` + strings.ReplaceAll(strings.ReplaceAll(arg, `\`, `\\`), `"`, `\"`) + `"`
+	}
+
+	return arg
+}
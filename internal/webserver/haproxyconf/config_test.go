@@ -0,0 +1,85 @@
+//go:build common
+
+package haproxyconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `global
+    crt-base /etc/haproxy/certs
+
+frontend https # public
+    bind *:443,:::443 ssl crt example.com.pem alpn h2,http/1.1
+    bind :80
+    acl is_example hdr(host) -i example.com www.example.com
+    use_backend api if { req.hdr(host) -m str api.example.com }
+    http-request set-header X-Note "a # b"
+
+backend app
+    server app1 127.0.0.1:8080
+`
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"bind", "*:443", "ssl"}, Tokenize("  bind *:443 ssl # comment"))
+	assert.Equal(t, []string{"http-request", "set-header", "X-Note", "a # b"}, Tokenize(`http-request set-header X-Note "a # b"`))
+	assert.Equal(t, []string{"log", "a b"}, Tokenize(`log a\ b`))
+	assert.Empty(t, Tokenize("# comment"))
+}
+
+func TestParse(t *testing.T) {
+	config := Parse(testConfig)
+	assert.Equal(t, "/etc/haproxy/certs", config.GetCrtBase())
+
+	frontends := config.FindSections(SectionFrontend)
+	require.Len(t, frontends, 1)
+	assert.Equal(t, "https", frontends[0].Name)
+
+	binds := frontends[0].GetBinds()
+	require.Len(t, binds, 2)
+	assert.True(t, binds[0].Ssl)
+	assert.Equal(t, []string{"example.com.pem"}, binds[0].Crts)
+	assert.Equal(t, []Address{{Port: "443"}, {IsIpv6: true, Host: "::", Port: "443"}}, binds[0].Addresses)
+	assert.False(t, binds[1].Ssl)
+
+	assert.Equal(t, [][]string{{"example.com", "www.example.com"}, {"api.example.com"}}, frontends[0].GetHostMatches())
+	assert.Equal(t, testConfig, config.Dump())
+}
+
+func TestAddCrt(t *testing.T) {
+	config := Parse(testConfig)
+	bind := config.FindSections(SectionFrontend)[0].GetBinds()[1]
+	bind.AddCrt("/etc/haproxy/certs/example2.com.pem")
+
+	config = Parse(config.Dump())
+	bind = config.FindSections(SectionFrontend)[0].GetBinds()[1]
+	assert.True(t, bind.Ssl)
+	assert.Equal(t, []string{"/etc/haproxy/certs/example2.com.pem"}, bind.Crts)
+}
+
+func TestCrtList(t *testing.T) {
+	crtListPath := filepath.Join(t.TempDir(), "crt-list.txt")
+	content := "# certificates\nexample.com.pem [alpn h2 ocsp-update on] example.com www.example.com\n/certs/default.pem\n"
+	require.Nil(t, os.WriteFile(crtListPath, []byte(content), 0644))
+
+	crtList, err := GetCrtList(crtListPath, "/certs")
+	require.Nil(t, err)
+	require.Len(t, crtList.Entries, 2)
+	assert.Equal(t, "/certs/example.com.pem", crtList.Entries[0].CertPath)
+	assert.Equal(t, []string{"alpn", "h2", "ocsp-update", "on"}, crtList.Entries[0].Options)
+	assert.Equal(t, []string{"example.com", "www.example.com"}, crtList.Entries[0].SniFilters)
+	assert.Equal(t, content, crtList.Dump())
+
+	crtList.SetCertificate("/certs/example2.com.pem", []string{"example2.com"})
+	crtList.SetCertificate("/certs/example.com-new.pem", []string{"example.com"})
+	assert.Equal(
+		t,
+		"# certificates\n/certs/example.com-new.pem [alpn h2 ocsp-update on] example.com www.example.com\n/certs/default.pem\n/certs/example2.com.pem example2.com\n",
+		crtList.Dump(),
+	)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/r2dtools/sslbot/internal/utils"
 	"github.com/r2dtools/sslbot/internal/webserver"
@@ -13,8 +14,11 @@ import (
 type HostManager interface {
 	Enable(configFilePath, originConfigFilePath string) (string, error)
 	Disable(configFilePath string) error
+	// GetConfigFilePath returns the path a new config file of the host should be created at
+	GetConfigFilePath(originConfigFilePath, fileName string) (string, error)
 }
 
+// DefaultHostManager enables hosts by symlinks: sites-available -> sites-enabled (Debian layout)
 type DefaultHostManager struct{}
 
 func (m *DefaultHostManager) Enable(configFilePath, enabledConfigRootPath string) (string, error) {
@@ -60,6 +64,82 @@ func (m *DefaultHostManager) Disable(enabledConfigFilePath string) error {
 	return err
 }
 
+func (m *DefaultHostManager) GetConfigFilePath(originConfigFilePath, fileName string) (string, error) {
+	return getSiblingConfigFilePath(originConfigFilePath, fileName)
+}
+
+// ConfDHostManager is used for layouts where hosts are enabled by including conf.d directory (RHEL layout).
+// Created host configs must be placed to an included directory, there is nothing to enable.
+type ConfDHostManager struct {
+	webServer webserver.LayoutWebServer
+}
+
+func (m *ConfDHostManager) Enable(configFilePath, enabledConfigRootPath string) (string, error) {
+	if !webserver.IsConfigIncluded(m.webServer, configFilePath) {
+		return "", fmt.Errorf("config file %s is not included by %s config", configFilePath, m.webServer.GetCode())
+	}
+
+	return configFilePath, nil
+}
+
+func (m *ConfDHostManager) Disable(enabledConfigFilePath string) error {
+	return nil
+}
+
+// GetConfigFilePath returns the path next to the origin config if it is included, otherwise the path in the hosts directory
+func (m *ConfDHostManager) GetConfigFilePath(originConfigFilePath, fileName string) (string, error) {
+	configFilePath, err := getSiblingConfigFilePath(originConfigFilePath, fileName)
+
+	if err != nil {
+		return "", err
+	}
+
+	if webserver.IsConfigIncluded(m.webServer, configFilePath) {
+		return configFilePath, nil
+	}
+
+	configFilePath = filepath.Join(m.webServer.GetLayout().HostsDir, fileName)
+
+	if webserver.IsConfigIncluded(m.webServer, configFilePath) {
+		return configFilePath, nil
+	}
+
+	return "", fmt.Errorf("could not find included directory for %s config file", fileName)
+}
+
+// NilHostManager is used by webservers without enabled/available host layout: haproxy, lighttpd and traefik
+type NilHostManager struct{}
+
+func (m *NilHostManager) Enable(configFilePath, enabledConfigRootPath string) (string, error) {
+	return configFilePath, nil
+}
+
+func (m *NilHostManager) Disable(enabledConfigFilePath string) error {
+	return nil
+}
+
+func (m *NilHostManager) GetConfigFilePath(originConfigFilePath, fileName string) (string, error) {
+	return getSiblingConfigFilePath(originConfigFilePath, fileName)
+}
+
 func CreateHostManager(webServer webserver.WebServer) (HostManager, error) {
+	if webServer != nil && slices.Contains([]string{webserver.WebServerHAProxyCode, webserver.WebServerLighttpdCode, webserver.WebServerTraefikCode}, webServer.GetCode()) {
+		return &NilHostManager{}, nil
+	}
+
+	if layoutWebServer, ok := webServer.(webserver.LayoutWebServer); ok && layoutWebServer.GetLayout().Code == webserver.LayoutConfD {
+		return &ConfDHostManager{webServer: layoutWebServer}, nil
+	}
+
 	return &DefaultHostManager{}, nil
 }
+
+func getSiblingConfigFilePath(originConfigFilePath, fileName string) (string, error) {
+	filePath, err := filepath.EvalSymlinks(originConfigFilePath)
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(filePath), fileName), nil
+}
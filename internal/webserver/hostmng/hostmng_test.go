@@ -0,0 +1,63 @@
+//go:build common
+
+package hostmng
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/webserver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfDHostManager(t *testing.T) {
+	dir := t.TempDir()
+	nginxConf := "events {}\n\nhttp {\n    include conf.d/*.conf;\n\n    server {\n        listen 80;\n        server_name main.com;\n    }\n}\n"
+	hostConf := "server {\n    listen 80;\n    server_name example.com;\n}\n"
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "conf.d"), 0755))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(nginxConf), 0644))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "conf.d", "example.com.conf"), []byte(hostConf), 0644))
+
+	webServer, err := webserver.GetNginxWebServer(map[string]string{config.NginxRootOpt: dir})
+	require.Nil(t, err)
+	require.Equal(t, webserver.LayoutConfD, webServer.GetLayout().Code)
+
+	hostManager, err := CreateHostManager(webServer)
+	require.Nil(t, err)
+	require.IsType(t, &ConfDHostManager{}, hostManager)
+
+	// the origin directory is included
+	configFilePath, err := hostManager.GetConfigFilePath(filepath.Join(dir, "conf.d", "example.com.conf"), "example.com-ssl.conf")
+	require.Nil(t, err)
+	require.Equal(t, filepath.Join(dir, "conf.d", "example.com-ssl.conf"), configFilePath)
+
+	// the main config directory is not included, the file is placed to conf.d
+	configFilePath, err = hostManager.GetConfigFilePath(filepath.Join(dir, "nginx.conf"), "nginx-ssl.conf")
+	require.Nil(t, err)
+	require.Equal(t, filepath.Join(dir, "conf.d", "nginx-ssl.conf"), configFilePath)
+
+	enabledConfigFilePath, err := hostManager.Enable(configFilePath, dir)
+	require.Nil(t, err)
+	require.Equal(t, configFilePath, enabledConfigFilePath)
+
+	_, err = hostManager.Enable(filepath.Join(dir, "nginx-ssl.conf"), dir)
+	require.NotNil(t, err)
+}
+
+func TestDebianLayoutHostManager(t *testing.T) {
+	dir := t.TempDir()
+	nginxConf := "events {}\n\nhttp {\n    include sites-enabled/*;\n}\n"
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "sites-enabled"), 0755))
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "sites-available"), 0755))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(nginxConf), 0644))
+
+	webServer, err := webserver.GetNginxWebServer(map[string]string{config.NginxRootOpt: dir})
+	require.Nil(t, err)
+	require.Equal(t, webserver.LayoutDebian, webServer.GetLayout().Code)
+
+	hostManager, err := CreateHostManager(webServer)
+	require.Nil(t, err)
+	require.IsType(t, &DefaultHostManager{}, hostManager)
+}
@@ -0,0 +1,85 @@
+package webserver
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+const (
+	// LayoutDebian stores hosts in sites-available and enables them by symlinks in sites-enabled
+	LayoutDebian = "debian"
+	// LayoutConfD stores hosts in conf.d directory included by the main config (RHEL/CentOS)
+	LayoutConfD = "confd"
+)
+
+type Layout struct {
+	Code string
+	// HostsDir is the directory new host configs are created in if the origin host directory is not suitable
+	HostsDir string
+	// EnabledHostsDir contains symlinks to enabled host configs. It is empty if hosts are enabled by including them.
+	EnabledHostsDir string
+}
+
+// LayoutWebServer is implemented by webservers which host configs layout depends on the distribution
+type LayoutWebServer interface {
+	WebServer
+	GetLayout() Layout
+	// GetIncludePaths returns absolute path patterns of included config files
+	GetIncludePaths() []string
+}
+
+// IsConfigIncluded checks if the config file is matched by one of the include patterns of the webserver
+func IsConfigIncluded(webServer LayoutWebServer, configFilePath string) bool {
+	for _, includePath := range webServer.GetIncludePaths() {
+		if matched, _ := filepath.Match(includePath, configFilePath); matched {
+			return true
+		}
+
+		// apache can include a whole directory
+		if info, err := os.Stat(includePath); err == nil && info.IsDir() && filepath.Dir(configFilePath) == filepath.Clean(includePath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func detectLayout(root string) Layout {
+	enabledHostsDir := filepath.Join(root, "sites-enabled")
+
+	if info, err := os.Stat(enabledHostsDir); err == nil && info.IsDir() {
+		return Layout{
+			Code:            LayoutDebian,
+			HostsDir:        filepath.Join(root, "sites-available"),
+			EnabledHostsDir: enabledHostsDir,
+		}
+	}
+
+	return Layout{
+		Code:     LayoutConfD,
+		HostsDir: filepath.Join(root, "conf.d"),
+	}
+}
+
+func getAbsIncludePaths(root string, includes []string) []string {
+	var paths []string
+
+	for _, include := range includes {
+		if include == "" {
+			continue
+		}
+
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(root, include)
+		}
+
+		include = filepath.Clean(include)
+
+		if !slices.Contains(paths, include) {
+			paths = append(paths, include)
+		}
+	}
+
+	return paths
+}
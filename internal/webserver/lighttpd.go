@@ -0,0 +1,270 @@
+package webserver
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/utils"
+	"github.com/r2dtools/sslbot/internal/webserver/lighttpdconf"
+	"github.com/r2dtools/sslbot/internal/webserver/processmng"
+)
+
+const (
+	LighttpdHostVariable       = `$HTTP["host"]`
+	LighttpdSocketVariable     = `$SERVER["socket"]`
+	LighttpdCertDirective      = "ssl.pemfile"
+	LighttpdCertKeyDirective   = "ssl.privkey"
+	LighttpdSslEngineDirective = "ssl.engine"
+	LighttpdDocRootDirective   = "server.document-root"
+)
+
+type LighttpdWebServer struct {
+	Config  *lighttpdconf.Config
+	options map[string]string
+}
+
+// LighttpdHost is $HTTP["host"] conditional block. Socket is the enclosing $SERVER["socket"] block if any.
+type LighttpdHost struct {
+	Block  *lighttpdconf.Entry
+	Socket *lighttpdconf.Entry
+}
+
+func (l *LighttpdWebServer) GetCode() string {
+	return WebServerLighttpdCode
+}
+
+func (l *LighttpdWebServer) GetVhostByName(serverName string) (*dto.VirtualHost, error) {
+	vhosts, err := l.GetVhosts()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return getVhostByName(vhosts, serverName), nil
+}
+
+func (l *LighttpdWebServer) GetVhosts() ([]dto.VirtualHost, error) {
+	var vhosts []dto.VirtualHost
+
+	global := l.getGlobalBlock()
+	globalAddress := dto.VirtualHostAddress{Port: "80"}
+
+	if port := l.getValue(global, "server.port"); port != "" {
+		globalAddress.Port = port
+	}
+
+	globalSsl := l.IsSslEnabled(global)
+	globalAddress.Ssl = globalSsl
+	sockets := l.FindSockets("")
+
+	for _, host := range l.FindHosts("") {
+		var addresses []dto.VirtualHostAddress
+		var ssl bool
+		var certificatePath, certificateKeyPath string
+		serverName := host.Block.Pattern
+
+		if host.Socket != nil {
+			ssl = l.IsSslEnabled(host.Socket)
+			addresses = append(addresses, parseLighttpdSocket(host.Socket.Pattern, ssl))
+			certificatePath, certificateKeyPath = l.getCertificatePaths(host.Block, host.Socket)
+		} else {
+			// conditions outside of sockets are applied to all sockets
+			addresses = append(addresses, globalAddress)
+			ssl = globalSsl
+			certificatePath, certificateKeyPath = l.getCertificatePaths(host.Block, global)
+
+			for _, socket := range sockets {
+				addresses = append(addresses, parseLighttpdSocket(socket.Pattern, l.IsSslEnabled(socket)))
+				ssl = ssl || l.IsSslEnabled(socket)
+
+				if certificatePath == "" && l.IsSslEnabled(socket) {
+					certificatePath, certificateKeyPath = l.getCertificatePaths(socket, nil)
+				}
+			}
+		}
+
+		certificate, _ := utils.GetCertificateFromFile(certificatePath)
+
+		// default certificate of the socket is used only if it covers the host
+		if certificate != nil && l.getValue(host.Block, LighttpdCertDirective) == "" && !utils.IsCertificateForDomain(certificate, serverName) {
+			certificate, certificatePath, certificateKeyPath = nil, "", ""
+		}
+
+		docRoot := l.getValue(host.Block, LighttpdDocRootDirective)
+
+		if docRoot == "" {
+			docRoot = l.getValue(global, LighttpdDocRootDirective)
+		}
+
+		vhost := dto.VirtualHost{
+			FilePath:           host.Block.File.Path,
+			ServerName:         serverName,
+			DocRoot:            docRoot,
+			Aliases:            []string{},
+			Ssl:                ssl,
+			WebServer:          getWebServerName(l.options, WebServerLighttpdCode),
+			Addresses:          addresses,
+			Certificate:        certificate,
+			CertificatePath:    certificatePath,
+			CertificateKeyPath: certificateKeyPath,
+		}
+		vhosts = append(vhosts, vhost)
+	}
+
+	vhosts = filterVhosts(vhosts)
+	vhosts = mergeVhosts(vhosts)
+	resolveCertificateCoverage(vhosts)
+
+	return vhosts, nil
+}
+
+// FindHosts returns $HTTP["host"] == "<serverName>" blocks. All host blocks are returned if server name is empty.
+func (l *LighttpdWebServer) FindHosts(serverName string) []LighttpdHost {
+	var hosts []LighttpdHost
+
+	l.Config.Walk(func(entry *lighttpdconf.Entry, parents []*lighttpdconf.Entry) {
+		if entry.Type != lighttpdconf.EntryBlock || entry.Variable != LighttpdHostVariable || entry.Operator != "==" {
+			return
+		}
+
+		if serverName != "" && entry.Pattern != serverName {
+			return
+		}
+
+		host := LighttpdHost{Block: entry}
+
+		for _, parent := range parents {
+			if parent.Variable == LighttpdSocketVariable && parent.Operator == "==" {
+				host.Socket = parent
+			}
+		}
+
+		hosts = append(hosts, host)
+	})
+
+	return hosts
+}
+
+// FindSockets returns top level $SERVER["socket"] blocks listening on the port. All sockets are returned if port is empty.
+func (l *LighttpdWebServer) FindSockets(port string) []*lighttpdconf.Entry {
+	var sockets []*lighttpdconf.Entry
+
+	l.Config.Walk(func(entry *lighttpdconf.Entry, parents []*lighttpdconf.Entry) {
+		if len(parents) > 0 || entry.Type != lighttpdconf.EntryBlock || entry.Variable != LighttpdSocketVariable || entry.Operator != "==" {
+			return
+		}
+
+		if port != "" && parseLighttpdSocket(entry.Pattern, false).Port != port {
+			return
+		}
+
+		sockets = append(sockets, entry)
+	})
+
+	return sockets
+}
+
+func (l *LighttpdWebServer) GetProcessManager() (ProcessManager, error) {
+	if processManager, ok := getInstanceProcessManager(l.options); ok {
+		return processManager, nil
+	}
+
+	return processmng.GetLighttpdProcessManager(l.options[config.WebServerInstancePidFileOpt])
+}
+
+func (l *LighttpdWebServer) TestConfig() error {
+	return processmng.RunCommand(l.getBin(), "-tt", "-f", l.Config.FilePath)
+}
+
+func (l *LighttpdWebServer) getBin() string {
+	if bin := l.options[config.LighttpdBinOpt]; bin != "" {
+		return bin
+	}
+
+	return "lighttpd"
+}
+
+// getGlobalBlock returns a block with top level statements of all config files
+func (l *LighttpdWebServer) getGlobalBlock() *lighttpdconf.Entry {
+	global := &lighttpdconf.Entry{Type: lighttpdconf.EntryBlock}
+
+	l.Config.Walk(func(entry *lighttpdconf.Entry, parents []*lighttpdconf.Entry) {
+		if len(parents) == 0 && entry.Type == lighttpdconf.EntryStatement {
+			global.Entries = append(global.Entries, entry)
+		}
+	})
+
+	return global
+}
+
+func (l *LighttpdWebServer) getValue(block *lighttpdconf.Entry, key string) string {
+	if block == nil {
+		return ""
+	}
+
+	statement := block.FindStatement(key)
+
+	if statement == nil {
+		return ""
+	}
+
+	return l.Config.Evaluate(statement.Value)
+}
+
+func (l *LighttpdWebServer) IsSslEnabled(block *lighttpdconf.Entry) bool {
+	return l.getValue(block, LighttpdSslEngineDirective) == "enable"
+}
+
+// getCertificatePaths returns ssl.pemfile and ssl.privkey of the block or of the fallback block.
+// If ssl.privkey is not set, the key is expected in the pem file.
+func (l *LighttpdWebServer) getCertificatePaths(block, fallback *lighttpdconf.Entry) (string, string) {
+	for _, b := range []*lighttpdconf.Entry{block, fallback} {
+		certPath := l.getValue(b, LighttpdCertDirective)
+
+		if certPath == "" {
+			continue
+		}
+
+		keyPath := l.getValue(b, LighttpdCertKeyDirective)
+
+		if keyPath == "" {
+			keyPath = certPath
+		}
+
+		return certPath, keyPath
+	}
+
+	return "", ""
+}
+
+func GetLighttpdWebServer(options map[string]string) (*LighttpdWebServer, error) {
+	lighttpdConfig, err := lighttpdconf.GetConfig(options[config.LighttpdConfigOpt])
+
+	if err != nil {
+		return nil, fmt.Errorf("could not parse lighttpd config: %v", err)
+	}
+
+	return &LighttpdWebServer{
+		Config:  lighttpdConfig,
+		options: options,
+	}, nil
+}
+
+// parseLighttpdSocket parses socket addresses like :443, 0.0.0.0:443, [::]:443
+func parseLighttpdSocket(socket string, ssl bool) dto.VirtualHostAddress {
+	host, port, err := net.SplitHostPort(socket)
+
+	if err != nil {
+		return dto.VirtualHostAddress{Port: strings.TrimPrefix(socket, ":"), Ssl: ssl}
+	}
+
+	return dto.VirtualHostAddress{
+		IsIpv6: strings.Contains(host, ":"),
+		Host:   host,
+		Port:   port,
+		Ssl:    ssl,
+	}
+}
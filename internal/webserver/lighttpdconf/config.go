@@ -0,0 +1,686 @@
+package lighttpdconf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	EntryRaw = iota
+	EntryStatement
+	EntryBlock
+)
+
+var conditionOperators = []string{"==", "!=", "=~", "!~", "=^", "=$"}
+
+// debianIncludeScript includes conf-enabled/*.conf on Debian based systems
+const debianIncludeScript = "/usr/share/lighttpd/include-conf-enabled.pl"
+
+// Entry is a statement (key = value, include "file"), a conditional block ($HTTP["host"] == "example.com" { ... }),
+// or a raw line: comment or blank line
+type Entry struct {
+	Type     int
+	Key      string
+	Operator string
+	Value    string
+	Variable string
+	Pattern  string
+	Else     bool
+	Entries  []*Entry
+	Parent   *Entry
+	File     *File
+	includes []*File
+	raw      string
+	footer   string
+	indent   string
+	inline   bool
+	dirty    bool
+}
+
+func (e *Entry) FindStatements(key string) []*Entry {
+	var entries []*Entry
+
+	for _, entry := range e.Entries {
+		if entry.Type == EntryStatement && entry.Key == key {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// FindStatement returns the last statement with the key: the last assignment wins
+func (e *Entry) FindStatement(key string) *Entry {
+	statements := e.FindStatements(key)
+
+	if len(statements) == 0 {
+		return nil
+	}
+
+	return statements[len(statements)-1]
+}
+
+func (e *Entry) FindBlocks(variable string) []*Entry {
+	var entries []*Entry
+
+	for _, entry := range e.Entries {
+		if entry.Type == EntryBlock && entry.Variable == variable {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+func (e *Entry) SetValue(value string) {
+	e.Value = value
+	e.markDirty()
+}
+
+// SetStatement updates the value of the last statement with the key or adds a new statement
+func (e *Entry) SetStatement(key, value string) *Entry {
+	statement := e.FindStatement(key)
+
+	if statement != nil && statement.Operator != "+=" {
+		statement.SetValue(value)
+
+		return statement
+	}
+
+	return e.AddStatement(key, "=", value)
+}
+
+func (e *Entry) AddStatement(key, operator, value string) *Entry {
+	statement := &Entry{Type: EntryStatement, Key: key, Operator: operator, Value: value}
+	e.addEntry(statement)
+
+	return statement
+}
+
+func (e *Entry) AddBlock(variable, operator, pattern string) *Entry {
+	block := &Entry{Type: EntryBlock, Variable: variable, Operator: operator, Pattern: pattern}
+	e.addEntry(block)
+
+	return block
+}
+
+func (e *Entry) RemoveEntry(entry *Entry) {
+	e.Entries = slices.DeleteFunc(e.Entries, func(child *Entry) bool { return child == entry })
+	e.markDirty()
+}
+
+func (e *Entry) addEntry(entry *Entry) {
+	entry.Parent = e
+	entry.File = e.File
+	entry.indent = e.getChildIndent()
+	entry.dirty = true
+	e.Entries = append(e.Entries, entry)
+	e.markDirty()
+}
+
+func (e *Entry) getChildIndent() string {
+	for _, entry := range e.Entries {
+		if entry.Type != EntryRaw && !entry.inline {
+			return entry.indent
+		}
+	}
+
+	if e.Parent == nil {
+		return ""
+	}
+
+	return e.indent + "    "
+}
+
+func (e *Entry) markDirty() {
+	for entry := e; entry != nil; entry = entry.Parent {
+		entry.dirty = true
+	}
+}
+
+// GetCondition returns block condition, e.g. $HTTP["host"] == "example.com"
+func (e *Entry) GetCondition() string {
+	condition := fmt.Sprintf("%s %s %s", e.Variable, e.Operator, Quote(e.Pattern))
+
+	if e.Variable == "" {
+		condition = ""
+	}
+
+	if e.Else {
+		condition = strings.TrimSpace("else " + condition)
+	}
+
+	return condition
+}
+
+func (e *Entry) String() string {
+	if !e.dirty && (e.Type != EntryBlock || e.inline) {
+		return e.raw
+	}
+
+	switch e.Type {
+	case EntryStatement:
+		if e.Operator == "" {
+			return fmt.Sprintf("%s%s %s", e.indent, e.Key, e.Value)
+		}
+
+		return fmt.Sprintf("%s%s %s %s", e.indent, e.Key, e.Operator, e.Value)
+	case EntryBlock:
+		var lines []string
+
+		if e.Parent != nil {
+			header := e.raw
+
+			if header == "" || e.inline {
+				header = fmt.Sprintf("%s%s {", e.indent, e.GetCondition())
+			}
+
+			lines = append(lines, header)
+		}
+
+		for _, entry := range e.Entries {
+			lines = append(lines, entry.String())
+		}
+
+		if e.Parent != nil && (e.footer != "" || e.inline || e.raw == "") {
+			footer := e.footer
+
+			if footer == "" {
+				footer = e.indent + "}"
+			}
+
+			lines = append(lines, footer)
+		}
+
+		return strings.Join(lines, "\n")
+	}
+
+	return e.raw
+}
+
+type File struct {
+	Path string
+	Root *Entry
+}
+
+func (f *File) Dump() string {
+	content := f.Root.String()
+
+	if content == "" {
+		return content
+	}
+
+	return content + "\n"
+}
+
+func (f *File) Save() error {
+	return os.WriteFile(f.Path, []byte(f.Dump()), 0644)
+}
+
+func (f *File) IsDirty() bool {
+	return f.Root.dirty
+}
+
+type Config struct {
+	FilePath string
+	Files    []*File
+	vars     map[string]string
+}
+
+func (c *Config) GetFile(path string) *File {
+	for _, file := range c.Files {
+		if file.Path == path {
+			return file
+		}
+	}
+
+	return nil
+}
+
+// Walk visits all entries including entries of included files. Parents are the enclosing blocks.
+func (c *Config) Walk(fn func(entry *Entry, parents []*Entry)) {
+	if len(c.Files) == 0 {
+		return
+	}
+
+	c.walk(c.Files[0].Root.Entries, nil, fn)
+}
+
+func (c *Config) walk(entries []*Entry, parents []*Entry, fn func(entry *Entry, parents []*Entry)) {
+	for _, entry := range entries {
+		fn(entry, parents)
+
+		if entry.Type == EntryBlock {
+			c.walk(entry.Entries, append(slices.Clone(parents), entry), fn)
+		}
+
+		for _, file := range entry.includes {
+			c.walk(file.Root.Entries, parents, fn)
+		}
+	}
+}
+
+// Evaluate evaluates a value: concatenation of strings, variables (var.name, env.NAME) and numbers
+func (c *Config) Evaluate(value string) string {
+	var result strings.Builder
+
+	for _, part := range splitOutsideQuotes(value, '+') {
+		part = strings.TrimSpace(part)
+
+		switch {
+		case strings.HasPrefix(part, `"`):
+			result.WriteString(Unquote(part))
+		case strings.HasPrefix(part, "env."):
+			result.WriteString(os.Getenv(strings.TrimPrefix(part, "env.")))
+		case c.vars != nil && c.vars[part] != "":
+			result.WriteString(c.vars[part])
+		default:
+			result.WriteString(part)
+		}
+	}
+
+	return result.String()
+}
+
+func (c *Config) Save() error {
+	for _, file := range c.Files {
+		if !file.IsDirty() {
+			continue
+		}
+
+		if err := file.Save(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func GetConfig(filePath string) (*Config, error) {
+	config := &Config{FilePath: filePath, vars: map[string]string{}}
+
+	if _, err := config.parseFile(filePath); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (c *Config) parseFile(filePath string) (*File, error) {
+	if file := c.GetFile(filePath); file != nil {
+		return nil, fmt.Errorf("lighttpd config %s is included more than once", filePath)
+	}
+
+	content, err := os.ReadFile(filePath)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read lighttpd config: %v", err)
+	}
+
+	file := Parse(string(content))
+	file.Path = filePath
+	c.Files = append(c.Files, file)
+
+	return file, c.processEntries(file, file.Root.Entries)
+}
+
+// processEntries sets the file of entries, collects variables and parses included files
+func (c *Config) processEntries(file *File, entries []*Entry) error {
+	for _, entry := range entries {
+		entry.File = file
+
+		if entry.Type == EntryBlock {
+			if err := c.processEntries(file, entry.Entries); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if entry.Type != EntryStatement {
+			continue
+		}
+
+		if strings.HasPrefix(entry.Key, "var.") && entry.Operator != "" {
+			c.vars[entry.Key] = c.Evaluate(entry.Value)
+
+			continue
+		}
+
+		for _, path := range c.getIncludePaths(entry) {
+			included, err := c.parseFile(path)
+
+			if err != nil {
+				return err
+			}
+
+			entry.includes = append(entry.includes, included)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) getIncludePaths(entry *Entry) []string {
+	var pattern string
+
+	switch entry.Key {
+	case "include":
+		pattern = c.Evaluate(entry.Value)
+	case "include_shell":
+		if strings.HasPrefix(c.Evaluate(entry.Value), debianIncludeScript) {
+			pattern = "conf-enabled/*.conf"
+		}
+	}
+
+	if pattern == "" {
+		return nil
+	}
+
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(c.FilePath), pattern)
+	}
+
+	paths, err := filepath.Glob(pattern)
+
+	if err != nil {
+		return nil
+	}
+
+	slices.Sort(paths)
+
+	return paths
+}
+
+// Parse parses config content. Statements can span several lines if they contain arrays.
+func Parse(content string) *File {
+	root := &Entry{Type: EntryBlock}
+	file := &File{Root: root}
+	root.File = file
+	current := root
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+	if content == "" {
+		lines = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		raw := lines[i]
+		text := strings.TrimSpace(stripComment(raw))
+		indent := raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))]
+
+		if text == "" {
+			current.Entries = append(current.Entries, &Entry{Type: EntryRaw, raw: raw, Parent: current})
+
+			continue
+		}
+
+		if strings.HasPrefix(text, "}") {
+			rest := strings.TrimSpace(text[1:])
+
+			if current.Parent == nil {
+				current.Entries = append(current.Entries, &Entry{Type: EntryRaw, raw: raw, Parent: current})
+
+				continue
+			}
+
+			if rest == "" {
+				current.footer = raw
+				current = current.Parent
+
+				continue
+			}
+
+			// } else $HTTP["host"] == "example.com" {
+			current = current.Parent
+			text = rest
+		}
+
+		// multiline statements: arrays in parentheses
+		for parenthesesBalance(text) > 0 && i+1 < len(lines) {
+			i++
+			raw += "\n" + lines[i]
+			text += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+
+		if strings.HasSuffix(text, "{") {
+			block := parseCondition(strings.TrimSpace(strings.TrimSuffix(text, "{")))
+			block.raw = raw
+			block.indent = indent
+			block.Parent = current
+			current.Entries = append(current.Entries, block)
+			current = block
+
+			continue
+		}
+
+		// inline block: $HTTP["host"] == "example.com" { server.document-root = "/var/www" }
+		if index := indexOutsideQuotes(text, "{"); index != -1 && strings.HasSuffix(text, "}") {
+			block := parseCondition(strings.TrimSpace(text[:index]))
+			block.raw = raw
+			block.indent = indent
+			block.inline = true
+			block.Parent = current
+
+			if inner := strings.TrimSpace(text[index+1 : len(text)-1]); inner != "" {
+				statement := parseStatement(inner)
+				statement.indent = indent + "    "
+				statement.raw = statement.indent + inner
+				statement.Parent = block
+				block.Entries = append(block.Entries, statement)
+			}
+
+			current.Entries = append(current.Entries, block)
+
+			continue
+		}
+
+		statement := parseStatement(text)
+		statement.raw = raw
+		statement.indent = indent
+		statement.Parent = current
+		current.Entries = append(current.Entries, statement)
+	}
+
+	return file
+}
+
+func parseCondition(condition string) *Entry {
+	block := &Entry{Type: EntryBlock}
+
+	for _, prefix := range []string{"elseif", "elsif", "else"} {
+		if rest, ok := strings.CutPrefix(condition, prefix); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '$') {
+			block.Else = true
+			condition = strings.TrimSpace(rest)
+
+			break
+		}
+	}
+
+	for _, operator := range conditionOperators {
+		if index := indexOutsideQuotes(condition, operator); index != -1 {
+			block.Variable = strings.TrimSpace(condition[:index])
+			block.Operator = operator
+			block.Pattern = Unquote(strings.TrimSpace(condition[index+len(operator):]))
+
+			break
+		}
+	}
+
+	return block
+}
+
+func parseStatement(text string) *Entry {
+	statement := &Entry{Type: EntryStatement}
+
+	if index := indexOutsideQuotes(text, "="); index != -1 {
+		key := text[:index]
+		statement.Operator = "="
+
+		// += and := operators
+		if strings.HasSuffix(key, "+") || strings.HasSuffix(key, ":") {
+			statement.Operator = key[len(key)-1:] + "="
+			key = key[:len(key)-1]
+		}
+
+		statement.Key = strings.TrimSpace(key)
+		statement.Value = strings.TrimSpace(text[index+1:])
+
+		return statement
+	}
+
+	// include "file.conf"
+	key, value, _ := strings.Cut(text, " ")
+	statement.Key = key
+	statement.Value = strings.TrimSpace(value)
+
+	return statement
+}
+
+type ArrayItem struct {
+	Key   string
+	Value string
+}
+
+// ParseArray parses array values: ( "a", "b" ) or ( "key" => "value" ). A single string is an array with one item.
+func ParseArray(value string) []ArrayItem {
+	value = strings.TrimSpace(value)
+
+	if !strings.HasPrefix(value, "(") {
+		return []ArrayItem{{Value: Unquote(value)}}
+	}
+
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "("), ")")
+	var items []ArrayItem
+
+	for _, part := range splitOutsideQuotes(value, ',') {
+		part = strings.TrimSpace(part)
+
+		if part == "" {
+			continue
+		}
+
+		if index := indexOutsideQuotes(part, "=>"); index != -1 {
+			items = append(items, ArrayItem{
+				Key:   Unquote(strings.TrimSpace(part[:index])),
+				Value: Unquote(strings.TrimSpace(part[index+2:])),
+			})
+		} else {
+			items = append(items, ArrayItem{Value: Unquote(part)})
+		}
+	}
+
+	return items
+}
+
+func FormatArray(items []ArrayItem) string {
+	var parts []string
+
+	for _, item := range items {
+		if item.Key != "" {
+			parts = append(parts, fmt.Sprintf("%s => %s", Quote(item.Key), Quote(item.Value)))
+		} else {
+			parts = append(parts, Quote(item.Value))
+		}
+	}
+
+	return "( " + strings.Join(parts, ", ") + " )"
+}
+
+func Unquote(value string) string {
+	if len(value) < 2 || value[0] != '"' {
+		return value
+	}
+
+	var result strings.Builder
+	escaped := false
+
+	for _, r := range value[1:] {
+		switch {
+		case escaped:
+			result.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			return result.String()
+		default:
+			result.WriteRune(r)
+		}
+	}
+
+	return result.String()
+}
+
+func Quote(value string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), `"`, `\"`) + `"`
+}
+
+func stripComment(line string) string {
+	if index := indexOutsideQuotes(line, "#"); index != -1 {
+		return line[:index]
+	}
+
+	return line
+}
+
+func indexOutsideQuotes(text, substr string) int {
+	inQuotes := false
+	escaped := false
+
+	for i := 0; i < len(text); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case text[i] == '\\' && inQuotes:
+			escaped = true
+		case text[i] == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && strings.HasPrefix(text[i:], substr):
+			return i
+		}
+	}
+
+	return -1
+}
+
+func splitOutsideQuotes(text string, separator byte) []string {
+	var parts []string
+
+	for {
+		index := indexOutsideQuotes(text, string(separator))
+
+		if index == -1 {
+			return append(parts, text)
+		}
+
+		parts = append(parts, text[:index])
+		text = text[index+1:]
+	}
+}
+
+func parenthesesBalance(text string) int {
+	balance := 0
+	inQuotes := false
+	escaped := false
+
+	for i := 0; i < len(text); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case text[i] == '\\' && inQuotes:
+			escaped = true
+		case text[i] == '"':
+			inQuotes = !inQuotes
+		case !inQuotes && text[i] == '(':
+			balance++
+		case !inQuotes && text[i] == ')':
+			balance--
+		}
+	}
+
+	return balance
+}
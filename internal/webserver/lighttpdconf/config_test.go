@@ -0,0 +1,106 @@
+//go:build common
+
+package lighttpdconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `var.basedir = "/var/www"
+server.modules = (
+	"mod_access",
+	"mod_alias", # comment
+)
+server.document-root = var.basedir + "/html"
+
+$HTTP["host"] == "example.com" {
+	server.document-root = var.basedir + "/example.com"
+} else $HTTP["host"] == "example2.com" {
+	server.document-root = "/var/www/example2.com"
+}
+$HTTP["host"] == "example3.com" { server.document-root = "/var/www/example3.com" }
+`
+
+func TestParse(t *testing.T) {
+	file := Parse(testConfig)
+	assert.Equal(t, testConfig, file.Dump())
+
+	modules := file.Root.FindStatement("server.modules")
+	require.NotNil(t, modules)
+	assert.Equal(t, []ArrayItem{{Value: "mod_access"}, {Value: "mod_alias"}}, ParseArray(modules.Value))
+
+	blocks := file.Root.FindBlocks(`$HTTP["host"]`)
+	require.Len(t, blocks, 3)
+	assert.Equal(t, "example.com", blocks[0].Pattern)
+	assert.Equal(t, "==", blocks[0].Operator)
+	assert.False(t, blocks[0].Else)
+	assert.Equal(t, "example2.com", blocks[1].Pattern)
+	assert.True(t, blocks[1].Else)
+	assert.Equal(t, `"/var/www/example3.com"`, blocks[2].FindStatement("server.document-root").Value)
+}
+
+func TestModify(t *testing.T) {
+	file := Parse(testConfig)
+	blocks := file.Root.FindBlocks(`$HTTP["host"]`)
+
+	blocks[1].SetStatement("ssl.pemfile", Quote("/etc/ssl/example2.com.pem"))
+	blocks[2].SetStatement("server.document-root", Quote("/srv/example3.com"))
+	socket := file.Root.AddBlock(`$SERVER["socket"]`, "==", ":443")
+	socket.AddStatement("ssl.engine", "=", Quote("enable"))
+
+	expected := `var.basedir = "/var/www"
+server.modules = (
+	"mod_access",
+	"mod_alias", # comment
+)
+server.document-root = var.basedir + "/html"
+
+$HTTP["host"] == "example.com" {
+	server.document-root = var.basedir + "/example.com"
+} else $HTTP["host"] == "example2.com" {
+	server.document-root = "/var/www/example2.com"
+	ssl.pemfile = "/etc/ssl/example2.com.pem"
+}
+$HTTP["host"] == "example3.com" {
+    server.document-root = "/srv/example3.com"
+}
+$SERVER["socket"] == ":443" {
+    ssl.engine = "enable"
+}
+`
+	assert.Equal(t, expected, file.Dump())
+}
+
+func TestGetConfig(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.Mkdir(filepath.Join(dir, "conf-enabled"), 0755))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "lighttpd.conf"), []byte(testConfig+"include \"conf-enabled/*.conf\"\n"), 0644))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "conf-enabled", "10-ssl.conf"), []byte("$SERVER[\"socket\"] == \":443\" {\n\tssl.engine = \"enable\"\n}\n"), 0644))
+
+	config, err := GetConfig(filepath.Join(dir, "lighttpd.conf"))
+	require.Nil(t, err)
+	require.Len(t, config.Files, 2)
+
+	var sockets []*Entry
+	var hosts []*Entry
+
+	config.Walk(func(entry *Entry, parents []*Entry) {
+		if entry.Type == EntryBlock && entry.Variable == `$SERVER["socket"]` {
+			sockets = append(sockets, entry)
+		}
+
+		if entry.Type == EntryBlock && entry.Variable == `$HTTP["host"]` {
+			hosts = append(hosts, entry)
+		}
+	})
+
+	require.Len(t, sockets, 1)
+	assert.Equal(t, filepath.Join(dir, "conf-enabled", "10-ssl.conf"), sockets[0].File.Path)
+	require.Len(t, hosts, 3)
+	assert.Equal(t, "/var/www/example.com", config.Evaluate(hosts[0].FindStatement("server.document-root").Value))
+}
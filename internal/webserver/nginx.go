@@ -2,6 +2,9 @@ package webserver
 
 import (
 	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	nginxConfig "github.com/r2dtools/gonginxconf/config"
@@ -14,44 +17,76 @@ import (
 const (
 	NginxCertKeyDirective = "ssl_certificate_key"
 	NginxCertDirective    = "ssl_certificate"
+	NginxStreamBlock      = "stream"
+	nginxServerBlock      = "server"
 )
 
 type NginxWebServer struct {
 	Config  *nginxConfig.Config
 	root    string
 	options map[string]string
+	// vhostIndex is set for webservers kept in the cache
+	vhostIndex *vhostIndex
+	// sandbox is set for webservers parsed from a sandbox config copy
+	sandbox bool
+	// reloadPrevented is set if the caller reloads the webserver itself
+	reloadPrevented bool
 }
 
 func (nws *NginxWebServer) GetCode() string {
 	return WebServerNginxCode
 }
 
+// GetVhostByName returns the host with the server name or the exact alias. Wildcard and regex names are
+// not matched against the name, since the host is changed by callers: ResolveVhost finds the serving host.
 func (nws *NginxWebServer) GetVhostByName(serverName string) (*dto.VirtualHost, error) {
+	if nws.vhostIndex != nil {
+		return nws.vhostIndex.getVhostByName(serverName), nil
+	}
+
+	vhosts, err := nws.GetVhosts()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return createVhostIndex(vhosts).getVhostByName(serverName), nil
+}
+
+// ResolveVhost returns the host nginx selects for the host name on the port, the port is optional
+func (nws *NginxWebServer) ResolveVhost(hostName, port string) (*dto.VirtualHost, error) {
 	vhosts, err := nws.GetVhosts()
 
 	if err != nil {
 		return nil, err
 	}
 
-	return getVhostByName(vhosts, serverName), nil
+	return resolveNginxVhost(vhosts, hostName, port, true), nil
+}
+
+func (nws *NginxWebServer) indexVhosts() error {
+	vhosts, err := nws.GetVhosts()
+
+	if err != nil {
+		return err
+	}
+
+	nws.vhostIndex = createVhostIndex(vhosts)
+
+	return nil
 }
 
 func (nws *NginxWebServer) GetVhosts() ([]dto.VirtualHost, error) {
+	if nws.vhostIndex != nil {
+		return nws.vhostIndex.getVhosts(), nil
+	}
+
 	var vhosts []dto.VirtualHost
 
-	nVhosts := nws.Config.FindServerBlocks()
+	nVhosts := nws.FindServerBlocks()
 
 	for _, nVhost := range nVhosts {
-		var addresses []dto.VirtualHostAddress
-
-		for _, address := range nVhost.GetAddresses() {
-			addresses = append(addresses, dto.VirtualHostAddress{
-				IsIpv6: address.IsIpv6,
-				Host:   address.Host,
-				Port:   address.Port,
-			})
-		}
-
+		addresses := getNginxAddresses(nVhost)
 		serverNames := nVhost.GetServerNames()
 
 		if len(serverNames) == 0 {
@@ -64,27 +99,303 @@ func (nws *NginxWebServer) GetVhosts() ([]dto.VirtualHost, error) {
 			aliases = serverNames[1:]
 		}
 
+		certificate, certificatePath := getNginxCertificate(nVhost)
 		vhost := dto.VirtualHost{
-			FilePath:    strings.Trim(nVhost.FilePath, "\""),
-			ServerName:  strings.Trim(serverNames[0], "\""),
-			DocRoot:     strings.Trim(nVhost.GetDocumentRoot(), "\""),
-			Aliases:     aliases,
-			Ssl:         nVhost.HasSSL(),
-			WebServer:   WebServerNginxCode,
-			Addresses:   addresses,
-			Certificate: getNginxCertificate(nVhost),
+			FilePath:           strings.Trim(nVhost.FilePath, "\""),
+			ServerName:         strings.Trim(serverNames[0], "\""),
+			DocRoot:            strings.Trim(nVhost.GetDocumentRoot(), "\""),
+			Aliases:            aliases,
+			Ssl:                nVhost.HasSSL(),
+			WebServer:          getWebServerName(nws.options, WebServerNginxCode),
+			Addresses:          addresses,
+			Certificate:        certificate,
+			CertificatePath:    certificatePath,
+			CertificateKeyPath: getNginxCertificateKeyPath(nVhost),
 		}
 		vhosts = append(vhosts, vhost)
 	}
 
+	markDefaultServers(vhosts)
 	vhosts = filterVhosts(vhosts)
 	vhosts = mergeVhosts(vhosts)
+	resolveCertificateCoverage(vhosts)
 
 	return vhosts, nil
 }
 
+// GetCertificateVhosts returns http servers with a certificate including servers without server name and on any port
+func (nws *NginxWebServer) GetCertificateVhosts() ([]dto.VirtualHost, error) {
+	var vhosts []dto.VirtualHost
+
+	for _, serverBlock := range nws.FindServerBlocks() {
+		certificatePath := getNginxCertificatePath(serverBlock)
+
+		if certificatePath == "" {
+			continue
+		}
+
+		var serverName string
+
+		if serverNames := serverBlock.GetServerNames(); len(serverNames) > 0 {
+			serverName = strings.Trim(serverNames[0], "\"")
+		}
+
+		vhosts = append(vhosts, dto.VirtualHost{
+			FilePath:           strings.Trim(serverBlock.FilePath, "\""),
+			ServerName:         serverName,
+			Ssl:                serverBlock.HasSSL(),
+			WebServer:          getWebServerName(nws.options, WebServerNginxCode),
+			Addresses:          getNginxAddresses(serverBlock),
+			CertificatePath:    certificatePath,
+			CertificateKeyPath: getNginxCertificateKeyPath(serverBlock),
+		})
+	}
+
+	return vhosts, nil
+}
+
+// FindServerBlocks returns http server blocks. Servers of stream blocks are skipped.
+func (nws *NginxWebServer) FindServerBlocks() []nginxConfig.ServerBlock {
+	return nws.excludeStreamServerBlocks(nws.Config.FindServerBlocks())
+}
+
+func (nws *NginxWebServer) FindServerBlocksByServerName(serverName string) []nginxConfig.ServerBlock {
+	return nws.excludeStreamServerBlocks(nws.Config.FindServerBlocksByServerName(serverName))
+}
+
+func (nws *NginxWebServer) FindSslServerBlocksByServerName(serverName string) []nginxConfig.ServerBlock {
+	var serverBlocks []nginxConfig.ServerBlock
+
+	for _, serverBlock := range nws.FindServerBlocksByServerName(serverName) {
+		if serverBlock.HasSSL() {
+			serverBlocks = append(serverBlocks, serverBlock)
+		}
+	}
+
+	return serverBlocks
+}
+
+// SaveConfigFiles writes changed config files to the disk
+func (nws *NginxWebServer) SaveConfigFiles(filePaths []string) error {
+	for _, filePath := range filePaths {
+		configFile := nws.Config.GetConfigFile(filepath.Base(filePath))
+
+		if configFile == nil {
+			return fmt.Errorf("failed to find config file %s", filePath)
+		}
+
+		if err := configFile.Dump(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetStreamServers returns servers of stream blocks. A server is keyed by its first listen address,
+// server_name of the server is used as a friendly name.
+func (nws *NginxWebServer) GetStreamServers() ([]dto.StreamServer, error) {
+	var servers []dto.StreamServer
+
+	for _, serverBlock := range nws.FindStreamServerBlocks() {
+		addresses := getNginxAddresses(serverBlock)
+
+		if len(addresses) == 0 {
+			continue
+		}
+
+		certificate, certificatePath := getNginxCertificate(serverBlock)
+		servers = append(servers, dto.StreamServer{
+			Key:                getNginxStreamServerKey(addresses[0]),
+			Name:               getNginxStreamServerName(serverBlock),
+			FilePath:           strings.Trim(serverBlock.FilePath, "\""),
+			WebServer:          getWebServerName(nws.options, WebServerNginxCode),
+			Ssl:                serverBlock.HasSSL(),
+			Addresses:          addresses,
+			Certificate:        certificate,
+			CertificatePath:    certificatePath,
+			CertificateKeyPath: getNginxCertificateKeyPath(serverBlock),
+		})
+	}
+
+	return servers, nil
+}
+
+// GetStreamServer returns the stream server by listen address or name
+func (nws *NginxWebServer) GetStreamServer(key string) (*dto.StreamServer, error) {
+	servers, err := nws.GetStreamServers()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, server := range servers {
+		if server.Key == key {
+			return &server, nil
+		}
+	}
+
+	for _, server := range servers {
+		if server.Name != "" && server.Name == key {
+			return &server, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// FindStreamServerBlock returns the server block of the stream server with the key
+func (nws *NginxWebServer) FindStreamServerBlock(key string) *nginxConfig.ServerBlock {
+	server, err := nws.GetStreamServer(key)
+
+	if err != nil || server == nil {
+		return nil
+	}
+
+	for _, serverBlock := range nws.FindStreamServerBlocks() {
+		addresses := getNginxAddresses(serverBlock)
+
+		if len(addresses) > 0 && getNginxStreamServerKey(addresses[0]) == server.Key {
+			return &serverBlock
+		}
+	}
+
+	return nil
+}
+
+func (nws *NginxWebServer) FindStreamServerBlocks() []nginxConfig.ServerBlock {
+	var serverBlocks []nginxConfig.ServerBlock
+
+	for _, streamBlock := range nws.Config.FindBlocks(NginxStreamBlock) {
+		for _, block := range streamBlock.FindBlocks(nginxServerBlock) {
+			serverBlocks = append(serverBlocks, nginxConfig.ServerBlock{Block: block})
+		}
+	}
+
+	return serverBlocks
+}
+
+// excludeStreamServerBlocks removes stream servers from the list.
+// Blocks are compared by file and content since the parser creates new block objects on every search.
+func (nws *NginxWebServer) excludeStreamServerBlocks(serverBlocks []nginxConfig.ServerBlock) []nginxConfig.ServerBlock {
+	streamServerBlocks := map[string]struct{}{}
+
+	for _, serverBlock := range nws.FindStreamServerBlocks() {
+		streamServerBlocks[serverBlock.FilePath+serverBlock.Dump()] = struct{}{}
+	}
+
+	if len(streamServerBlocks) == 0 {
+		return serverBlocks
+	}
+
+	var httpServerBlocks []nginxConfig.ServerBlock
+
+	for _, serverBlock := range serverBlocks {
+		if _, ok := streamServerBlocks[serverBlock.FilePath+serverBlock.Dump()]; !ok {
+			httpServerBlocks = append(httpServerBlocks, serverBlock)
+		}
+	}
+
+	return httpServerBlocks
+}
+
+func (nws *NginxWebServer) GetLayout() Layout {
+	return detectLayout(nws.root)
+}
+
+func (nws *NginxWebServer) GetIncludePaths() []string {
+	var includes []string
+
+	for _, directive := range nws.Config.FindDirectives("include") {
+		includes = append(includes, strings.Trim(directive.GetFirstValue(), "\"'"))
+	}
+
+	return getAbsIncludePaths(nws.root, includes)
+}
+
 func (nws *NginxWebServer) GetProcessManager() (ProcessManager, error) {
-	return processmng.GetNginxProcessManager()
+	if nws.sandbox || nws.reloadPrevented {
+		return &nilProcessManager{}, nil
+	}
+
+	if processManager, ok := getInstanceProcessManager(nws.options); ok {
+		return processManager, nil
+	}
+
+	strategy := nws.options[config.NginxReloadStrategyOpt]
+	processManager, ok, err := getStrategyProcessManager(strategy, nws.options[config.NginxSystemdUnitOpt], nws.options[config.NginxReloadCommandOpt])
+
+	if ok {
+		return processManager, err
+	}
+
+	switch strategy {
+	case "", processmng.ReloadStrategySignal:
+		return processmng.GetNginxProcessManager(nws.getPidFile())
+	case processmng.ReloadStrategyNginx:
+		return processmng.GetExecProcessManager(nws.getBin(), "-c", nws.GetConfigFilePath(), "-s", "reload"), nil
+	default:
+		return nil, fmt.Errorf("nginx reload strategy '%s' is not supported", strategy)
+	}
+}
+
+func (nws *NginxWebServer) TestConfig() error {
+	if nws.sandbox {
+		return nil
+	}
+
+	return processmng.RunCommand(nws.getBin(), "-t", "-q", "-c", nws.GetConfigFilePath())
+}
+
+func (nws *NginxWebServer) GetConfigFilePath() string {
+	return filepath.Join(nws.root, "nginx.conf")
+}
+
+func (nws *NginxWebServer) preventReload() {
+	nws.reloadPrevented = true
+}
+
+func (nws *NginxWebServer) getRoot() string {
+	return nws.root
+}
+
+func (nws *NginxWebServer) createSandboxWebServer(root string) (WebServer, error) {
+	options := map[string]string{}
+	maps.Copy(options, nws.options)
+	options[config.NginxRootOpt] = root
+	webServer, err := GetNginxWebServer(options)
+
+	if err != nil {
+		return nil, err
+	}
+
+	webServer.sandbox = true
+
+	return webServer, nil
+}
+
+// getPidFile returns the pid file of the webserver instance or the file from pid directive.
+// The master process is searched by name if the pid file is not found.
+func (nws *NginxWebServer) getPidFile() string {
+	if pidFile := nws.options[config.WebServerInstancePidFileOpt]; pidFile != "" {
+		return pidFile
+	}
+
+	directives := nws.Config.FindDirectives("pid")
+
+	if len(directives) == 0 {
+		return ""
+	}
+
+	return resolvePidFile(nws.root, directives[len(directives)-1].GetFirstValue())
+}
+
+func (nws *NginxWebServer) getBin() string {
+	if bin := nws.options[config.NginxBinOpt]; bin != "" {
+		return bin
+	}
+
+	return "nginx"
 }
 
 func GetNginxWebServer(options map[string]string) (*NginxWebServer, error) {
@@ -95,6 +406,10 @@ func GetNginxWebServer(options map[string]string) (*NginxWebServer, error) {
 		return nil, fmt.Errorf("could not parse nginx config: %v", err)
 	}
 
+	if err = parseNginxStreamIncludes(config); err != nil {
+		return nil, fmt.Errorf("could not parse nginx stream config: %v", err)
+	}
+
 	return &NginxWebServer{
 		Config:  config,
 		root:    root,
@@ -102,15 +417,96 @@ func GetNginxWebServer(options map[string]string) (*NginxWebServer, error) {
 	}, nil
 }
 
-func getNginxCertificate(serverBlock nginxConfig.ServerBlock) *dto.Certificate {
+// parseNginxStreamIncludes parses files included into stream blocks, the parser follows only http includes
+func parseNginxStreamIncludes(config *nginxConfig.Config) error {
+	for _, streamBlock := range config.FindBlocks(NginxStreamBlock) {
+		for _, include := range streamBlock.FindDirectives("include") {
+			if err := config.ParseFile(strings.Trim(include.GetFirstValue(), "\"")); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func getNginxAddresses(serverBlock nginxConfig.ServerBlock) []dto.VirtualHostAddress {
+	var addresses []dto.VirtualHostAddress
+	listens := serverBlock.FindDirectives("listen")
+	sslListens := serverBlock.GetListens()
+
+	for i, address := range serverBlock.GetAddresses() {
+		var defaultServer, ssl bool
+
+		if i < len(listens) {
+			values := listens[i].GetValues()
+			defaultServer = slices.Contains(values, "default_server") || slices.Contains(values, "default")
+		}
+
+		if i < len(sslListens) {
+			ssl = sslListens[i].Ssl
+		}
+
+		addresses = append(addresses, dto.VirtualHostAddress{
+			IsIpv6:        address.IsIpv6,
+			Host:          address.Host,
+			Port:          address.Port,
+			DefaultServer: defaultServer,
+			Ssl:           ssl,
+		})
+	}
+
+	return addresses
+}
+
+// getNginxStreamServerKey returns the port for servers that listen on all addresses, so servers on
+// the same port and different addresses get different keys
+func getNginxStreamServerKey(address dto.VirtualHostAddress) string {
+	if address.Host == "" || address.Host == "*" {
+		return address.Port
+	}
+
+	return address.Host + ":" + address.Port
+}
+
+func getNginxStreamServerName(serverBlock nginxConfig.ServerBlock) string {
+	serverNames := serverBlock.GetServerNames()
+
+	if len(serverNames) == 0 {
+		return ""
+	}
+
+	return serverNames[0]
+}
+
+func getNginxCertificate(serverBlock nginxConfig.ServerBlock) (*dto.Certificate, string) {
+	certPath := getNginxCertificatePath(serverBlock)
+
+	if certPath == "" {
+		return nil, ""
+	}
+
+	cert, _ := utils.GetCertificateFromFile(certPath)
+
+	return cert, certPath
+}
+
+func getNginxCertificatePath(serverBlock nginxConfig.ServerBlock) string {
 	certDirectives := serverBlock.FindDirectives(NginxCertDirective)
 
 	if len(certDirectives) == 0 {
-		return nil
+		return ""
 	}
 
-	certDirective := certDirectives[len(certDirectives)-1]
-	cert, _ := utils.GetCertificateFromFile(certDirective.GetFirstValue())
+	return strings.Trim(certDirectives[len(certDirectives)-1].GetFirstValue(), "\"")
+}
+
+func getNginxCertificateKeyPath(serverBlock nginxConfig.ServerBlock) string {
+	keyDirectives := serverBlock.FindDirectives(NginxCertKeyDirective)
+
+	if len(keyDirectives) == 0 {
+		return ""
+	}
 
-	return cert
+	return strings.Trim(keyDirectives[len(keyDirectives)-1].GetFirstValue(), "\"")
 }
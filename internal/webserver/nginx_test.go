@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/dto"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,7 +14,7 @@ func TestNginxGetVHosts(t *testing.T) {
 	nginxWebServer := getNginxWebServer(t)
 	hosts, err := nginxWebServer.GetVhosts()
 	assert.Nil(t, err)
-	assert.Len(t, hosts, 5)
+	assert.Len(t, hosts, 6)
 }
 
 func TestNginxGetVHost(t *testing.T) {
@@ -26,6 +27,8 @@ func TestNginxGetVHost(t *testing.T) {
 	assert.True(t, host.Ssl)
 	assert.Equal(t, "/var/www/html", host.DocRoot)
 	assert.Len(t, host.Addresses, 4)
+	assert.False(t, host.Addresses[0].Ssl)
+	assert.True(t, host.Addresses[3].Ssl)
 	assert.Equal(t, "example2.com", host.ServerName)
 
 	host, err = nginxWebServer.GetVhostByName("example3.com")
@@ -59,6 +62,46 @@ func TestNginxGetVHost(t *testing.T) {
 	assert.Equal(t, "webmail.r2dtools.work.gd", host.ServerName)
 }
 
+func TestNginxGetWildcardVHost(t *testing.T) {
+	nginxWebServer := getNginxWebServer(t)
+	host, err := nginxWebServer.GetVhostByName(".example.com")
+	assert.Nil(t, err)
+	assert.NotNil(t, host)
+	assert.Equal(t, dto.MatchTypeWildcardPrefix, host.MatchType)
+	assert.Len(t, host.Addresses, 4)
+
+	// host names are matched against wildcard names only on resolving
+	host, err = nginxWebServer.GetVhostByName("mail.example.com")
+	assert.Nil(t, err)
+	assert.Nil(t, host)
+
+	host, err = nginxWebServer.ResolveVhost("mail.example.com", "")
+	assert.Nil(t, err)
+	assert.NotNil(t, host)
+	assert.Equal(t, ".example.com", host.ServerName)
+
+	host, err = nginxWebServer.GetVhostByName("www.example.com")
+	assert.Nil(t, err)
+	assert.NotNil(t, host)
+	assert.Equal(t, "example.com", host.ServerName)
+	assert.Equal(t, dto.MatchTypeExact, host.MatchType)
+
+	host, err = nginxWebServer.GetVhostByName("unknown.com")
+	assert.Nil(t, err)
+	assert.Nil(t, host)
+
+	// the first server of a port is the default one if there is no explicit default server
+	host, err = nginxWebServer.ResolveVhost("unknown.com", "80")
+	assert.Nil(t, err)
+	assert.NotNil(t, host)
+	assert.Equal(t, ".example.com", host.ServerName)
+
+	host, err = nginxWebServer.ResolveVhost("unknown.com", "443")
+	assert.Nil(t, err)
+	assert.NotNil(t, host)
+	assert.Equal(t, "example.com", host.ServerName)
+}
+
 func getNginxWebServer(t *testing.T) *NginxWebServer {
 	config, err := config.GetConfig()
 	assert.Nil(t, err)
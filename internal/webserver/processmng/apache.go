@@ -21,8 +21,8 @@ func (m *ApacheProcessManager) Reload() error {
 	return nil
 }
 
-func GetApacheProcessManager() (*ApacheProcessManager, error) {
-	apacheProcess, err := findProcessByName([]string{"apache2", "httpd"})
+func GetApacheProcessManager(pidFile string) (*ApacheProcessManager, error) {
+	apacheProcess, err := findProcess(pidFile, []string{"apache2", "httpd"})
 
 	if err != nil {
 		return nil, err
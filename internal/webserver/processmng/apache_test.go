@@ -9,7 +9,7 @@ import (
 )
 
 func TestApacheReload(t *testing.T) {
-	apacheProcessManager, err := GetApacheProcessManager()
+	apacheProcessManager, err := GetApacheProcessManager("")
 	assert.Nil(t, err)
 
 	err = apacheProcessManager.Reload()
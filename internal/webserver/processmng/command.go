@@ -0,0 +1,67 @@
+package processmng
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	ReloadStrategySignal    = "signal"
+	ReloadStrategySystemctl = "systemctl"
+	ReloadStrategyApachectl = "apachectl"
+	ReloadStrategyNginx     = "nginx"
+	ReloadStrategyCommand   = "command"
+)
+
+// CommandProcessManager reloads a webserver by running a command. Stderr of the command is returned in the error.
+type CommandProcessManager struct {
+	name string
+	args []string
+}
+
+func (m *CommandProcessManager) Reload() error {
+	if err := RunCommand(m.name, m.args...); err != nil {
+		return fmt.Errorf("reload failed: %v", err)
+	}
+
+	return nil
+}
+
+func (m *CommandProcessManager) String() string {
+	return strings.Join(append([]string{m.name}, m.args...), " ")
+}
+
+func GetExecProcessManager(name string, args ...string) *CommandProcessManager {
+	return &CommandProcessManager{name: name, args: args}
+}
+
+// GetCommandProcessManager returns the manager that runs the custom shell command
+func GetCommandProcessManager(command string) *CommandProcessManager {
+	return GetExecProcessManager("sh", "-c", command)
+}
+
+func GetSystemctlProcessManager(unit string) *CommandProcessManager {
+	return GetExecProcessManager("systemctl", "reload", unit)
+}
+
+// RunCommand runs the command and returns its stderr in the error if the command fails
+func RunCommand(name string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		command := strings.Join(append([]string{name}, args...), " ")
+		output := strings.TrimSpace(stderr.String())
+
+		if output == "" {
+			return fmt.Errorf("command '%s' failed: %v", command, err)
+		}
+
+		return fmt.Errorf("command '%s' failed: %v: %s", command, err, output)
+	}
+
+	return nil
+}
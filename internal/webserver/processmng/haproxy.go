@@ -0,0 +1,88 @@
+package processmng
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+)
+
+// HAProxyProcessManager reloads haproxy via the master CLI socket if it is configured, otherwise by SIGUSR2 sent to the master process
+type HAProxyProcessManager struct {
+	proc         *process.Process
+	masterSocket string
+}
+
+func (m *HAProxyProcessManager) Reload() error {
+	if m.masterSocket != "" {
+		return m.reloadViaMasterSocket()
+	}
+
+	err := m.proc.SendSignal(syscall.SIGUSR2)
+
+	if err != nil {
+		return fmt.Errorf("failed to reload haproxy: %v", err)
+	}
+
+	return nil
+}
+
+func (m *HAProxyProcessManager) reloadViaMasterSocket() error {
+	conn, err := net.DialTimeout("unix", m.masterSocket, 5*time.Second)
+
+	if err != nil {
+		return fmt.Errorf("failed to connect to haproxy master socket: %v", err)
+	}
+
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	if _, err := conn.Write([]byte("reload\n")); err != nil {
+		return fmt.Errorf("failed to reload haproxy: %v", err)
+	}
+
+	// haproxy 2.7+ reports the reload status, older versions close the connection
+	scanner := bufio.NewScanner(conn)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "Success=0") {
+			return fmt.Errorf("failed to reload haproxy: %s", line)
+		}
+	}
+
+	return nil
+}
+
+func GetHAProxyProcessManager(masterSocket, pidFile string) (*HAProxyProcessManager, error) {
+	if masterSocket != "" {
+		return &HAProxyProcessManager{masterSocket: masterSocket}, nil
+	}
+
+	haproxyProcess, err := findProcess(pidFile, []string{"haproxy"})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if haproxyProcess == nil {
+		return nil, fmt.Errorf("haproxy process not found")
+	}
+
+	isRunning, err := haproxyProcess.IsRunning()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !isRunning {
+		return nil, fmt.Errorf("haproxy process is not running")
+	}
+
+	return &HAProxyProcessManager{proc: haproxyProcess}, nil
+}
@@ -0,0 +1,53 @@
+package processmng
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+
+	"github.com/shirou/gopsutil/process"
+)
+
+type LighttpdProcessManager struct {
+	proc *process.Process
+}
+
+// Reload gracefully restarts lighttpd. lighttpd-angel restarts the server on SIGHUP, lighttpd itself on SIGUSR1.
+func (m *LighttpdProcessManager) Reload() error {
+	signal := syscall.SIGUSR1
+	name, err := m.proc.Name()
+
+	if err == nil && strings.Contains(name, "angel") {
+		signal = syscall.SIGHUP
+	}
+
+	if err := m.proc.SendSignal(signal); err != nil {
+		return fmt.Errorf("failed to reload lighttpd: %v", err)
+	}
+
+	return nil
+}
+
+func GetLighttpdProcessManager(pidFile string) (*LighttpdProcessManager, error) {
+	lighttpdProcess, err := findProcess(pidFile, []string{"lighttpd"})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if lighttpdProcess == nil {
+		return nil, fmt.Errorf("lighttpd process not found")
+	}
+
+	isRunning, err := lighttpdProcess.IsRunning()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !isRunning {
+		return nil, fmt.Errorf("lighttpd process is not running")
+	}
+
+	return &LighttpdProcessManager{lighttpdProcess}, nil
+}
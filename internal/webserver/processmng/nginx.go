@@ -21,8 +21,8 @@ func (m *NginxProcessManager) Reload() error {
 	return nil
 }
 
-func GetNginxProcessManager() (*NginxProcessManager, error) {
-	nginxProcess, err := findProcessByName([]string{"nginx", "httpd"})
+func GetNginxProcessManager(pidFile string) (*NginxProcessManager, error) {
+	nginxProcess, err := findProcess(pidFile, []string{"nginx", "httpd"})
 
 	if err != nil {
 		return nil, err
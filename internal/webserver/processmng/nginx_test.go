@@ -9,7 +9,7 @@ import (
 )
 
 func TestNginxReload(t *testing.T) {
-	nginxProcessManager, err := GetNginxProcessManager()
+	nginxProcessManager, err := GetNginxProcessManager("")
 	assert.Nil(t, err)
 
 	err = nginxProcessManager.Reload()
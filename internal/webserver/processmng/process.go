@@ -1,11 +1,39 @@
 package processmng
 
 import (
+	"fmt"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/shirou/gopsutil/process"
 )
 
+// findProcess returns the process with pid from the pid file if it is specified, otherwise the master process found by name
+func findProcess(pidFile string, names []string) (*process.Process, error) {
+	if pidFile == "" {
+		return findProcessByName(names)
+	}
+
+	return findProcessByPidFile(pidFile)
+}
+
+func findProcessByPidFile(pidFile string) (*process.Process, error) {
+	content, err := os.ReadFile(pidFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read pid file: %v", err)
+	}
+
+	pid, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 32)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid pid file %s: %v", pidFile, err)
+	}
+
+	return process.NewProcess(int32(pid))
+}
+
 func findProcessByName(names []string) (*process.Process, error) {
 	processes, err := process.Processes()
 
@@ -60,3 +88,10 @@ func isRequiredProcess(cmd string, names []string) bool {
 
 	return false
 }
+
+// NilProcessManager is used by webservers that apply config changes without reload, e.g. traefik watches its dynamic config
+type NilProcessManager struct{}
+
+func (m *NilProcessManager) Reload() error {
+	return nil
+}
@@ -0,0 +1,75 @@
+package reverter
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/r2dtools/sslbot/internal/logger"
+	"github.com/r2dtools/sslbot/internal/webserver"
+)
+
+// ApplyChanges saves changed config files, tests webserver config and reloads webserver.
+// Changes are rolled back if any step fails.
+func ApplyChanges(
+	webServer webserver.WebServer,
+	reverter Reverter,
+	logger logger.Logger,
+	filePaths []string,
+	save func() error,
+) error {
+	processManager, err := webServer.GetProcessManager()
+
+	if err != nil {
+		return err
+	}
+
+	if err := reverter.BackupConfigs(filePaths); err != nil {
+		return err
+	}
+
+	if err := save(); err != nil {
+		if rErr := reverter.Rollback(); rErr != nil {
+			logger.Error(fmt.Sprintf("failed to rollback webserver configuration on config saving: %v", rErr))
+		}
+
+		return err
+	}
+
+	if err := webserver.TestConfig(webServer); err != nil {
+		if rErr := reverter.Rollback(); rErr != nil {
+			logger.Error(fmt.Sprintf("failed to rollback webserver configuration on config test: %v", rErr))
+		}
+
+		return err
+	}
+
+	if err := processManager.Reload(); err != nil {
+		if rErr := reverter.Rollback(); rErr != nil {
+			logger.Error(fmt.Sprintf("failed to rollback webserver configuration on webserver reload: %v", rErr))
+		}
+
+		return err
+	}
+
+	return reverter.Commit()
+}
+
+// ApplyConfigFileChanges saves changed config files via the webserver and applies them as ApplyChanges does
+func ApplyConfigFileChanges(
+	webServer webserver.ConfigFileWebServer,
+	reverter Reverter,
+	logger logger.Logger,
+	filePaths []string,
+) error {
+	var uniqueFilePaths []string
+
+	for _, filePath := range filePaths {
+		if !slices.Contains(uniqueFilePaths, filePath) {
+			uniqueFilePaths = append(uniqueFilePaths, filePath)
+		}
+	}
+
+	return ApplyChanges(webServer, reverter, logger, uniqueFilePaths, func() error {
+		return webServer.SaveConfigFiles(uniqueFilePaths)
+	})
+}
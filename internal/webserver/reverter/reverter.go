@@ -24,6 +24,8 @@ type Reverter interface {
 	BackupConfigs(filePaths []string) error
 	BackupConfig(filePath string) error
 	AddConfigToDisable(filePath string)
+	// AddConfigToEnable enables the config back on rollback, e.g. the config disabled on host undeploy
+	AddConfigToEnable(filePath, enabledConfigRootPath string)
 	Rollback() error
 	Commit() error
 }
@@ -32,6 +34,7 @@ type DefaultReverter struct {
 	configsToDelete  []string
 	configsToRestore map[string]string
 	configsToDisable []string
+	configsToEnable  map[string]string
 	hostMng          hostmng.HostManager
 	logger           logger.Logger
 }
@@ -64,13 +67,20 @@ func (r *DefaultReverter) BackupConfig(filePath string) error {
 		return nil
 	}
 
+	info, err := os.Stat(filePath)
+
+	if err != nil {
+		return err
+	}
+
 	content, err := os.ReadFile(filePath)
 
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(bFilePath, content, 0644)
+	// keep the file mode: backed up file can contain a private key
+	err = os.WriteFile(bFilePath, content, info.Mode().Perm())
 
 	if err != nil {
 		return err
@@ -89,6 +99,14 @@ func (r *DefaultReverter) AddConfigToDisable(filePath string) {
 	r.configsToDisable = append(r.configsToDisable, filePath)
 }
 
+func (r *DefaultReverter) AddConfigToEnable(filePath, enabledConfigRootPath string) {
+	if r.configsToEnable == nil {
+		r.configsToEnable = make(map[string]string)
+	}
+
+	r.configsToEnable[filePath] = enabledConfigRootPath
+}
+
 func (r *DefaultReverter) Rollback() error {
 	// Disable all enabled before sites
 	for _, configToDisable := range r.configsToDisable {
@@ -117,10 +135,6 @@ func (r *DefaultReverter) Rollback() error {
 		}
 	}
 
-	if r.configsToRestore == nil {
-		return nil
-	}
-
 	// restore the content of backed up files
 	for originFilePath, bFilePath := range r.configsToRestore {
 		bContent, err := os.ReadFile(bFilePath)
@@ -142,6 +156,15 @@ func (r *DefaultReverter) Rollback() error {
 		delete(r.configsToRestore, originFilePath)
 	}
 
+	// enable disabled sites after their configs are restored
+	for filePath, enabledConfigRootPath := range r.configsToEnable {
+		if _, err := r.hostMng.Enable(filePath, enabledConfigRootPath); err != nil {
+			r.logger.Error(err.Error())
+		}
+
+		delete(r.configsToEnable, filePath)
+	}
+
 	return nil
 }
 
@@ -157,6 +180,7 @@ func (r *DefaultReverter) Commit() error {
 	}
 
 	r.configsToDelete = nil
+	r.configsToEnable = nil
 
 	return nil
 }
@@ -21,6 +21,10 @@ func (m stubHostManager) Disable(configFilePath string) error {
 	return nil
 }
 
+func (m stubHostManager) GetConfigFilePath(originConfigFilePath, fileName string) (string, error) {
+	return "", nil
+}
+
 func TestReverterRollback(t *testing.T) {
 	reverter := getReverter(t)
 	fileToBackup := "/tmp/fileToRemove"
@@ -0,0 +1,473 @@
+package webserver
+
+import (
+	"bytes"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/r2dtools/sslbot/internal/dto"
+)
+
+const (
+	sandboxDirPattern = "sslbot-sandbox-"
+	// sandboxMaxAge is the age of sandboxes left by killed processes to be removed, dry runs take less time
+	sandboxMaxAge = time.Hour
+)
+
+// sandboxIncludeRegex matches nginx and apache include directives with absolute paths
+var sandboxIncludeRegex = regexp.MustCompile(`(?mi)^(\s*include(?:optional)?\s+["']?)/`)
+
+// sandboxSkippedExtensions are extensions of certificate and key files, such files are not copied to the sandbox.
+// PEM files are checked by content since they can hold public data of the config, e.g. dh parameters.
+var sandboxSkippedExtensions = []string{".key", ".crt", ".cer", ".der", ".csr", ".p12", ".pfx"}
+
+// sandboxPublicPemTypes are types of PEM blocks that are copied to the sandbox
+var sandboxPublicPemTypes = []string{"DH PARAMETERS"}
+
+type sandboxWebServer interface {
+	LayoutWebServer
+	getRoot() string
+	// createSandboxWebServer parses the config copy in the root, the webserver must not test config and reload
+	createSandboxWebServer(root string) (WebServer, error)
+}
+
+type sandboxFile struct {
+	// content is the symlink target for symlinks
+	content string
+	symlink bool
+}
+
+// Sandbox is a temporary copy of the webserver config. Commands run against the sandbox webserver
+// show changes they would make to the config, the webserver config itself is not changed.
+// Absolute include paths and symlink targets are redirected to the copy, so parsed config files are never origin ones.
+// Certificates and private keys are not copied: the sandbox config keeps referencing origin ones.
+// The sandbox must be removed after use, sandboxes left by killed processes are removed on the next sandbox creation.
+type Sandbox struct {
+	dir       string
+	root      string
+	webServer sandboxWebServer
+	// files are copied files and symlinks by sandbox path
+	files map[string]sandboxFile
+}
+
+// CreateWebServer parses the sandbox config. The config is parsed again on every call to include changes made by commands.
+func (s *Sandbox) CreateWebServer() (WebServer, error) {
+	webServer, err := s.webServer.createSandboxWebServer(s.getSandboxPath(s.root))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.checkWebServerPaths(webServer); err != nil {
+		return nil, err
+	}
+
+	return webServer, nil
+}
+
+// GetDiffs returns unified diffs of the files changed, created or removed in the sandbox. Paths are origin ones.
+func (s *Sandbox) GetDiffs() ([]dto.ConfigDiff, error) {
+	diffs := []dto.ConfigDiff{}
+	sandboxPaths := map[string]struct{}{}
+
+	err := filepath.WalkDir(s.dir, func(sandboxPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		file, ok, err := readSandboxFile(sandboxPath, entry)
+
+		if err != nil || !ok {
+			return err
+		}
+
+		sandboxPaths[sandboxPath] = struct{}{}
+		originFile, ok := s.files[sandboxPath]
+
+		if ok && originFile == file {
+			return nil
+		}
+
+		var diff dto.ConfigDiff
+
+		if ok {
+			diff, err = s.createDiff(sandboxPath, &originFile, &file)
+		} else {
+			diff, err = s.createDiff(sandboxPath, nil, &file)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		diffs = append(diffs, diff)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("could not compare sandbox config: %v", err)
+	}
+
+	for sandboxPath, originFile := range s.files {
+		if _, ok := sandboxPaths[sandboxPath]; ok {
+			continue
+		}
+
+		diff, err := s.createDiff(sandboxPath, &originFile, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		diffs = append(diffs, diff)
+	}
+
+	slices.SortFunc(diffs, func(a, b dto.ConfigDiff) int {
+		return strings.Compare(a.FilePath, b.FilePath)
+	})
+
+	return diffs, nil
+}
+
+func (s *Sandbox) Remove() error {
+	return os.RemoveAll(s.dir)
+}
+
+func (s *Sandbox) copyPath(path string) error {
+	info, err := os.Lstat(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	sandboxPath := s.getSandboxPath(path)
+
+	// directories are walked again since nested files could be created on symlink copying
+	if info.IsDir() {
+		return s.copyDir(path, sandboxPath)
+	}
+
+	if _, err := os.Lstat(sandboxPath); err == nil {
+		return nil
+	}
+
+	if err = os.MkdirAll(filepath.Dir(sandboxPath), 0755); err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		return s.copySymlink(path, sandboxPath)
+	}
+
+	if info.Mode().IsRegular() {
+		return s.copyFile(path, sandboxPath, info)
+	}
+
+	return nil
+}
+
+func (s *Sandbox) copyDir(path, sandboxPath string) error {
+	if err := os.MkdirAll(sandboxPath, 0755); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(path)
+
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err = s.copyPath(filepath.Join(path, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copySymlink copies the symlink and its target. Absolute targets are redirected to the sandbox.
+func (s *Sandbox) copySymlink(path, sandboxPath string) error {
+	target, err := os.Readlink(path)
+
+	if err != nil {
+		return err
+	}
+
+	originTarget := target
+
+	if filepath.IsAbs(target) {
+		target = s.getSandboxPath(target)
+	} else {
+		originTarget = filepath.Join(filepath.Dir(path), target)
+	}
+
+	if err = os.Symlink(target, sandboxPath); err != nil {
+		return err
+	}
+
+	s.files[sandboxPath] = sandboxFile{content: target, symlink: true}
+
+	return s.copyPath(originTarget)
+}
+
+func (s *Sandbox) copyFile(path, sandboxPath string, info fs.FileInfo) error {
+	if slices.Contains(sandboxSkippedExtensions, strings.ToLower(filepath.Ext(path))) {
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+
+	if err != nil {
+		return err
+	}
+
+	// key files can have any name, only public PEM data is a part of the config
+	if bytes.Contains(content, []byte("-----BEGIN ")) && !isPublicPem(content) {
+		return nil
+	}
+
+	sandboxContent := sandboxIncludeRegex.ReplaceAllString(string(content), "${1}"+s.dir+"/")
+
+	if err = os.WriteFile(sandboxPath, []byte(sandboxContent), info.Mode().Perm()|0600); err != nil {
+		return err
+	}
+
+	s.files[sandboxPath] = sandboxFile{content: sandboxContent}
+
+	return nil
+}
+
+// checkWebServerPaths makes sure that config files of the sandbox webserver are copies, so commands can not change origin files
+func (s *Sandbox) checkWebServerPaths(webServer WebServer) error {
+	var paths []string
+
+	if layoutWebServer, ok := webServer.(LayoutWebServer); ok {
+		for _, includePath := range layoutWebServer.GetIncludePaths() {
+			matches, err := filepath.Glob(includePath)
+
+			if err != nil {
+				return err
+			}
+
+			paths = append(paths, matches...)
+		}
+	}
+
+	vhosts, err := webServer.GetVhosts()
+
+	if err != nil {
+		return err
+	}
+
+	for _, vhost := range vhosts {
+		paths = append(paths, vhost.FilePath)
+	}
+
+	for _, path := range paths {
+		resolvedPath, err := filepath.EvalSymlinks(path)
+
+		if err != nil {
+			continue
+		}
+
+		if !strings.HasPrefix(resolvedPath, s.dir+string(os.PathSeparator)) {
+			return fmt.Errorf("config file %s could not be copied to the sandbox", resolvedPath)
+		}
+	}
+
+	return nil
+}
+
+func (s *Sandbox) createDiff(sandboxPath string, originFile, file *sandboxFile) (dto.ConfigDiff, error) {
+	filePath := s.getOriginPath(sandboxPath)
+	configDiff := dto.ConfigDiff{FilePath: filePath}
+	unifiedDiff := difflib.UnifiedDiff{
+		FromFile: filePath,
+		ToFile:   filePath,
+		Context:  3,
+	}
+
+	if originFile == nil {
+		unifiedDiff.FromFile = os.DevNull
+	} else {
+		unifiedDiff.A = s.splitLines(originFile.content)
+		configDiff.Symlink = originFile.symlink
+	}
+
+	if file == nil {
+		unifiedDiff.ToFile = os.DevNull
+	} else {
+		unifiedDiff.B = s.splitLines(file.content)
+		configDiff.Symlink = file.symlink
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(unifiedDiff)
+
+	if err != nil {
+		return configDiff, fmt.Errorf("could not create diff of %s: %v", filePath, err)
+	}
+
+	configDiff.Diff = diff
+
+	return configDiff, nil
+}
+
+// splitLines returns lines of the content with origin paths
+func (s *Sandbox) splitLines(content string) []string {
+	content = strings.ReplaceAll(content, s.dir, "")
+
+	if content == "" {
+		return nil
+	}
+
+	// difflib.SplitLines adds an empty line if the content ends with a new line
+	lines := strings.SplitAfter(content, "\n")
+
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+
+	lines[len(lines)-1] += "\n"
+
+	return lines
+}
+
+func (s *Sandbox) getSandboxPath(path string) string {
+	return filepath.Join(s.dir, path)
+}
+
+func (s *Sandbox) getOriginPath(sandboxPath string) string {
+	return strings.TrimPrefix(sandboxPath, s.dir)
+}
+
+func readSandboxFile(sandboxPath string, entry fs.DirEntry) (sandboxFile, bool, error) {
+	if entry.Type()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(sandboxPath)
+
+		return sandboxFile{content: target, symlink: true}, err == nil, err
+	}
+
+	if !entry.Type().IsRegular() {
+		return sandboxFile{}, false, nil
+	}
+
+	content, err := os.ReadFile(sandboxPath)
+
+	return sandboxFile{content: string(content)}, err == nil, err
+}
+
+// isPublicPem checks if all PEM blocks of the content are public, e.g. dh parameters
+func isPublicPem(content []byte) bool {
+	var hasBlocks bool
+
+	for {
+		block, rest := pem.Decode(content)
+
+		if block == nil {
+			return hasBlocks
+		}
+
+		if !slices.Contains(sandboxPublicPemTypes, block.Type) {
+			return false
+		}
+
+		hasBlocks = true
+		content = rest
+	}
+}
+
+// CreateSandbox copies the config of the webserver: the root directory, included files and symlink targets
+func CreateSandbox(webServer WebServer) (*Sandbox, error) {
+	sWebServer, ok := webServer.(sandboxWebServer)
+
+	if !ok {
+		return nil, fmt.Errorf("dry run is not supported by webserver %s", webServer.GetCode())
+	}
+
+	root, err := filepath.Abs(sWebServer.getRoot())
+
+	if err != nil {
+		return nil, err
+	}
+
+	removeStaleSandboxes()
+	tempDir, err := os.MkdirTemp("", sandboxDirPattern)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not create sandbox directory: %v", err)
+	}
+
+	// the sandbox path is resolved since parsed file paths are compared with it
+	dir, err := filepath.EvalSymlinks(tempDir)
+
+	if err != nil {
+		os.RemoveAll(tempDir)
+
+		return nil, err
+	}
+
+	sandbox := &Sandbox{
+		dir:       dir,
+		root:      root,
+		webServer: sWebServer,
+		files:     map[string]sandboxFile{},
+	}
+
+	for _, pattern := range append([]string{root}, sWebServer.GetIncludePaths()...) {
+		matches, err := filepath.Glob(pattern)
+
+		if err == nil {
+			for _, match := range matches {
+				if err = sandbox.copyPath(match); err != nil {
+					break
+				}
+			}
+		}
+
+		if err != nil {
+			sandbox.Remove()
+
+			return nil, fmt.Errorf("could not copy %s config to sandbox: %v", webServer.GetCode(), err)
+		}
+	}
+
+	return sandbox, nil
+}
+
+// removeStaleSandboxes removes sandboxes that were not removed by killed processes
+func removeStaleSandboxes() {
+	dirs, err := filepath.Glob(filepath.Join(os.TempDir(), sandboxDirPattern+"*"))
+
+	if err != nil {
+		return
+	}
+
+	for _, dir := range dirs {
+		info, err := os.Lstat(dir)
+
+		if err != nil || !info.IsDir() || time.Since(info.ModTime()) < sandboxMaxAge {
+			continue
+		}
+
+		os.RemoveAll(dir)
+	}
+}
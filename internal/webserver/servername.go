@@ -0,0 +1,224 @@
+package webserver
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/r2dtools/sslbot/internal/dto"
+)
+
+// getServerNameMatchType returns the match type of the server name or an empty string if the name is not valid
+func getServerNameMatchType(serverName string) string {
+	switch {
+	case strings.HasPrefix(serverName, "~"):
+		if _, err := regexp.Compile(serverName[1:]); err != nil {
+			return ""
+		}
+
+		return dto.MatchTypeRegex
+	case strings.HasPrefix(serverName, "*."):
+		if !isValidDomain(serverName[2:]) {
+			return ""
+		}
+
+		return dto.MatchTypeWildcardPrefix
+	case strings.HasPrefix(serverName, "."):
+		if !isValidDomain(serverName[1:]) {
+			return ""
+		}
+
+		return dto.MatchTypeWildcardPrefix
+	case strings.HasSuffix(serverName, ".*"):
+		// the name is completed by a top-level domain to be validated
+		if !isValidDomain(strings.TrimSuffix(serverName, "*") + "com") {
+			return ""
+		}
+
+		return dto.MatchTypeWildcardSuffix
+	case isValidDomain(serverName):
+		return dto.MatchTypeExact
+	default:
+		return ""
+	}
+}
+
+// IsExactServerName checks if the server name is a host name, not a wildcard or regex pattern
+func IsExactServerName(serverName string) bool {
+	return getServerNameMatchType(serverName) == dto.MatchTypeExact
+}
+
+// matchNginxServerName checks if the host name matches the server name and returns the match type
+func matchNginxServerName(serverName, hostName string) (string, bool) {
+	serverName = strings.ToLower(serverName)
+	hostName = strings.ToLower(hostName)
+
+	switch matchType := getServerNameMatchType(serverName); matchType {
+	case dto.MatchTypeExact:
+		return matchType, serverName == hostName
+	case dto.MatchTypeWildcardPrefix:
+		// ".example.com" matches both "example.com" and "*.example.com"
+		suffix := strings.TrimPrefix(serverName, "*")
+
+		if strings.HasPrefix(serverName, ".") && hostName == suffix[1:] {
+			return matchType, true
+		}
+
+		return matchType, strings.HasSuffix(hostName, suffix) && len(hostName) > len(suffix)
+	case dto.MatchTypeWildcardSuffix:
+		prefix := strings.TrimSuffix(serverName, "*")
+
+		return matchType, strings.HasPrefix(hostName, prefix) && len(hostName) > len(prefix)
+	case dto.MatchTypeRegex:
+		regex, err := regexp.Compile(serverName[1:])
+
+		if err != nil {
+			return matchType, false
+		}
+
+		return matchType, regex.MatchString(hostName)
+	default:
+		return matchType, false
+	}
+}
+
+// resolveNginxVhost returns the host serving the host name on the port following nginx precedence:
+// exact name, the longest wildcard name starting with an asterisk, the longest wildcard name ending with an asterisk
+// and the first matching regular expression. The default server of the port is returned if no name matches.
+func resolveNginxVhost(vhosts []dto.VirtualHost, hostName, port string, useDefault bool) *dto.VirtualHost {
+	matches := make(map[string]*dto.VirtualHost)
+	matchLengths := make(map[string]int)
+
+	for i := range vhosts {
+		vhost := &vhosts[i]
+
+		if !isVhostListenPort(vhost, port) {
+			continue
+		}
+
+		for _, serverName := range append([]string{vhost.ServerName}, vhost.Aliases...) {
+			matchType, ok := matchNginxServerName(serverName, hostName)
+
+			if !ok {
+				continue
+			}
+
+			if _, exists := matches[matchType]; exists {
+				// the first regex wins, for wildcards the longest one wins
+				if matchType == dto.MatchTypeRegex || len(serverName) <= matchLengths[matchType] {
+					continue
+				}
+			}
+
+			matches[matchType] = vhost
+			matchLengths[matchType] = len(serverName)
+		}
+	}
+
+	for _, matchType := range []string{dto.MatchTypeExact, dto.MatchTypeWildcardPrefix, dto.MatchTypeWildcardSuffix, dto.MatchTypeRegex} {
+		if vhost, ok := matches[matchType]; ok {
+			return vhost
+		}
+	}
+
+	if useDefault {
+		return getDefaultVhost(vhosts, port)
+	}
+
+	return nil
+}
+
+// resolveApacheVhost returns the first host with the server name or alias matching the host name on the port.
+// Aliases may contain "*" and "?" wildcards. The default server of the port is returned if no name matches.
+func resolveApacheVhost(vhosts []dto.VirtualHost, hostName, port string, useDefault bool) *dto.VirtualHost {
+	hostName = strings.ToLower(hostName)
+
+	for i := range vhosts {
+		vhost := &vhosts[i]
+
+		if !isVhostListenPort(vhost, port) {
+			continue
+		}
+
+		if strings.EqualFold(vhost.ServerName, hostName) {
+			return vhost
+		}
+
+		for _, alias := range vhost.Aliases {
+			if matched, _ := path.Match(strings.ToLower(alias), hostName); matched {
+				return vhost
+			}
+		}
+	}
+
+	if useDefault {
+		return getDefaultVhost(vhosts, port)
+	}
+
+	return nil
+}
+
+func getDefaultVhost(vhosts []dto.VirtualHost, port string) *dto.VirtualHost {
+	for i := range vhosts {
+		for _, address := range vhosts[i].Addresses {
+			if address.DefaultServer && (port == "" || address.Port == port) {
+				return &vhosts[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// markDefaultServers marks the first host of every address as the default one if the address has no explicit default host.
+// Hosts must be in config order.
+func markDefaultServers(vhosts []dto.VirtualHost) {
+	defaults := make(map[string]bool)
+
+	for _, vhost := range vhosts {
+		for _, address := range vhost.Addresses {
+			if address.DefaultServer {
+				defaults[getAddressKey(address)] = true
+			}
+		}
+	}
+
+	for i := range vhosts {
+		for j, address := range vhosts[i].Addresses {
+			key := getAddressKey(address)
+
+			if !defaults[key] {
+				vhosts[i].Addresses[j].DefaultServer = true
+				defaults[key] = true
+			}
+		}
+	}
+}
+
+func getAddressKey(address dto.VirtualHostAddress) string {
+	host := address.Host
+
+	if host == "*" || host == "0.0.0.0" || host == "_default_" || host == "[::]" {
+		host = ""
+	}
+
+	if address.IsIpv6 {
+		return "[" + host + "]:" + address.Port
+	}
+
+	return host + ":" + address.Port
+}
+
+func isVhostListenPort(vhost *dto.VirtualHost, port string) bool {
+	if port == "" {
+		return true
+	}
+
+	for _, address := range vhost.Addresses {
+		if address.Port == port {
+			return true
+		}
+	}
+
+	return false
+}
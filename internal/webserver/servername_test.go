@@ -0,0 +1,129 @@
+//go:build common
+
+package webserver
+
+import (
+	"testing"
+
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetServerNameMatchType(t *testing.T) {
+	items := []struct {
+		serverName string
+		matchType  string
+	}{
+		{"example.com", dto.MatchTypeExact},
+		{"*.example.com", dto.MatchTypeWildcardPrefix},
+		{".example.com", dto.MatchTypeWildcardPrefix},
+		{"www.example.*", dto.MatchTypeWildcardSuffix},
+		{`~^(?<user>.+)\.example\.net$`, dto.MatchTypeRegex},
+		{"_", ""},
+		{"localhost", ""},
+		{"*", ""},
+		{"~^(.+$", ""},
+	}
+
+	for _, item := range items {
+		assert.Equal(t, item.matchType, getServerNameMatchType(item.serverName), item.serverName)
+	}
+}
+
+func TestResolveNginxVhost(t *testing.T) {
+	vhosts := []dto.VirtualHost{
+		getTestVhost(`~^(www\.)?.+\.example\.com$`, "80", false),
+		getTestVhost("www.example.*", "80", false),
+		getTestVhost("*.example.com", "80", false),
+		getTestVhost("*.mail.example.com", "80", false),
+		getTestVhost(".example.org", "80", false),
+		getTestVhost("example.com", "80", false),
+		getTestVhost("default.com", "80", true),
+		getTestVhost("ssl.example.net", "443", true),
+	}
+
+	items := []struct {
+		hostName   string
+		port       string
+		serverName string
+	}{
+		{"example.com", "", "example.com"},
+		{"EXAMPLE.com", "", "example.com"},
+		{"www.example.com", "", "*.example.com"},
+		{"smtp.mail.example.com", "", "*.mail.example.com"},
+		{"example.org", "", ".example.org"},
+		{"www.example.org", "", ".example.org"},
+		{"www.example.net", "", "www.example.*"},
+		{"ssl.example.net", "", "ssl.example.net"},
+		{"ssl.example.net", "80", "default.com"},
+		{"unknown.com", "80", "default.com"},
+		{"unknown.com", "443", "ssl.example.net"},
+	}
+
+	for _, item := range items {
+		vhost := resolveNginxVhost(vhosts, item.hostName, item.port, true)
+
+		if assert.NotNil(t, vhost, item.hostName) {
+			assert.Equal(t, item.serverName, vhost.ServerName, item.hostName)
+		}
+	}
+
+	assert.Nil(t, resolveNginxVhost(vhosts, "unknown.com", "80", false))
+	assert.Nil(t, resolveNginxVhost(vhosts, "unknown.com", "8080", true))
+
+	vhosts = []dto.VirtualHost{
+		getTestVhost(`~^www\.`, "80", false),
+		getTestVhost(`~^www\.example\.`, "80", false),
+	}
+	vhost := resolveNginxVhost(vhosts, "www.example.com", "", false)
+	assert.NotNil(t, vhost)
+	assert.Equal(t, `~^www\.`, vhost.ServerName)
+}
+
+func TestResolveApacheVhost(t *testing.T) {
+	vhosts := []dto.VirtualHost{
+		getTestVhost("example.com", "80", false),
+		getTestVhost("example.org", "80", false),
+	}
+	vhosts[0].Aliases = []string{"*.example.com"}
+	vhosts[1].Aliases = []string{"www.example.???", "*.example.com"}
+	markDefaultServers(vhosts)
+
+	vhost := resolveApacheVhost(vhosts, "mail.example.com", "", false)
+	assert.NotNil(t, vhost)
+	assert.Equal(t, "example.com", vhost.ServerName)
+
+	vhost = resolveApacheVhost(vhosts, "www.example.net", "", false)
+	assert.NotNil(t, vhost)
+	assert.Equal(t, "example.org", vhost.ServerName)
+
+	assert.Nil(t, resolveApacheVhost(vhosts, "www.example.info", "", false))
+
+	vhost = resolveApacheVhost(vhosts, "www.example.info", "80", true)
+	assert.NotNil(t, vhost)
+	assert.Equal(t, "example.com", vhost.ServerName)
+}
+
+func TestMarkDefaultServers(t *testing.T) {
+	vhosts := []dto.VirtualHost{
+		getTestVhost("example.com", "80", false),
+		getTestVhost("example.org", "80", true),
+		getTestVhost("example.net", "443", false),
+	}
+	vhosts[2].Addresses = append(vhosts[2].Addresses, dto.VirtualHostAddress{Host: "0.0.0.0", Port: "80"})
+	markDefaultServers(vhosts)
+
+	assert.False(t, vhosts[0].IsDefaultServer())
+	assert.True(t, vhosts[1].IsDefaultServer())
+	assert.True(t, vhosts[2].Addresses[0].DefaultServer)
+	assert.False(t, vhosts[2].Addresses[1].DefaultServer)
+}
+
+func getTestVhost(serverName, port string, defaultServer bool) dto.VirtualHost {
+	return dto.VirtualHost{
+		ServerName: serverName,
+		Addresses: []dto.VirtualHostAddress{
+			{Port: port, DefaultServer: defaultServer},
+		},
+	}
+}
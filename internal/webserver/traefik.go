@@ -0,0 +1,127 @@
+package webserver
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/utils"
+	"github.com/r2dtools/sslbot/internal/webserver/processmng"
+	"github.com/r2dtools/sslbot/internal/webserver/traefikconf"
+)
+
+const TraefikCertificatesFileName = "sslbot-certificates.yml"
+
+type TraefikWebServer struct {
+	Config  *traefikconf.Config
+	options map[string]string
+}
+
+type traefikCertificate struct {
+	traefikconf.Certificate
+	cert *dto.Certificate
+}
+
+func (t *TraefikWebServer) GetCode() string {
+	return WebServerTraefikCode
+}
+
+func (t *TraefikWebServer) GetVhostByName(serverName string) (*dto.VirtualHost, error) {
+	vhosts, err := t.GetVhosts()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return getVhostByName(vhosts, serverName), nil
+}
+
+// GetVhosts returns hosts of routers rules. Entry points can have any address,
+// so routers with tls are considered to listen on 443 port and other routers on 80 port.
+func (t *TraefikWebServer) GetVhosts() ([]dto.VirtualHost, error) {
+	var vhosts []dto.VirtualHost
+	certificates := t.getCertificates()
+
+	for _, router := range t.Config.GetRouters() {
+		hosts := traefikconf.ParseHosts(router.Rule)
+
+		if len(hosts) == 0 {
+			continue
+		}
+
+		address := dto.VirtualHostAddress{Port: "80", Ssl: router.Tls}
+
+		if router.Tls {
+			address.Port = "443"
+		}
+
+		vhost := dto.VirtualHost{
+			FilePath:   router.FilePath,
+			ServerName: hosts[0],
+			Aliases:    hosts[1:],
+			Ssl:        router.Tls,
+			WebServer:  getWebServerName(t.options, WebServerTraefikCode),
+			Addresses:  []dto.VirtualHostAddress{address},
+		}
+
+		if router.Tls {
+			for _, certificate := range certificates {
+				if certificate.cert != nil && utils.IsCertificateForDomain(certificate.cert, vhost.ServerName) {
+					vhost.Certificate = certificate.cert
+					vhost.CertificatePath = certificate.CertFile
+					vhost.CertificateKeyPath = certificate.KeyFile
+
+					break
+				}
+			}
+		}
+
+		vhosts = append(vhosts, vhost)
+	}
+
+	vhosts = filterVhosts(vhosts)
+	vhosts = mergeVhosts(vhosts)
+	resolveCertificateCoverage(vhosts)
+
+	return vhosts, nil
+}
+
+// GetCertificatesFilePath returns the file certificates are deployed to.
+// If the dynamic config is a directory, a separate file is used so that user files are not changed.
+func (t *TraefikWebServer) GetCertificatesFilePath() string {
+	if t.Config.IsDir() {
+		return filepath.Join(t.Config.Path, TraefikCertificatesFileName)
+	}
+
+	return t.Config.Path
+}
+
+// GetProcessManager returns nil process manager: traefik watches the dynamic config itself
+func (t *TraefikWebServer) GetProcessManager() (ProcessManager, error) {
+	return &processmng.NilProcessManager{}, nil
+}
+
+func (t *TraefikWebServer) getCertificates() []traefikCertificate {
+	var certificates []traefikCertificate
+
+	for _, certificate := range t.Config.GetCertificates() {
+		cert, _ := utils.GetCertificateFromFile(certificate.CertFile)
+		certificates = append(certificates, traefikCertificate{Certificate: certificate, cert: cert})
+	}
+
+	return certificates
+}
+
+func GetTraefikWebServer(options map[string]string) (*TraefikWebServer, error) {
+	traefikConfig, err := traefikconf.GetConfig(options[config.TraefikDynamicConfigOpt])
+
+	if err != nil {
+		return nil, fmt.Errorf("could not parse traefik config: %v", err)
+	}
+
+	return &TraefikWebServer{
+		Config:  traefikConfig,
+		options: options,
+	}, nil
+}
@@ -0,0 +1,283 @@
+package traefikconf
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+type Router struct {
+	Name     string
+	Rule     string
+	Tls      bool
+	Tcp      bool
+	FilePath string
+}
+
+type Certificate struct {
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	FilePath string `yaml:"-"`
+}
+
+type File struct {
+	Path string
+	Data map[string]any
+}
+
+// Config is the dynamic configuration of the file provider: a single file or a directory with files
+type Config struct {
+	Path  string
+	Files []*File
+}
+
+func (c *Config) IsDir() bool {
+	info, err := os.Stat(c.Path)
+
+	return err == nil && info.IsDir()
+}
+
+func (c *Config) GetRouters() []Router {
+	var routers []Router
+
+	for _, file := range c.Files {
+		for _, protocol := range []string{"http", "tcp"} {
+			for name, value := range getMap(getMap(file.Data, protocol), "routers") {
+				routerData, ok := value.(map[string]any)
+
+				if !ok {
+					continue
+				}
+
+				rule, _ := routerData["rule"].(string)
+				_, hasTls := routerData["tls"]
+				routers = append(routers, Router{
+					Name:     name,
+					Rule:     rule,
+					Tls:      hasTls,
+					Tcp:      protocol == "tcp",
+					FilePath: file.Path,
+				})
+			}
+		}
+	}
+
+	slices.SortFunc(routers, func(a, b Router) int {
+		return strings.Compare(a.FilePath+a.Name, b.FilePath+b.Name)
+	})
+
+	return routers
+}
+
+func (c *Config) GetCertificates() []Certificate {
+	var certificates []Certificate
+
+	for _, file := range c.Files {
+		items, _ := getMap(file.Data, "tls")["certificates"].([]any)
+
+		for _, item := range items {
+			certData, ok := item.(map[string]any)
+
+			if !ok {
+				continue
+			}
+
+			certFile, _ := certData["certFile"].(string)
+			keyFile, _ := certData["keyFile"].(string)
+			certificates = append(certificates, Certificate{CertFile: certFile, KeyFile: keyFile, FilePath: file.Path})
+		}
+	}
+
+	return certificates
+}
+
+func (c *Config) GetFileCertificates(filePath string) []Certificate {
+	var certificates []Certificate
+
+	for _, certificate := range c.GetCertificates() {
+		if certificate.FilePath == filePath {
+			certificates = append(certificates, certificate)
+		}
+	}
+
+	return certificates
+}
+
+func GetConfig(path string) (*Config, error) {
+	config := &Config{Path: path}
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read traefik dynamic config: %v", err)
+	}
+
+	if !info.IsDir() {
+		file, err := parseFile(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		config.Files = append(config.Files, file)
+
+		return config, nil
+	}
+
+	err = filepath.WalkDir(path, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || !isConfigFile(filePath) {
+			return nil
+		}
+
+		file, err := parseFile(filePath)
+
+		if err != nil {
+			return err
+		}
+
+		config.Files = append(config.Files, file)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read traefik dynamic config: %v", err)
+	}
+
+	return config, nil
+}
+
+// ParseHosts returns host names of Host() and HostSNI() matchers of the rule
+func ParseHosts(rule string) []string {
+	var hosts []string
+	matcherRegex := regexp.MustCompile(`Host(?:SNI)?\(([^)]*)\)`)
+	valueRegex := regexp.MustCompile("[`\"]([^`\"]+)[`\"]")
+
+	for _, match := range matcherRegex.FindAllStringSubmatch(rule, -1) {
+		for _, value := range valueRegex.FindAllStringSubmatch(match[1], -1) {
+			host := strings.ToLower(strings.TrimSpace(value[1]))
+
+			if host != "" && host != "*" && !slices.Contains(hosts, host) {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+
+	return hosts
+}
+
+// WriteCertificates replaces tls.certificates list of the yaml file. Other file content is kept.
+// The file is created if it does not exist.
+func WriteCertificates(filePath string, certificates []Certificate) error {
+	if !isYamlFile(filePath) {
+		return fmt.Errorf("only yaml traefik config can be changed: %s", filePath)
+	}
+
+	document := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	content, err := os.ReadFile(filePath)
+
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read traefik config: %v", err)
+	}
+
+	if len(strings.TrimSpace(string(content))) > 0 {
+		if err := yaml.Unmarshal(content, document); err != nil {
+			return fmt.Errorf("could not parse traefik config: %v", err)
+		}
+	}
+
+	root := document.Content[0]
+
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid traefik config: %s", filePath)
+	}
+
+	tlsNode := getOrCreateMappingValue(root, "tls")
+	certificatesNode := &yaml.Node{}
+
+	if err := certificatesNode.Encode(certificates); err != nil {
+		return err
+	}
+
+	setMappingValue(tlsNode, "certificates", certificatesNode)
+	content, err = yaml.Marshal(document)
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, content, 0644)
+}
+
+func parseFile(filePath string) (*File, error) {
+	content, err := os.ReadFile(filePath)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read traefik config %s: %v", filePath, err)
+	}
+
+	data := map[string]any{}
+
+	if isYamlFile(filePath) {
+		err = yaml.Unmarshal(content, &data)
+	} else {
+		err = toml.Unmarshal(content, &data)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not parse traefik config %s: %v", filePath, err)
+	}
+
+	return &File{Path: filePath, Data: data}, nil
+}
+
+func getMap(data map[string]any, key string) map[string]any {
+	value, _ := data[key].(map[string]any)
+
+	return value
+}
+
+func getOrCreateMappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key && node.Content[i+1].Kind == yaml.MappingNode {
+			return node.Content[i+1]
+		}
+	}
+
+	value := &yaml.Node{Kind: yaml.MappingNode}
+	setMappingValue(node, key, value)
+
+	return value
+}
+
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+
+			return
+		}
+	}
+
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+}
+
+func isConfigFile(filePath string) bool {
+	return isYamlFile(filePath) || filepath.Ext(filePath) == ".toml"
+}
+
+func isYamlFile(filePath string) bool {
+	extension := filepath.Ext(filePath)
+
+	return extension == ".yml" || extension == ".yaml"
+}
@@ -0,0 +1,61 @@
+//go:build common
+
+package traefikconf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHosts(t *testing.T) {
+	assert.Equal(t, []string{"example.com", "www.example.com"}, ParseHosts("Host(`example.com`) || Host(`www.example.com`) && PathPrefix(`/api`)"))
+	assert.Equal(t, []string{"example.com", "www.example.com"}, ParseHosts("Host(`example.com`, `www.example.com`)"))
+	assert.Equal(t, []string{"example.com"}, ParseHosts("HostSNI(`example.com`)"))
+	assert.Empty(t, ParseHosts("HostSNI(`*`)"))
+	assert.Empty(t, ParseHosts("PathPrefix(`/`)"))
+}
+
+func TestGetConfig(t *testing.T) {
+	dir := t.TempDir()
+	yamlConfig := "http:\n  routers:\n    app:\n      rule: Host(`example.com`)\n      tls: {}\n    web:\n      rule: Host(`example2.com`)\ntls:\n  certificates:\n    - certFile: /certs/example.com.crt\n      keyFile: /certs/example.com.key\n"
+	tomlConfig := "[tcp.routers.db]\nrule = \"HostSNI(`db.example.com`)\"\n[tcp.routers.db.tls]\npassthrough = true\n"
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "app.yml"), []byte(yamlConfig), 0644))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "db.toml"), []byte(tomlConfig), 0644))
+
+	config, err := GetConfig(dir)
+	require.Nil(t, err)
+	require.Len(t, config.Files, 2)
+
+	routers := config.GetRouters()
+	require.Len(t, routers, 3)
+	assert.Equal(t, Router{Name: "app", Rule: "Host(`example.com`)", Tls: true, FilePath: filepath.Join(dir, "app.yml")}, routers[0])
+	assert.False(t, routers[1].Tls)
+	assert.True(t, routers[2].Tcp)
+	assert.True(t, routers[2].Tls)
+
+	assert.Equal(t, []Certificate{{CertFile: "/certs/example.com.crt", KeyFile: "/certs/example.com.key", FilePath: filepath.Join(dir, "app.yml")}}, config.GetCertificates())
+}
+
+func TestWriteCertificates(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "dynamic.yml")
+	require.Nil(t, os.WriteFile(filePath, []byte("# routers\nhttp:\n  routers:\n    app:\n      rule: Host(`example.com`)\n"), 0644))
+
+	certificates := []Certificate{{CertFile: "/certs/example.com.crt", KeyFile: "/certs/example.com.key"}}
+	require.Nil(t, WriteCertificates(filePath, certificates))
+
+	config, err := GetConfig(filePath)
+	require.Nil(t, err)
+	require.Len(t, config.GetRouters(), 1)
+	require.Len(t, config.GetCertificates(), 1)
+	assert.Equal(t, "/certs/example.com.crt", config.GetCertificates()[0].CertFile)
+
+	content, err := os.ReadFile(filePath)
+	require.Nil(t, err)
+	assert.Contains(t, string(content), "# routers")
+
+	require.NotNil(t, WriteCertificates(filepath.Join(t.TempDir(), "dynamic.toml"), certificates))
+}
@@ -3,6 +3,7 @@ package webserver
 import (
 	"regexp"
 	"slices"
+	"strings"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/unknwon/com"
@@ -14,19 +15,23 @@ func filterVhosts(vhosts []dto.VirtualHost) []dto.VirtualHost {
 	var fVhosts []dto.VirtualHost
 
 	for _, vhost := range vhosts {
-		if !isValidDomain(vhost.ServerName) || !checkVhostPorts(vhost.Addresses, []string{"80", "443"}) {
+		matchType := getServerNameMatchType(vhost.ServerName)
+
+		if matchType == "" || !checkVhostPorts(vhost.Addresses, []string{"80", "443"}) {
 			continue
 		}
 
+		vhost.MatchType = matchType
 		fVhosts = append(fVhosts, vhost)
 	}
 
 	return fVhosts
 }
 
-// MergeVhosts merge similar vhosts. For example, vhost:443 will be merged with vhost:80
+// MergeVhosts merge similar vhosts. For example, vhost:443 will be merged with vhost:80. The order of hosts is kept.
 func mergeVhosts(vhosts []dto.VirtualHost) []dto.VirtualHost {
 	var fVhosts []dto.VirtualHost
+	var serverNames []string
 	vhostsMap := make(map[string]dto.VirtualHost)
 
 	for _, vhost := range vhosts {
@@ -59,16 +64,19 @@ func mergeVhosts(vhosts []dto.VirtualHost) []dto.VirtualHost {
 
 			if existedVhost.Certificate == nil {
 				existedVhost.Certificate = vhost.Certificate
+				existedVhost.CertificatePath = vhost.CertificatePath
+				existedVhost.CertificateKeyPath = vhost.CertificateKeyPath
 			}
 
 			vhostsMap[vhost.ServerName] = existedVhost
 		} else {
 			vhostsMap[vhost.ServerName] = vhost
+			serverNames = append(serverNames, vhost.ServerName)
 		}
 	}
 
-	for _, vhost := range vhostsMap {
-		fVhosts = append(fVhosts, vhost)
+	for _, serverName := range serverNames {
+		fVhosts = append(fVhosts, vhostsMap[serverName])
 	}
 
 	return fVhosts
@@ -91,3 +99,27 @@ func isValidDomain(domain string) bool {
 
 	return match
 }
+
+// IsDirectiveInBlock checks if the directive is set in the block itself, not in an included file.
+// Comments are skipped. If values are passed, the directive must have all of them.
+func IsDirectiveInBlock(blockContent, name string, values ...string) bool {
+	for _, line := range strings.Split(blockContent, "\n") {
+		fields := strings.Fields(line)
+
+		if len(fields) == 0 || !strings.EqualFold(fields[0], name) {
+			continue
+		}
+
+		hasValues := !slices.ContainsFunc(values, func(value string) bool {
+			return !slices.ContainsFunc(fields[1:], func(field string) bool {
+				return strings.EqualFold(strings.Trim(field, `"';`), value)
+			})
+		})
+
+		if hasValues {
+			return true
+		}
+	}
+
+	return false
+}
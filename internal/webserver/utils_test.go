@@ -83,3 +83,14 @@ func TestMergeVHosts(t *testing.T) {
 	assert.Len(t, mergedHost.Addresses, 4)
 	assert.Equal(t, cert, mergedHost.Certificate)
 }
+
+func TestIsDirectiveInBlock(t *testing.T) {
+	content := "server {\n\t# Read up on ssl_ciphers\n\tinclude ssl.conf;\n\tssl_protocols TLSv1.3;\n\t#add_header Strict-Transport-Security \"max-age=300\";\n}"
+	assert.True(t, IsDirectiveInBlock(content, "ssl_protocols"))
+	assert.False(t, IsDirectiveInBlock(content, "ssl_ciphers"))
+	assert.False(t, IsDirectiveInBlock(content, "add_header", "Strict-Transport-Security"))
+
+	content = "<VirtualHost *:443>\n\tHeader always set X-Frame-Options DENY\n\tHeader always set Strict-Transport-Security \"max-age=300\"\n</VirtualHost>"
+	assert.True(t, IsDirectiveInBlock(content, "header", "strict-transport-security"))
+	assert.False(t, IsDirectiveInBlock(content, "Header", "Content-Security-Policy"))
+}
@@ -1,14 +1,23 @@
 package webserver
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 
+	"github.com/r2dtools/sslbot/config"
 	"github.com/r2dtools/sslbot/internal/dto"
+	"github.com/r2dtools/sslbot/internal/webserver/processmng"
+	"github.com/unknwon/com"
 )
 
 const (
-	WebServerNginxCode  = "nginx"
-	WebServerApacheCode = "apache"
+	WebServerNginxCode    = "nginx"
+	WebServerApacheCode   = "apache"
+	WebServerHAProxyCode  = "haproxy"
+	WebServerLighttpdCode = "lighttpd"
+	WebServerTraefikCode  = "traefik"
 )
 
 type HostManager interface {
@@ -20,8 +29,37 @@ type ProcessManager interface {
 	Reload() error
 }
 
+// nilProcessManager does nothing: the sandbox config is never served or the webserver is reloaded by the caller
+type nilProcessManager struct{}
+
+func (m *nilProcessManager) Reload() error {
+	return nil
+}
+
+// reloadPreventer is implemented by webservers which reload can be skipped
+type reloadPreventer interface {
+	preventReload()
+}
+
+// PreventReload makes the process manager of the webserver skip reloads, e.g. if the caller reloads the webserver later.
+// Config changes are still tested. false is returned if the webserver does not support it.
+func PreventReload(webServer WebServer) bool {
+	preventer, ok := webServer.(reloadPreventer)
+
+	if ok {
+		preventer.preventReload()
+	}
+
+	return ok
+}
+
 func GetSupportedWebServers() []string {
-	return []string{WebServerNginxCode, WebServerApacheCode}
+	return []string{WebServerNginxCode, WebServerApacheCode, WebServerHAProxyCode, WebServerLighttpdCode, WebServerTraefikCode}
+}
+
+// GetWebServers returns supported webservers and names of the configured webserver instances
+func GetWebServers(options map[string]string) []string {
+	return append(GetSupportedWebServers(), config.GetWebServerInstanceNames(options)...)
 }
 
 type WebServer interface {
@@ -31,15 +69,130 @@ type WebServer interface {
 	GetProcessManager() (ProcessManager, error)
 }
 
+// ConfigTester is implemented by webservers that can check config syntax before reload
+type ConfigTester interface {
+	TestConfig() error
+}
+
+// TestConfig checks config syntax of the webserver. Webservers without config test are considered valid.
+func TestConfig(webServer WebServer) error {
+	tester, ok := webServer.(ConfigTester)
+
+	if !ok {
+		return nil
+	}
+
+	if err := tester.TestConfig(); err != nil {
+		return fmt.Errorf("%s config test failed: %v", webServer.GetCode(), err)
+	}
+
+	return nil
+}
+
+// ConfigFileWebServer is implemented by webservers which config files are changed in memory and saved explicitly
+type ConfigFileWebServer interface {
+	WebServer
+	SaveConfigFiles(filePaths []string) error
+}
+
+// VhostResolver is implemented by webservers that select a host by server name precedence and default server
+type VhostResolver interface {
+	ResolveVhost(hostName, port string) (*dto.VirtualHost, error)
+}
+
+// ResolveVhost returns the host the webserver selects for the host name on the port.
+// Hosts of webservers without server name precedence are looked up by name.
+func ResolveVhost(webServer WebServer, hostName, port string) (*dto.VirtualHost, error) {
+	resolver, ok := webServer.(VhostResolver)
+
+	if !ok {
+		return webServer.GetVhostByName(hostName)
+	}
+
+	return resolver.ResolveVhost(hostName, port)
+}
+
+// CertificateVhostWebServer is implemented by webservers whose hosts can use a certificate on any port
+type CertificateVhostWebServer interface {
+	// GetCertificateVhosts returns all hosts with a certificate. Hosts are not filtered by port and server name.
+	GetCertificateVhosts() ([]dto.VirtualHost, error)
+}
+
+// GetCertificateVhosts returns hosts that reference a certificate, e.g. to check if a certificate is still used
+func GetCertificateVhosts(webServer WebServer) ([]dto.VirtualHost, error) {
+	if certWebServer, ok := webServer.(CertificateVhostWebServer); ok {
+		return certWebServer.GetCertificateVhosts()
+	}
+
+	vhosts, err := webServer.GetVhosts()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var certVhosts []dto.VirtualHost
+
+	for _, vhost := range vhosts {
+		if vhost.CertificatePath != "" {
+			certVhosts = append(certVhosts, vhost)
+		}
+	}
+
+	return certVhosts, nil
+}
+
+// IsWebServerInstalled checks if the main config file of the webserver or the webserver instance exists.
+// A webserver that is installed but can not be created has a broken config.
+func IsWebServerInstalled(webServerCode string, options map[string]string) bool {
+	if code, instanceOptions, ok := config.GetWebServerInstanceOptions(webServerCode, options); ok {
+		webServerCode = code
+		options = instanceOptions
+	}
+
+	switch webServerCode {
+	case WebServerNginxCode:
+		return com.IsFile(filepath.Join(options[config.NginxRootOpt], "nginx.conf"))
+	case WebServerApacheCode:
+		return getApacheConfigFilePath(options[config.ApacheRootOpt]) != ""
+	case WebServerHAProxyCode:
+		return com.IsExist(options[config.HAProxyConfigOpt])
+	case WebServerLighttpdCode:
+		return com.IsExist(options[config.LighttpdConfigOpt])
+	case WebServerTraefikCode:
+		return com.IsExist(options[config.TraefikDynamicConfigOpt])
+	}
+
+	return false
+}
+
+// StreamWebServer is implemented by webservers that proxy TCP/TLS streams
+type StreamWebServer interface {
+	WebServer
+	GetStreamServers() ([]dto.StreamServer, error)
+	GetStreamServer(key string) (*dto.StreamServer, error)
+}
+
+// CreateWebServer creates the webserver by its code or by the name of a webserver instance
 func CreateWebServer(webServerCode string, options map[string]string) (WebServer, error) {
 	var webServer WebServer
 	var err error
 
+	if code, instanceOptions, ok := config.GetWebServerInstanceOptions(webServerCode, options); ok {
+		webServerCode = code
+		options = instanceOptions
+	}
+
 	switch webServerCode {
 	case WebServerNginxCode:
 		webServer, err = GetNginxWebServer(options)
 	case WebServerApacheCode:
 		webServer, err = GetApacheWebServer(options)
+	case WebServerHAProxyCode:
+		webServer, err = GetHAProxyWebServer(options)
+	case WebServerLighttpdCode:
+		webServer, err = GetLighttpdWebServer(options)
+	case WebServerTraefikCode:
+		webServer, err = GetTraefikWebServer(options)
 	default:
 		err = fmt.Errorf("webserver '%s' is not supported", webServerCode)
 	}
@@ -47,6 +200,60 @@ func CreateWebServer(webServerCode string, options map[string]string) (WebServer
 	return webServer, err
 }
 
+// getWebServerName returns the instance name if the webserver is created for an instance, otherwise the webserver code
+func getWebServerName(options map[string]string, code string) string {
+	if name := options[config.WebServerInstanceOpt]; name != "" {
+		return name
+	}
+
+	return code
+}
+
+// getInstanceProcessManager returns the process manager that runs the reload command of the webserver instance
+func getInstanceProcessManager(options map[string]string) (ProcessManager, bool) {
+	if command := options[config.WebServerInstanceReloadCommandOpt]; command != "" {
+		return processmng.GetCommandProcessManager(command), true
+	}
+
+	return nil, false
+}
+
+// getStrategyProcessManager returns the process manager for reload strategies common for all webservers.
+// false is returned if the strategy is webserver specific.
+func getStrategyProcessManager(strategy, systemdUnit, command string) (ProcessManager, bool, error) {
+	switch strategy {
+	case processmng.ReloadStrategySystemctl:
+		return processmng.GetSystemctlProcessManager(systemdUnit), true, nil
+	case processmng.ReloadStrategyCommand:
+		if command == "" {
+			return nil, true, errors.New("reload command is not specified")
+		}
+
+		return processmng.GetCommandProcessManager(command), true, nil
+	}
+
+	return nil, false, nil
+}
+
+// resolvePidFile returns the absolute path of the pid file if it exists, relative paths are relative to the webserver root
+func resolvePidFile(root, pidFile string) string {
+	pidFile = strings.Trim(pidFile, "\"'")
+
+	if pidFile == "" {
+		return ""
+	}
+
+	if !filepath.IsAbs(pidFile) {
+		pidFile = filepath.Join(root, pidFile)
+	}
+
+	if !com.IsFile(pidFile) {
+		return ""
+	}
+
+	return pidFile
+}
+
 func getVhostByName(vhosts []dto.VirtualHost, serverName string) *dto.VirtualHost {
 	for _, vhost := range vhosts {
 		if vhost.ServerName == serverName {
@@ -0,0 +1,141 @@
+//go:build common
+
+package webserver
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/r2dtools/sslbot/config"
+	"github.com/r2dtools/sslbot/internal/webserver/processmng"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWebServerInstance(t *testing.T) {
+	dir := t.TempDir()
+	nginxConf := "events {}\n\nhttp {\n    server {\n        listen 80;\n        server_name internal.example.com;\n    }\n}\n"
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(nginxConf), 0644))
+
+	options := map[string]string{
+		config.NginxRootOpt: "/not/existing",
+		config.WebServerInstancesOpt + ".internal.webserver":      WebServerNginxCode,
+		config.WebServerInstancesOpt + ".internal.root":           dir,
+		config.WebServerInstancesOpt + ".internal.reload_command": "true",
+	}
+	require.Equal(t, []string{"internal"}, config.GetWebServerInstanceNames(options))
+	require.Contains(t, GetWebServers(options), "internal")
+
+	webServer, err := CreateWebServer("internal", options)
+	require.Nil(t, err)
+	require.Equal(t, WebServerNginxCode, webServer.GetCode())
+
+	vhost, err := webServer.GetVhostByName("internal.example.com")
+	require.Nil(t, err)
+	require.NotNil(t, vhost)
+	require.Equal(t, "internal", vhost.WebServer)
+
+	processManager, err := webServer.GetProcessManager()
+	require.Nil(t, err)
+	require.Nil(t, processManager.Reload())
+
+	_, err = CreateWebServer(WebServerNginxCode, options)
+	require.NotNil(t, err)
+}
+
+func TestNginxReloadStrategies(t *testing.T) {
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "nginx.pid")
+	nginxConf := "pid " + pidFile + ";\nevents {}\n"
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte(nginxConf), 0644))
+	require.Nil(t, os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644))
+
+	options := map[string]string{config.NginxRootOpt: dir}
+	webServer, err := GetNginxWebServer(options)
+	require.Nil(t, err)
+	require.Equal(t, pidFile, webServer.getPidFile())
+
+	processManager, err := webServer.GetProcessManager()
+	require.Nil(t, err)
+	require.IsType(t, &processmng.NginxProcessManager{}, processManager)
+
+	options[config.NginxReloadStrategyOpt] = processmng.ReloadStrategyNginx
+	processManager, err = webServer.GetProcessManager()
+	require.Nil(t, err)
+	require.Equal(t, "nginx -c "+filepath.Join(dir, "nginx.conf")+" -s reload", processManager.(*processmng.CommandProcessManager).String())
+
+	options[config.NginxReloadStrategyOpt] = processmng.ReloadStrategyCommand
+	_, err = webServer.GetProcessManager()
+	require.NotNil(t, err)
+
+	options[config.NginxReloadCommandOpt] = "echo 'invalid config' >&2; exit 1"
+	processManager, err = webServer.GetProcessManager()
+	require.Nil(t, err)
+
+	err = processManager.Reload()
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "invalid config")
+
+	options[config.NginxReloadStrategyOpt] = "unknown"
+	_, err = webServer.GetProcessManager()
+	require.NotNil(t, err)
+}
+
+func TestGetApacheEnvVars(t *testing.T) {
+	dir := t.TempDir()
+	envVars := "unset HOME\nexport APACHE_RUN_DIR=/var/run/apache2$SUFFIX\nexport APACHE_PID_FILE=${APACHE_RUN_DIR}/apache2.pid\n"
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "envvars"), []byte(envVars), 0644))
+
+	vars := getApacheEnvVars(dir)
+	require.Equal(t, "/var/run/apache2/apache2.pid", vars["APACHE_PID_FILE"])
+}
+
+func TestNginxTestConfig(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "nginx.conf"), []byte("events {}\n"), 0644))
+
+	options := map[string]string{config.NginxRootOpt: dir, config.NginxBinOpt: "true"}
+	webServer, err := GetNginxWebServer(options)
+	require.Nil(t, err)
+	require.Nil(t, TestConfig(webServer))
+
+	script := filepath.Join(dir, "nginx-test.sh")
+	require.Nil(t, os.WriteFile(script, []byte("#!/bin/sh\necho 'unknown directive \"foo\"' >&2\nexit 1\n"), 0755))
+	options[config.NginxBinOpt] = script
+
+	err = TestConfig(webServer)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unknown directive")
+}
+
+func TestHAProxyAndLighttpdTestConfig(t *testing.T) {
+	dir := t.TempDir()
+	haproxyConfig := filepath.Join(dir, "haproxy.cfg")
+	require.Nil(t, os.WriteFile(haproxyConfig, []byte("frontend web\n    bind :80\n"), 0644))
+	lighttpdConfig := filepath.Join(dir, "lighttpd.conf")
+	require.Nil(t, os.WriteFile(lighttpdConfig, []byte("server.port = 80\n"), 0644))
+
+	script := filepath.Join(dir, "test.sh")
+	require.Nil(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"invalid config $*\" >&2\nexit 1\n"), 0755))
+
+	haproxyOptions := map[string]string{config.HAProxyConfigOpt: haproxyConfig, config.HAProxyBinOpt: "true"}
+	haproxyWebServer, err := GetHAProxyWebServer(haproxyOptions)
+	require.Nil(t, err)
+	require.Nil(t, TestConfig(haproxyWebServer))
+
+	haproxyOptions[config.HAProxyBinOpt] = script
+	err = TestConfig(haproxyWebServer)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "invalid config -c -f "+haproxyConfig)
+
+	lighttpdOptions := map[string]string{config.LighttpdConfigOpt: lighttpdConfig, config.LighttpdBinOpt: "true"}
+	lighttpdWebServer, err := GetLighttpdWebServer(lighttpdOptions)
+	require.Nil(t, err)
+	require.Nil(t, TestConfig(lighttpdWebServer))
+
+	lighttpdOptions[config.LighttpdBinOpt] = script
+	err = TestConfig(lighttpdWebServer)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "invalid config -tt -f "+lighttpdConfig)
+}